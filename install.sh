@@ -88,7 +88,10 @@ compile_program() {
         exit 1
     fi
     
-    go build -o monitor main.go
+    VERSION=$(git describe --tags --always --dirty 2>/dev/null || echo dev)
+    COMMIT=$(git rev-parse --short HEAD 2>/dev/null || echo unknown)
+    DATE=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+    go build -ldflags "-X main.version=$VERSION -X main.commit=$COMMIT -X main.date=$DATE" -o monitor main.go
     
     if [ ! -f "monitor" ]; then
         echo "Kompilierung fehlgeschlagen!"
@@ -160,7 +163,7 @@ Type=simple
 User=$SERVICE_USER
 Group=$SERVICE_GROUP
 WorkingDirectory=/opt/grundstueckverkehrsgesetz
-ExecStart=/usr/local/bin/grundstueckverkehrsgesetz-monitor --loop
+ExecStart=/usr/local/bin/grundstueckverkehrsgesetz-monitor run --loop
 Restart=always
 RestartSec=10
 StandardOutput=journal
@@ -0,0 +1,592 @@
+// Package fakeserver stellt nachgebaute Lemmy-, Mastodon-, Bluesky-, Telegram- und Discord-Server für Tests bereit.
+// Die Server implementieren nur die Endpunkte, die der Monitor verwendet, und
+// erlauben es, gezielt Fehler zu injizieren. Das Paket wird nicht in das Binary gebaut.
+package fakeserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// failure beschreibt einen injizierten Fehler für einen Endpunkt
+type failure struct {
+	status int
+	times  int // Anzahl der verbleibenden Fehlschläge, -1 = dauerhaft
+}
+
+// injector verwaltet injizierte Fehler pro Pfad
+type injector struct {
+	mu       sync.Mutex
+	failures map[string]*failure
+}
+
+// FailNext lässt die nächsten times Anfragen an path mit status fehlschlagen (times < 0: dauerhaft)
+func (in *injector) FailNext(path string, status, times int) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.failures == nil {
+		in.failures = map[string]*failure{}
+	}
+	in.failures[path] = &failure{status: status, times: times}
+}
+
+// fail prüft, ob für path ein Fehler injiziert wurde, und schreibt gegebenenfalls die Fehlerantwort
+func (in *injector) fail(w http.ResponseWriter, path string) bool {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	f, ok := in.failures[path]
+	if !ok || f.times == 0 {
+		return false
+	}
+	if f.times > 0 {
+		f.times--
+	}
+	http.Error(w, fmt.Sprintf(`{"error":"injected failure %d"}`, f.status), f.status)
+	return true
+}
+
+// LemmyPost ist ein vom Fake-Lemmy empfangener Post
+type LemmyPost struct {
+	Name        string `json:"name"`
+	Body        string `json:"body"`
+	URL         string `json:"url"`
+	CommunityID int    `json:"community_id"`
+	LanguageID  int    `json:"language_id"`
+}
+
+// Lemmy ist ein nachgebauter Lemmy-Server
+type Lemmy struct {
+	*httptest.Server
+	injector
+
+	Community   string
+	CommunityID int
+	Jwt         string
+	// LookupMissing lässt /api/v3/community eine Antwort ohne Community liefern, wie manche
+	// neuere Instanzen; gefunden wird die Community dann nur über /api/v3/search
+	LookupMissing bool
+	// BodyAuthOnly lehnt den Authorization-Header ab und akzeptiert das JWT nur im Feld auth,
+	// wie ältere Lemmy-Versionen
+	BodyAuthOnly bool
+
+	mu    sync.Mutex
+	posts []LemmyPost
+}
+
+// NewLemmy startet einen Fake-Lemmy mit einer Community
+func NewLemmy(community string, communityID int) *Lemmy {
+	l := &Lemmy{Community: community, CommunityID: communityID, Jwt: "fake-lemmy-jwt"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/user/login", l.handleLogin)
+	mux.HandleFunc("/api/v3/community", l.handleCommunity)
+	mux.HandleFunc("/api/v3/search", l.handleSearch)
+	mux.HandleFunc("/api/v3/post", l.handlePost)
+	mux.HandleFunc("/api/v3/site", l.handleSite)
+	l.Server = httptest.NewServer(mux)
+	return l
+}
+
+// Posts gibt alle bisher empfangenen Posts zurück
+func (l *Lemmy) Posts() []LemmyPost {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]LemmyPost(nil), l.posts...)
+}
+
+// authorized prüft das JWT aus dem Header bzw. bei BodyAuthOnly aus dem Feld auth
+func (l *Lemmy) authorized(r *http.Request, bodyAuth string) bool {
+	if l.BodyAuthOnly {
+		return bodyAuth == l.Jwt
+	}
+	return r.Header.Get("Authorization") == "Bearer "+l.Jwt
+}
+
+func (l *Lemmy) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if l.fail(w, r.URL.Path) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"jwt": l.Jwt})
+}
+
+func (l *Lemmy) handleCommunity(w http.ResponseWriter, r *http.Request) {
+	if l.fail(w, r.URL.Path) {
+		return
+	}
+	if r.URL.Query().Get("name") != l.Community {
+		http.Error(w, `{"error":"couldnt_find_community"}`, http.StatusNotFound)
+		return
+	}
+	if l.LookupMissing {
+		writeJSON(w, map[string]interface{}{"moderators": []interface{}{}})
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"community_view": map[string]interface{}{
+			"community": map[string]interface{}{"id": l.CommunityID, "name": l.Community},
+		},
+	})
+}
+
+func (l *Lemmy) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if l.fail(w, r.URL.Path) {
+		return
+	}
+	communities := []map[string]interface{}{}
+	if r.URL.Query().Get("type_") == "Communities" && strings.Contains(l.Community, r.URL.Query().Get("q")) {
+		communities = append(communities, map[string]interface{}{
+			"community": map[string]interface{}{
+				"id": l.CommunityID, "name": l.Community, "local": true, "actor_id": l.URL + "/c/" + l.Community,
+			},
+		})
+	}
+	writeJSON(w, map[string]interface{}{"type_": "Communities", "communities": communities})
+}
+
+func (l *Lemmy) handlePost(w http.ResponseWriter, r *http.Request) {
+	if l.fail(w, r.URL.Path) {
+		return
+	}
+	var req struct {
+		LemmyPost
+		Auth string `json:"auth"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !l.authorized(r, req.Auth) {
+		http.Error(w, `{"error":"not_logged_in"}`, http.StatusUnauthorized)
+		return
+	}
+	post := req.LemmyPost
+	l.mu.Lock()
+	l.posts = append(l.posts, post)
+	id := len(l.posts)
+	l.mu.Unlock()
+	writeJSON(w, map[string]interface{}{
+		"post_view": map[string]interface{}{
+			"post": map[string]interface{}{"id": id, "ap_id": fmt.Sprintf("%s/post/%d", l.URL, id)},
+		},
+	})
+}
+
+func (l *Lemmy) handleSite(w http.ResponseWriter, r *http.Request) {
+	if l.fail(w, r.URL.Path) {
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"site_view": map[string]interface{}{"site": map[string]interface{}{"name": "Fake Lemmy"}},
+		"all_languages": []map[string]interface{}{
+			{"id": 0, "code": "und", "name": "Undetermined"},
+			{"id": 37, "code": "en", "name": "English"},
+			{"id": 32, "code": "de", "name": "Deutsch"},
+		},
+	})
+}
+
+// MastodonStatus ist ein vom Fake-Mastodon empfangener Beitrag
+type MastodonStatus struct {
+	Status     string   `json:"status"`
+	Visibility string   `json:"visibility"`
+	MediaIDs   []string `json:"media_ids"`
+}
+
+// Mastodon ist ein nachgebauter Mastodon-Server
+type Mastodon struct {
+	*httptest.Server
+	injector
+
+	Token     string
+	ExpiresIn int
+
+	mu       sync.Mutex
+	statuses []MastodonStatus
+	media    int
+
+	// visibilities enthält die erlaubten Sichtbarkeiten, nil erlaubt alle
+	visibilities map[string]bool
+}
+
+// NewMastodon startet einen Fake-Mastodon, der token als gültiges Access Token akzeptiert
+func NewMastodon(token string) *Mastodon {
+	m := &Mastodon{Token: token}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", m.handleToken)
+	mux.HandleFunc("/api/v1/statuses", m.handleStatus)
+	mux.HandleFunc("/api/v1/instance", m.handleInstance)
+	mux.HandleFunc("/api/v1/accounts/verify_credentials", m.handleVerify)
+	mux.HandleFunc("/api/v1/media", m.handleMedia)
+	mux.HandleFunc("/api/v2/media", m.handleMedia)
+	m.Server = httptest.NewServer(mux)
+	return m
+}
+
+// NewGoToSocial startet einen Fake-GoToSocial: ohne Password-Grant und /api/v2/media,
+// mit den Sichtbarkeiten von GoToSocial
+func NewGoToSocial(token string) *Mastodon {
+	m := &Mastodon{Token: token}
+	m.visibilities = map[string]bool{"public": true, "unlisted": true, "private": true, "mutuals_only": true, "direct": true}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/statuses", m.handleStatus)
+	mux.HandleFunc("/api/v1/instance", m.handleInstance)
+	mux.HandleFunc("/api/v1/accounts/verify_credentials", m.handleVerify)
+	mux.HandleFunc("/api/v1/media", m.handleMedia)
+	m.Server = httptest.NewServer(mux)
+	return m
+}
+
+// MediaUploads gibt die Anzahl der hochgeladenen Medien zurück
+func (m *Mastodon) MediaUploads() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.media
+}
+
+// Statuses gibt alle bisher empfangenen Beiträge zurück
+func (m *Mastodon) Statuses() []MastodonStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MastodonStatus(nil), m.statuses...)
+}
+
+func (m *Mastodon) authorized(r *http.Request) bool {
+	return r.Header.Get("Authorization") == "Bearer "+m.Token
+}
+
+func (m *Mastodon) handleToken(w http.ResponseWriter, r *http.Request) {
+	if m.fail(w, r.URL.Path) {
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"access_token": m.Token,
+		"token_type":   "Bearer",
+		"expires_in":   m.ExpiresIn,
+	})
+}
+
+func (m *Mastodon) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if m.fail(w, r.URL.Path) {
+		return
+	}
+	if !m.authorized(r) {
+		http.Error(w, `{"error":"The access token is invalid"}`, http.StatusUnauthorized)
+		return
+	}
+	var status MastodonStatus
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&status); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		r.ParseForm()
+		status.Status = r.FormValue("status")
+		status.Visibility = r.FormValue("visibility")
+		status.MediaIDs = r.Form["media_ids[]"]
+	}
+	if m.visibilities != nil && status.Visibility != "" && !m.visibilities[status.Visibility] {
+		http.Error(w, `{"error":"Validation failed: visibility is invalid"}`, http.StatusUnprocessableEntity)
+		return
+	}
+	m.mu.Lock()
+	m.statuses = append(m.statuses, status)
+	id := len(m.statuses)
+	m.mu.Unlock()
+	writeJSON(w, map[string]interface{}{
+		"id":  fmt.Sprint(id),
+		"url": fmt.Sprintf("%s/@bot/%d", m.URL, id),
+	})
+}
+
+func (m *Mastodon) handleInstance(w http.ResponseWriter, r *http.Request) {
+	if m.fail(w, r.URL.Path) {
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"uri":   strings.TrimPrefix(m.URL, "http://"),
+		"title": "Fake Mastodon",
+		"configuration": map[string]interface{}{
+			"statuses": map[string]interface{}{"max_characters": 500, "characters_reserved_per_url": 23},
+		},
+	})
+}
+
+func (m *Mastodon) handleVerify(w http.ResponseWriter, r *http.Request) {
+	if m.fail(w, r.URL.Path) {
+		return
+	}
+	if !m.authorized(r) {
+		http.Error(w, `{"error":"The access token is invalid"}`, http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"id": "1", "username": "bot"})
+}
+
+func (m *Mastodon) handleMedia(w http.ResponseWriter, r *http.Request) {
+	if m.fail(w, r.URL.Path) {
+		return
+	}
+	if !m.authorized(r) {
+		http.Error(w, `{"error":"The access token is invalid"}`, http.StatusUnauthorized)
+		return
+	}
+	io.Copy(io.Discard, r.Body)
+	m.mu.Lock()
+	m.media++
+	id := m.media
+	m.mu.Unlock()
+	writeJSON(w, map[string]interface{}{"id": fmt.Sprintf("media-%d", id), "type": "image"})
+}
+
+// BlueskyRecord ist ein vom Fake-Bluesky empfangener app.bsky.feed.post-Eintrag
+type BlueskyRecord struct {
+	Text   string `json:"text"`
+	Facets []struct {
+		Index struct {
+			ByteStart int `json:"byteStart"`
+			ByteEnd   int `json:"byteEnd"`
+		} `json:"index"`
+		Features []struct {
+			Type string `json:"$type"`
+			URI  string `json:"uri"`
+		} `json:"features"`
+	} `json:"facets"`
+}
+
+// Bluesky ist ein nachgebauter Bluesky-PDS
+type Bluesky struct {
+	*httptest.Server
+	injector
+
+	Handle    string
+	Password  string
+	Did       string
+	AccessJwt string
+
+	mu      sync.Mutex
+	records []BlueskyRecord
+}
+
+// NewBluesky startet einen Fake-PDS, der handle mit password anmeldet
+func NewBluesky(handle, password string) *Bluesky {
+	b := &Bluesky{Handle: handle, Password: password, Did: "did:plc:fakebot", AccessJwt: "fake-bluesky-jwt"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xrpc/com.atproto.server.createSession", b.handleSession)
+	mux.HandleFunc("/xrpc/com.atproto.repo.createRecord", b.handleRecord)
+	b.Server = httptest.NewServer(mux)
+	return b
+}
+
+// Records gibt alle bisher empfangenen Einträge zurück
+func (b *Bluesky) Records() []BlueskyRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]BlueskyRecord(nil), b.records...)
+}
+
+func (b *Bluesky) handleSession(w http.ResponseWriter, r *http.Request) {
+	if b.fail(w, r.URL.Path) {
+		return
+	}
+	var login struct {
+		Identifier string `json:"identifier"`
+		Password   string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&login); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if login.Identifier != b.Handle || login.Password != b.Password {
+		http.Error(w, `{"error":"AuthenticationRequired"}`, http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"accessJwt": b.AccessJwt, "did": b.Did, "handle": b.Handle})
+}
+
+func (b *Bluesky) handleRecord(w http.ResponseWriter, r *http.Request) {
+	if b.fail(w, r.URL.Path) {
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+b.AccessJwt {
+		http.Error(w, `{"error":"AuthenticationRequired"}`, http.StatusUnauthorized)
+		return
+	}
+	var req struct {
+		Repo   string        `json:"repo"`
+		Record BlueskyRecord `json:"record"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	b.mu.Lock()
+	b.records = append(b.records, req.Record)
+	id := len(b.records)
+	b.mu.Unlock()
+	writeJSON(w, map[string]interface{}{
+		"uri": fmt.Sprintf("at://%s/app.bsky.feed.post/rkey%d", req.Repo, id),
+		"cid": fmt.Sprintf("cid%d", id),
+	})
+}
+
+// TelegramMessage ist eine vom Fake-Telegram empfangene Nachricht
+type TelegramMessage struct {
+	ChatID    string `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode"`
+}
+
+// Telegram ist eine nachgebaute Telegram Bot API für einen Bot und einen Kanal
+type Telegram struct {
+	*httptest.Server
+	injector
+
+	Token    string
+	ChatID   string
+	Username string // öffentlicher Name des Kanals für die Nachrichten-URL
+
+	mu       sync.Mutex
+	messages []TelegramMessage
+}
+
+// NewTelegram startet eine Fake-Bot-API, die token akzeptiert und Nachrichten an chatID annimmt
+func NewTelegram(token, chatID string) *Telegram {
+	t := &Telegram{Token: token, ChatID: chatID, Username: strings.TrimPrefix(chatID, "@")}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/bot"+token+"/sendMessage", t.handleSendMessage)
+	mux.HandleFunc("/bot"+token+"/getMe", t.handleGetMe)
+	mux.HandleFunc("/bot"+token+"/getChat", t.handleGetChat)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"ok":false,"error_code":401,"description":"Unauthorized"}`, http.StatusUnauthorized)
+	})
+	t.Server = httptest.NewServer(mux)
+	return t
+}
+
+// Messages gibt alle bisher empfangenen Nachrichten zurück
+func (t *Telegram) Messages() []TelegramMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TelegramMessage(nil), t.messages...)
+}
+
+func (t *Telegram) handleSendMessage(w http.ResponseWriter, r *http.Request) {
+	if t.fail(w, "/sendMessage") {
+		return
+	}
+	var msg TelegramMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if msg.ChatID != t.ChatID {
+		http.Error(w, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`, http.StatusBadRequest)
+		return
+	}
+	t.mu.Lock()
+	t.messages = append(t.messages, msg)
+	id := len(t.messages)
+	t.mu.Unlock()
+	writeJSON(w, map[string]interface{}{
+		"ok": true,
+		"result": map[string]interface{}{
+			"message_id": id,
+			"chat":       map[string]interface{}{"username": t.Username},
+		},
+	})
+}
+
+func (t *Telegram) handleGetMe(w http.ResponseWriter, r *http.Request) {
+	if t.fail(w, "/getMe") {
+		return
+	}
+	writeJSON(w, map[string]interface{}{"ok": true, "result": map[string]interface{}{"username": "fake_bot"}})
+}
+
+func (t *Telegram) handleGetChat(w http.ResponseWriter, r *http.Request) {
+	if t.fail(w, "/getChat") {
+		return
+	}
+	if r.URL.Query().Get("chat_id") != t.ChatID {
+		http.Error(w, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"ok": true, "result": map[string]interface{}{"username": t.Username}})
+}
+
+// DiscordEmbed ist ein vom Fake-Discord empfangener Embed
+type DiscordEmbed struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+}
+
+// Discord ist ein nachgebauter Discord-Webhook. Die Webhook-URL ist URL + "/api/webhooks/1/token".
+type Discord struct {
+	*httptest.Server
+	injector
+
+	mu     sync.Mutex
+	embeds []DiscordEmbed
+}
+
+// NewDiscord startet einen Fake-Webhook
+func NewDiscord() *Discord {
+	d := &Discord{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/webhooks/1/token", d.handleWebhook)
+	d.Server = httptest.NewServer(mux)
+	return d
+}
+
+// WebhookURL gibt die URL des Fake-Webhooks zurück
+func (d *Discord) WebhookURL() string {
+	return d.URL + "/api/webhooks/1/token"
+}
+
+// Embeds gibt alle bisher empfangenen Embeds zurück
+func (d *Discord) Embeds() []DiscordEmbed {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]DiscordEmbed(nil), d.embeds...)
+}
+
+func (d *Discord) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		writeJSON(w, map[string]interface{}{"name": "gvgbot", "channel_id": "42"})
+		return
+	}
+	if d.fail(w, "/webhook") {
+		return
+	}
+	var msg struct {
+		Embeds []DiscordEmbed `json:"embeds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil || len(msg.Embeds) == 0 {
+		http.Error(w, `{"message":"Cannot send an empty message","code":50006}`, http.StatusBadRequest)
+		return
+	}
+	d.mu.Lock()
+	d.embeds = append(d.embeds, msg.Embeds...)
+	id := len(d.embeds)
+	d.mu.Unlock()
+	if r.URL.Query().Get("wait") != "true" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"id": fmt.Sprint(id), "channel_id": "42"})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -1,25 +1,66 @@
 package main
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"encoding/xml"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
+	"maps"
+	"slices"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/antchfx/htmlquery"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"golang.org/x/net/html"
+	"golang.org/x/time/rate"
 	"bufio"
+	"bytes"
+	"mime/multipart"
+	"net/textproto"
+	"path"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"text/template"
+	htmltemplate "html/template"
+
+	_ "modernc.org/sqlite"
 )
 
-// Config enthält die Konfiguration für das Programm
+// Config enthält die Konfiguration für das Programm.
+//
+// Geheimnisse können statt in der Datei auch über Umgebungsvariablen gesetzt werden,
+// die Vorrang vor den Dateiwerten haben (siehe envSecrets):
+// GVG_LEMMY_PASSWORD, GVG_LEMMY_TOKEN, GVG_MASTODON_ACCESS_TOKEN, GVG_MASTODON_PASSWORD,
+// GVG_MASTODON_CLIENT_SECRET, GVG_BLUESKY_PASSWORD, GVG_TELEGRAM_BOT_TOKEN, GVG_DISCORD_WEBHOOK_URL
+// und GVG_ADMIN_TOKEN.
 type Config struct {
 	URL            string        `json:"url"`
 	CheckInterval  time.Duration `json:"check_interval"`
@@ -31,24 +72,558 @@ type Config struct {
 	LemmyToken     string        `json:"lemmy_token"`
 	LemmyTokenExp  time.Time     `json:"lemmy_token_exp"`
 	IgnoreDirs     []string      `json:"ignore_dirs"`
+	// WatchDirs beschränkt die Überwachung auf diese obersten Verzeichnisse (leer = alle).
+	// IgnoreDirs wird danach zusätzlich angewendet.
+	WatchDirs []string `json:"watch_dirs"`
+	// CheckJitter verschiebt jede Überprüfung im Loop-Modus zufällig um bis zu ±CheckJitter,
+	// StartupJitter verzögert die erste Überprüfung zufällig um bis zu StartupJitter (in Nanosekunden).
+	// So treffen mehrere gleichzeitig gestartete Instanzen nicht zur selben Zeit auf die Website.
+	CheckJitter   time.Duration `json:"check_jitter"`
+	StartupJitter time.Duration `json:"startup_jitter"`
+	// QuietHoursStart und QuietHoursEnd ("HH:MM", Ortszeit in Timezone) legen eine Ruhezeit fest,
+	// in der nicht gepostet wird. Neue Links werden dann zurückgestellt und beim ersten Durchlauf
+	// danach gepostet. Die Ruhezeit darf über Mitternacht reichen (z.B. "22:00" bis "07:00").
+	QuietHoursStart string `json:"quiet_hours_start"`
+	QuietHoursEnd   string `json:"quiet_hours_end"`
+	// Timezone ist die Zeitzone der Ruhezeit, z.B. "Europe/Berlin" (Standard: Zeitzone des Systems)
+	Timezone string `json:"timezone"`
+	// HTTPTimeout begrenzt jede ausgehende HTTP-Anfrage (Quelle und Plattform-APIs), in Nanosekunden
+	HTTPTimeout time.Duration `json:"http_timeout"`
+	// ProxyURL leitet alle ausgehenden Anfragen über einen Proxy (http://, https:// oder socks5://).
+	// Ist er leer, gelten die Umgebungsvariablen HTTP_PROXY, HTTPS_PROXY und NO_PROXY.
+	ProxyURL string `json:"proxy_url"`
+	// Database ist eine SQLite-Datenbank, in der die Link-Daten aller Quellen statt in den
+	// JSON-Dateien (data_file) gespeichert werden. Beim ersten Start wird die JSON-Datei übernommen.
+	Database string `json:"database"`
+	// LinkPattern ist ein regulärer Ausdruck, dem ein (normalisiertes) href der Übersichtsseite
+	// entsprechen muss, um als Listing zu gelten. Standard: Unterverzeichnisse mit index.htm.
+	LinkPattern string `json:"link_pattern"`
+	// LemmyTargets sind weitere Lemmy-Communities, auch auf anderen Servern. Die Felder
+	// lemmy_server/lemmy_community/... bilden, wenn gesetzt, das erste Ziel.
+	LemmyTargets []LemmyTarget `json:"lemmy_targets,omitempty"`
+	// LemmyAuthMode legt fest, wie das JWT an Lemmy übergeben wird: "header" (Standard) als
+	// Authorization: Bearer wie ab Lemmy 0.19, "body" im Feld auth des JSON-Bodys bzw. als
+	// Query-Parameter auth für ältere Instanzen. Lehnt ein Server im Modus header das Token
+	// mit HTTP 401/403 ab, wird automatisch die Body-Variante versucht.
+	LemmyAuthMode string `json:"lemmy_auth_mode,omitempty"`
+	// LemmyLanguage ist die Sprache der Lemmy-Posts als ISO-Code (z.B. "de"), gilt auch für
+	// lemmy_targets ohne eigenes language. Die numerische language_id des Servers wird beim
+	// Login über /api/v3/site ermittelt und mit dem Token in LemmyLanguageID gespeichert.
+	LemmyLanguage   string `json:"lemmy_language,omitempty"`
+	LemmyLanguageID int    `json:"lemmy_language_id,omitempty"`
 
 	// Mastodon-Konfiguration
 	MastodonServer      string    `json:"mastodon_server"`
-	MastodonAccessToken string    `json:"mastodon_access_token"` // Optional, wird überschrieben, wenn Username/Passwort genutzt wird
+	MastodonAccessToken string    `json:"mastodon_access_token"` // Optional, wird beim Login per Username/Passwort ersetzt
 	MastodonUsername    string    `json:"mastodon_username"`
 	MastodonPassword    string    `json:"mastodon_password"`
 	MastodonClientID    string    `json:"mastodon_client_id"`
 	MastodonClientSecret string   `json:"mastodon_client_secret"`
-	MastodonToken       string    `json:"mastodon_token"`
-	MastodonTokenExp    time.Time `json:"mastodon_token_exp"`
+	MastodonTokenExp    time.Time `json:"mastodon_token_exp"` // Ablauf von MastodonAccessToken, leer bei unbegrenzt gültigen Tokens
 	MastodonVisibility  string    `json:"mastodon_visibility"` // z.B. "public", "unlisted", "private", "direct"
+	MastodonFlavor      string    `json:"mastodon_flavor"`     // Server-Software: "mastodon" (Standard), "gotosocial" oder "akkoma"
+	MastodonMaxChars    int       `json:"mastodon_max_chars"`  // Zeichenlimit der Instanz (Standard 500), platform_limits hat Vorrang
+	// MastodonImages hängt das erste Bild des Listings (z.B. eine Flurkarte) an den Mastodon-Post an.
+	// Größere Bilder als MastodonMaxImageBytes werden mit einer Warnung übersprungen.
+	MastodonImages        bool  `json:"mastodon_images"`
+	MastodonMaxImageBytes int64 `json:"mastodon_max_image_bytes"`
+	// StaticMapTemplate ist die URL eines Kartendienstes (z.B. eines eigenen staticmap-Servers)
+	// mit den Platzhaltern {lat}, {lon} und {zoom}. Ist sie gesetzt, wird die Lage des
+	// Flurstücks über GeocodeURL ermittelt und die Karte zusätzlich an den Mastodon-Post
+	// angehängt. Schlägt das fehl, erscheint der Post ohne Karte.
+	StaticMapTemplate string `json:"static_map_template,omitempty"`
+	StaticMapZoom     int    `json:"static_map_zoom,omitempty"`
+	// GeocodeURL ist die Suche eines Nominatim-kompatiblen Geocoders mit dem Platzhalter {query}
+	GeocodeURL string `json:"geocode_url,omitempty"`
+
+	// Bluesky-Konfiguration (AT Protocol). Als Passwort sollte ein App-Passwort verwendet werden.
+	BlueskyHandle   string `json:"bluesky_handle"`
+	BlueskyPassword string `json:"bluesky_password"`
+	BlueskyPDS      string `json:"bluesky_pds"` // Personal Data Server, Standard https://bsky.social
+
+	// Telegram-Konfiguration (Bot API). Der Bot muss im Kanal Nachrichten senden dürfen.
+	TelegramBotToken string `json:"telegram_bot_token"`
+	TelegramChatID   string `json:"telegram_chat_id"` // z.B. "@gvg_nrw" oder "-1001234567890"
+
+	// Discord-Konfiguration: Webhook-URL eines Kanals (Kanaleinstellungen → Integrationen → Webhooks)
+	DiscordWebhookURL string `json:"discord_webhook_url"`
+
+	// Webhooks sind eigene HTTP-Endpunkte, an die jeder Post als JSON gesendet wird
+	Webhooks []WebhookTarget `json:"webhooks,omitempty"`
+
+	// Vorlagen (text/template) für Titel und Text der Posts. Verfügbare Variablen:
+	// {{.City}}, {{.Title}}, {{.Text}}, {{.URL}}, {{.Permalink}} sowie - falls im Text gefunden -
+	// {{.Area}}, {{.Price}}, {{.Gemarkung}}, {{.Flurstueck}}, {{.Category}}, {{.Hashtag}} und {{.Deadline}} (sonst leer)
+	// sowie die Liste {{.Attachments}} der verlinkten Formulare. Leere Vorlagen verwenden das Standardformat.
+	TitleTemplate string `json:"title_template"`
+	BodyTemplate  string `json:"body_template"`
+	// PlatformTemplates überschreibt title_template/body_template für einzelne Plattformen,
+	// z.B. {"mastodon": {"body": "{{.City}}: {{.Text}}"}}. Nicht gesetzte Felder gelten weiter.
+	PlatformTemplates map[string]PostTemplate `json:"platform_templates,omitempty"`
+
+	// StatusBaseURL ist die öffentliche Basis-URL des Status-Servers. Ist sie gesetzt, erhält
+	// jeder Post einen dauerhaften Permalink <StatusBaseURL>/item/<hash>, den der
+	// Status-Server (-status-addr) ausliefert.
+	StatusBaseURL string `json:"status_base_url"`
+
+	// Filter nach Nutzungsart (z.B. "Ackerland", "Grünland", "Wald"). Ist IncludeCategories
+	// gesetzt, werden nur diese Kategorien gepostet; ExcludeCategories wird danach angewendet.
+	// Listings ohne erkennbare Kategorie werden immer gepostet.
+	IncludeCategories []string `json:"include_categories"`
+	ExcludeCategories []string `json:"exclude_categories"`
+
+	// RequireApproval legt neue Links in approval_queue.json ab, statt sie zu posten.
+	// Erst nach -approve <url> werden sie beim nächsten Durchlauf gepostet,
+	// nach -reject <url> beim nächsten Durchlauf ohne Post als gesehen markiert.
+	RequireApproval bool `json:"require_approval"`
+
+	// AdminToken schützt die Freigabe-Endpunkte /queue, /approve und /reject des Status-Servers.
+	// Ohne Token sind die Endpunkte deaktiviert.
+	AdminToken string `json:"admin_token"`
+
+	// DeadlineReminderDays > 0 postet im Loop-Modus einmalig eine Erinnerung,
+	// wenn die Frist eines Listings in höchstens so vielen Tagen abläuft
+	DeadlineReminderDays int `json:"deadline_reminder_days"`
+
+	// PostRemovals postet eine kurze Mitteilung, wenn ein Listing von der Website verschwindet.
+	// Fehlgeschlagene Mitteilungen werden beim nächsten Durchlauf erneut versucht.
+	PostRemovals bool `json:"post_removals"`
+
+	// PostUpdates ruft bei jedem Durchlauf die Detailseiten bereits geposteter Links (bedingt)
+	// erneut ab und postet eine Mitteilung, wenn sich ihr Text geändert hat, z.B. bei einer
+	// verlängerten Frist. Fehlgeschlagene Mitteilungen werden beim nächsten Durchlauf erneut versucht.
+	PostUpdates bool `json:"post_updates"`
+
+	// MaxDetailFetchesPerRun begrenzt die Anzahl der pro Durchlauf abgerufenen Detailseiten (0 = unbegrenzt).
+	// Weitere neue Links werden zurückgestellt und beim nächsten Durchlauf abgerufen.
+	MaxDetailFetchesPerRun int `json:"max_detail_fetches_per_run"`
+
+	// StatsdAddr (host:port) sendet nach jedem Durchlauf Metriken per UDP an StatsD
+	StatsdAddr string `json:"statsd_addr"`
+
+	// RepostReturning postet Links erneut, die entfernt wurden und später wieder erscheinen (Standard: true).
+	// Mit false werden zurückkehrende Links stillschweigend wieder als gesehen übernommen.
+	RepostReturning bool `json:"repost_returning"`
+
+	// DebounceDuration hält neue Links zurück, bis sie mindestens so lange über mehrere
+	// Überprüfungen hinweg auf der Website stehen (0 = sofort posten). Links, die vorher
+	// wieder verschwinden, werden stillschweigend verworfen.
+	DebounceDuration time.Duration `json:"debounce_duration"`
+
+	// DedupeByContentAcrossURLs markiert neue Links ohne Post als gesehen, wenn ihr Text
+	// mit dem eines bereits geposteten Links unter anderer URL übereinstimmt
+	DedupeByContentAcrossURLs bool `json:"dedupe_by_content_across_urls"`
+
+	// SourceHeaders werden bei Abrufen vom Host der Quelle mitgesendet, z.B. ein von
+	// Mirrors verlangter Referer oder Cookie. An Lemmy und Mastodon gehen sie nicht.
+	// Accept-Language ist standardmäßig "de-DE,de;q=0.9" und lässt sich hier überschreiben.
+	SourceHeaders map[string]string `json:"source_headers"`
+
+	// RequireConfirmProd verweigert außerhalb des Testmodus das Posten auf ein Ziel aus
+	// ProductionTargets, solange run/check nicht mit -yes-post-to-prod aufgerufen werden.
+	// Einträge sind Hosts ("social.example.org") oder Lemmy-Host/Community ("lemmy.example.org/kulturlandschaft").
+	RequireConfirmProd bool     `json:"require_confirm_prod"`
+	ProductionTargets  []string `json:"production_targets"`
+
+	// MaxRetries legt fest, wie oft ein Abruf der Quelle bei Verbindungsfehlern, HTTP 5xx
+	// und 429 wiederholt wird (exponentielles Backoff mit Jitter, Retry-After wird beachtet)
+	MaxRetries int `json:"max_retries"`
+	// MaxFailedAttempts legt fest, nach wie vielen fehlgeschlagenen Durchläufen in Folge ein
+	// Link aufgegeben und ohne Post als gesehen markiert wird (0 = unbegrenzt, Standard 5).
+	// Gezählt werden nur Fehler beim Abrufen und Auswerten der Detailseite.
+	MaxFailedAttempts int `json:"max_failed_attempts"`
+
+	// UserAgent wird bei allen ausgehenden HTTP-Anfragen gesendet
+	UserAgent string `json:"user_agent"`
+
+	// MaxPageBytes begrenzt die Größe einer abgerufenen Seite (0 = unbegrenzt)
+	MaxPageBytes int64 `json:"max_page_bytes"`
+
+	// Sources enthält zusätzliche Quellen, die neben URL/DataFile überprüft werden. Jede
+	// Quelle braucht eine eigene Datendatei; ohne IgnoreDirs bzw. WatchDirs gilt die globale Liste.
+	Sources []SourceConfig `json:"sources"`
+
+	// SourceConcurrency begrenzt, wie viele Quellen gleichzeitig überprüft werden (Standard 1)
+	SourceConcurrency int `json:"source_concurrency"`
+
+	// FetchConcurrency begrenzt, wie viele Detailseiten gleichzeitig abgerufen werden (Standard 4).
+	// Gepostet wird weiterhin nacheinander in der Reihenfolge der Links.
+	FetchConcurrency int `json:"fetch_concurrency"`
+
+	// RequestsPerSecond begrenzt die Anfragen an die überwachte Website (0 = unbegrenzt),
+	// PlatformRequestsPerSecond getrennt davon die Aufrufe der Plattform-APIs
+	RequestsPerSecond         float64 `json:"requests_per_second"`
+	PlatformRequestsPerSecond float64 `json:"platform_requests_per_second"`
+
+	// HRSectionStart und HRSectionEnd legen fest, zwischen welchen <hr>-Tags (1-basiert)
+	// der Text der Detailseite steht. Standard ist zwischen dem ersten und zweiten.
+	HRSectionStart int `json:"hr_section_start"`
+	HRSectionEnd   int `json:"hr_section_end"`
+
+	// ContentXPath wählt den Inhalts-Container für Detailseiten ohne ausreichend <hr>-Tags
+	// (z.B. "//div[@id='content']"). Ohne Angabe wird der Block mit dem meisten Fließtext verwendet.
+	ContentXPath string `json:"content_xpath"`
+
+	// NextPageXPath findet auf einer paginierten Übersichtsseite den Link zur nächsten Seite
+	// (z.B. "//a[@rel='next']"). Die Links aller Seiten werden zusammengefasst, höchstens
+	// MaxPages Seiten. Ohne Angabe wird nur die Übersichtsseite selbst ausgewertet.
+	NextPageXPath string `json:"next_page_xpath"`
+	MaxPages      int    `json:"max_pages"`
+
+	// TitleNormalization steuert die Bereinigung der aus <h3> extrahierten Überschrift
+	TitleNormalization TitleNormalization `json:"title_normalization"`
+
+	// RedactContacts ersetzt E-Mail-Adressen und Telefonnummern im geposteten Text
+	// durch einen Hinweis auf die Originalseite
+	RedactContacts bool `json:"redact_contacts"`
+
+	// ExcerptLength postet statt des vollständigen Textes nur die ersten N Zeichen mit
+	// einem Link auf die Quelle ("… (mehr: <url>)"). 0 postet den vollständigen Text.
+	ExcerptLength int `json:"excerpt_length"`
+
+	// PlatformLimits überschreibt die maximale Zeichenzahl pro Plattform (z.B. {"mastodon": 2000})
+	PlatformLimits map[string]int `json:"platform_limits"`
+
+	// FeedFile wird nach jeder Überprüfung als RSS-2.0-Feed der gespeicherten Links neu geschrieben
+	FeedFile string `json:"feed_file"`
+	// DumpDir ist das Verzeichnis, in das im Testmodus jeder geplante Post als JSON geschrieben wird (Standard: posts)
+	DumpDir string `json:"dump_dir,omitempty"`
+
+	// CompressArchive speichert archivierte Posts in posts/ gzip-komprimiert als .json.gz
+	CompressArchive bool `json:"compress_archive"`
+
+	// Disclaimer wird an jeden Post angehängt (nach Permalink und Hashtag), z.B. ein Hinweis
+	// auf den Bot mit Link zu Quellcode und Kontakt. Beim Kürzen bleibt er immer erhalten.
+	Disclaimer string `json:"disclaimer"`
+
+	// PlatformPostDelays legt die Pause in Sekunden zwischen zwei Posts pro Plattform fest
+	// (z.B. {"mastodon": 5}). Die Plattformen werden parallel bedient, eine Pause hält nur
+	// die eigene Plattform auf.
+	PlatformPostDelays map[string]int `json:"platform_post_delays"`
+
+	// configFile ist der Pfad, aus dem die Konfiguration geladen wurde. Neue Tokens
+	// werden dorthin zurückgeschrieben.
+	configFile string
+
+	// linkRe ist das beim Laden kompilierte LinkPattern
+	linkRe *regexp.Regexp
+
+	// envOverrides enthält je Umgebungsvariable den ursprünglichen Dateiwert und den
+	// Wert aus der Umgebung, damit saveConfig keine Geheimnisse aus der Umgebung speichert
+	envOverrides map[string]envOverride
+
+	// location ist die beim Laden aus Timezone ermittelte Zeitzone
+	location *time.Location
+
+	// onlyPlatform beschränkt die Posts auf eine Plattform (Schlüssel wie in PostedTo), z.B.
+	// beim Nachholen mit check -backfill. Leer werden alle konfigurierten Plattformen bedient.
+	onlyPlatform string
+}
+
+// postsTo meldet, ob Posts an die Plattform mit dem Schlüssel name gehen
+func (c Config) postsTo(name string) bool {
+	return c.onlyPlatform == "" || c.onlyPlatform == name
+}
+
+// envOverride merkt sich einen aus der Umgebung überschriebenen Konfigurationswert
+type envOverride struct {
+	file string
+	env  string
+}
+
+// envSecrets ordnet die unterstützten Umgebungsvariablen den Feldern in Config zu
+var envSecrets = []struct {
+	name  string
+	field func(*Config) *string
+}{
+	{"GVG_LEMMY_PASSWORD", func(c *Config) *string { return &c.LemmyPassword }},
+	{"GVG_LEMMY_TOKEN", func(c *Config) *string { return &c.LemmyToken }},
+	{"GVG_MASTODON_ACCESS_TOKEN", func(c *Config) *string { return &c.MastodonAccessToken }},
+	{"GVG_MASTODON_PASSWORD", func(c *Config) *string { return &c.MastodonPassword }},
+	{"GVG_MASTODON_CLIENT_SECRET", func(c *Config) *string { return &c.MastodonClientSecret }},
+	{"GVG_BLUESKY_PASSWORD", func(c *Config) *string { return &c.BlueskyPassword }},
+	{"GVG_TELEGRAM_BOT_TOKEN", func(c *Config) *string { return &c.TelegramBotToken }},
+	{"GVG_DISCORD_WEBHOOK_URL", func(c *Config) *string { return &c.DiscordWebhookURL }},
+	{"GVG_ADMIN_TOKEN", func(c *Config) *string { return &c.AdminToken }},
+}
+
+// applyEnvSecrets überschreibt Geheimnisse mit gesetzten Umgebungsvariablen
+func applyEnvSecrets(config *Config) {
+	config.envOverrides = nil
+	for _, secret := range envSecrets {
+		value, ok := os.LookupEnv(secret.name)
+		if !ok || value == "" {
+			continue
+		}
+		field := secret.field(config)
+		if config.envOverrides == nil {
+			config.envOverrides = make(map[string]envOverride)
+		}
+		config.envOverrides[secret.name] = envOverride{file: *field, env: value}
+		*field = value
+	}
+}
+
+// withoutEnvSecrets setzt Felder, die noch den Wert aus der Umgebung haben, auf den
+// Dateiwert zurück. Zur Laufzeit geänderte Werte (z.B. neu geholte Tokens) bleiben erhalten.
+func withoutEnvSecrets(config Config) Config {
+	for _, secret := range envSecrets {
+		override, ok := config.envOverrides[secret.name]
+		if !ok {
+			continue
+		}
+		if field := secret.field(&config); *field == override.env {
+			*field = override.file
+		}
+	}
+	return config
+}
+
+// PostTemplate enthält plattformspezifische Vorlagen für Titel und Text
+type PostTemplate struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+// SourceConfig beschreibt eine zusätzliche Quelle
+type SourceConfig struct {
+	URL        string   `json:"url"`
+	DataFile   string   `json:"data_file"`
+	IgnoreDirs []string `json:"ignore_dirs"`
+	WatchDirs  []string `json:"watch_dirs"`
+}
+
+// TitleNormalization legt fest, wie extrahierte Überschriften bereinigt werden.
+// Führende und folgende Leerzeichen werden immer entfernt.
+type TitleNormalization struct {
+	CollapseWhitespace  bool `json:"collapse_whitespace"`   // Mehrfache Leerzeichen/Zeilenumbrüche zusammenfassen
+	StripTrailingColons bool `json:"strip_trailing_colons"` // Doppelpunkte am Ende entfernen
+	TitleCase           bool `json:"title_case"`            // Kleingeschriebene und GROSSGESCHRIEBENE Wörter groß beginnen lassen
+}
+
+// defaultPlatformLimits enthält die Standard-Zeichenlimits der Plattformen
+var defaultPlatformLimits = map[string]int{
+	"lemmy":    10000,
+	"mastodon": 500,
+	"bluesky":  300,
+	"telegram": 4096,
+	"discord":  discordDescriptionLimit,
+}
+
+// LinkRecord ist ein gesehener Link mit den beim Posten extrahierten Angaben
+type LinkRecord struct {
+	Href      string    `json:"href"`
+	Title     string    `json:"title,omitempty"`
+	City      string    `json:"city,omitempty"`
+	Text      string    `json:"text,omitempty"`
+	FirstSeen time.Time `json:"first_seen,omitempty"` // Zeitpunkt, zu dem der Link erfolgreich gepostet wurde
+	PostedTo  []string  `json:"posted_to,omitempty"`  // Plattformen, auf denen der Link gepostet wurde
+	// Skipped gibt an, warum der Link ohne Post als gesehen gespeichert wurde (siehe skipRejected usw.)
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// Gründe, aus denen ein Link ohne Post als gesehen gespeichert wird. Solche Links werden
+// von check -backfill nicht nachgeholt.
+const (
+	skipRejected  = "rejected"  // in der Freigabe-Warteschlange verworfen
+	skipDuplicate = "duplicate" // Inhalt identisch mit einem bereits geposteten Link
+	skipAbandoned = "abandoned" // nach zu vielen Fehlschlägen aufgegeben
+	skipCategory  = "category"  // Kategorie herausgefiltert
+	skipReturned  = "returned"  // zurückgekehrter Link, der nicht erneut gepostet wird
+)
+
+// UnmarshalJSON liest neben Objekten auch die bloßen Strings älterer Datendateien
+func (r *LinkRecord) UnmarshalJSON(b []byte) error {
+	var href string
+	if err := json.Unmarshal(b, &href); err == nil {
+		*r = LinkRecord{Href: href}
+		return nil
+	}
+	type plain LinkRecord
+	return json.Unmarshal(b, (*plain)(r))
 }
 
 // LinkData speichert die gefundenen Links
 type LinkData struct {
-	Links       []string  `json:"links"`
-	FailedLinks []string  `json:"failed_links"` // Links die beim Posten fehlgeschlagen sind
-	LastSeen    time.Time `json:"last_seen"`
+	Links       []LinkRecord `json:"links"`
+	FailedLinks []string     `json:"failed_links"` // Links die beim Posten fehlgeschlagen sind
+	// PendingLinks wurden zurückgestellt (z.B. wegen des Abruf-Budgets) und werden beim nächsten Durchlauf bearbeitet
+	PendingLinks []string  `json:"pending_links,omitempty"`
+	LastSeen     time.Time `json:"last_seen"`
+
+	// FailedAttempts zählt pro Link die fehlgeschlagenen Durchläufe seit dem letzten Erfolg
+	FailedAttempts map[string]FailedAttempt `json:"failed_attempts,omitempty"`
+
+	// Deadlines enthält die aus dem Text gelesene Frist pro Link
+	Deadlines map[string]time.Time `json:"deadlines,omitempty"`
+	// RemindersSent enthält den Zeitpunkt, zu dem an die Frist eines Links erinnert wurde
+	RemindersSent map[string]time.Time `json:"reminders_sent,omitempty"`
+
+	// ContentHashes enthält den Hash des extrahierten Textes pro geposteten Link
+	ContentHashes map[string]string `json:"content_hashes,omitempty"`
+
+	// FirstObserved enthält den Zeitpunkt, zu dem ein noch nicht geposteter Link zum ersten
+	// Mal auf der Website gesehen wurde (für DebounceDuration)
+	FirstObserved map[string]time.Time `json:"first_observed,omitempty"`
+
+	// RemovedLinks enthält Links, die von der Website verschwunden sind, mit dem Zeitpunkt der Entfernung
+	RemovedLinks map[string]time.Time `json:"removed_links,omitempty"`
+
+	// PendingRemovalNotices enthält entfernte Links, deren Mitteilung (PostRemovals) noch
+	// nicht erfolgreich gepostet wurde
+	PendingRemovalNotices []LinkRecord `json:"pending_removal_notices,omitempty"`
+
+	// PostCounts zählt pro Plattform alle jemals erfolgreich erstellten Listing-Posts seit
+	// CountingSince. Die Zähler werden beim Aufräumen nicht zurückgesetzt.
+	PostCounts    map[string]int `json:"post_counts,omitempty"`
+	CountingSince time.Time      `json:"counting_since,omitempty"`
+
+	// PostNotBefore enthält pro Plattform den Zeitpunkt, vor dem nach einem
+	// HTTP 429 mit Retry-After nicht erneut gepostet werden darf
+	PostNotBefore map[string]time.Time `json:"post_not_before,omitempty"`
+
+	// Validators enthält pro URL die ETag- und Last-Modified-Werte der letzten Antwort
+	// für bedingte Abrufe der Übersichtsseite
+	Validators map[string]CacheValidators `json:"validators,omitempty"`
+}
+
+// FailedAttempt beschreibt die bisherigen Fehlschläge eines Links
+type FailedAttempt struct {
+	Count int       `json:"count"`
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+}
+
+// CacheValidators sind die Header, mit denen ein Server eine unveränderte Seite erkennt
+type CacheValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// legacyLinkFields enthält die Angaben pro Link, die ältere Datendateien in eigenen
+// Maps gespeichert haben. Sie werden beim Laden in die LinkRecords übernommen.
+type legacyLinkFields struct {
+	FirstSeen map[string]time.Time `json:"first_seen"`
+	Cities    map[string]string    `json:"cities"`
+	Titles    map[string]string    `json:"titles"`
+	Texts     map[string]string    `json:"texts"`
+}
+
+// hrefs gibt die Hrefs aller gespeicherten Links zurück
+func (d *LinkData) hrefs() []string {
+	hrefs := make([]string, len(d.Links))
+	for i, record := range d.Links {
+		hrefs[i] = record.Href
+	}
+	return hrefs
+}
+
+// record gibt den gespeicherten Link mit href zurück oder nil
+func (d *LinkData) record(href string) *LinkRecord {
+	for i := range d.Links {
+		if d.Links[i].Href == href {
+			return &d.Links[i]
+		}
+	}
+	return nil
+}
+
+// addLink speichert einen Link. Ist er bereits gespeichert, werden seine Angaben
+// aktualisiert; ein vorhandener FirstSeen-Zeitpunkt bleibt dabei erhalten.
+func (d *LinkData) addLink(record LinkRecord) {
+	existing := d.record(record.Href)
+	if existing == nil {
+		d.Links = append(d.Links, record)
+		return
+	}
+	if !existing.FirstSeen.IsZero() {
+		record.FirstSeen = existing.FirstSeen
+	}
+	*existing = record
+}
+
+// LemmyTarget ist eine Lemmy-Community, in der gepostet wird
+type LemmyTarget struct {
+	Server    string    `json:"server"`
+	Community string    `json:"community"`
+	Username  string    `json:"username"`
+	Password  string    `json:"password"`
+	Token     string    `json:"token,omitempty"`
+	TokenExp  time.Time `json:"token_exp,omitempty"`
+
+	// Language ist der ISO-Code der Post-Sprache, LanguageID die dazu ermittelte language_id
+	Language   string `json:"language,omitempty"`
+	LanguageID int    `json:"language_id,omitempty"`
+
+	// index ist die Position in Config.LemmyTargets, -1 für das Ziel aus den lemmy_*-Feldern
+	index int
+}
+
+// configured gibt an, ob alle für das Posten nötigen Felder gesetzt sind
+func (t LemmyTarget) configured() bool {
+	return t.Server != "" && t.Community != "" && t.Username != "" && t.Password != ""
+}
+
+// String gibt das Ziel als Host/Community zurück
+func (t LemmyTarget) String() string {
+	host := t.Server
+	if u, err := url.Parse(t.Server); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return host + "/" + t.Community
+}
+
+// key ist der Schlüssel für Nachweise, Sperren und Zähler. Das Ziel aus den lemmy_*-Feldern
+// behält "lemmy", damit vorhandene Nachweise weiter gelten.
+func (t LemmyTarget) key() string {
+	if t.index < 0 {
+		return "lemmy"
+	}
+	return "lemmy@" + t.String()
+}
+
+// lemmyTargets gibt alle vollständig konfigurierten Lemmy-Ziele zurück
+func lemmyTargets(config Config) []LemmyTarget {
+	var targets []LemmyTarget
+	implicit := LemmyTarget{
+		Server:     config.LemmyServer,
+		Community:  config.LemmyCommunity,
+		Username:   config.LemmyUsername,
+		Password:   config.LemmyPassword,
+		Token:      config.LemmyToken,
+		TokenExp:   config.LemmyTokenExp,
+		Language:   config.LemmyLanguage,
+		LanguageID: config.LemmyLanguageID,
+		index:      -1,
+	}
+	if implicit.configured() {
+		targets = append(targets, implicit)
+	}
+	for i, target := range config.LemmyTargets {
+		target.index = i
+		if target.Language == "" {
+			target.Language = config.LemmyLanguage
+		}
+		if target.configured() {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// storeLemmyToken legt das Token eines Ziels wieder in der Konfiguration ab
+func storeLemmyToken(config *Config, target LemmyTarget) {
+	if target.index < 0 {
+		config.LemmyToken = target.Token
+		config.LemmyTokenExp = target.TokenExp
+		config.LemmyLanguageID = target.LanguageID
+		return
+	}
+	if target.index < len(config.LemmyTargets) {
+		config.LemmyTargets[target.index].Token = target.Token
+		config.LemmyTargets[target.index].TokenExp = target.TokenExp
+		config.LemmyTargets[target.index].LanguageID = target.LanguageID
+	}
 }
 
 // LemmyLoginResponse ist die Antwortstruktur für den Lemmy-Login
@@ -59,16 +634,131 @@ type LemmyLoginResponse struct {
 
 // LemmyPostResponse ist die Antwortstruktur für die Lemmy-Post-Erstellung
 type LemmyPostResponse struct {
-	Post struct {
-		Id int `json:"id"`
+	PostView struct {
+		Post struct {
+			Id   int    `json:"id"`
+			ApId string `json:"ap_id"`
+		} `json:"post"`
 	} `json:"post_view"`
 }
 
+// RemotePost identifiziert einen erfolgreich erstellten Post auf einer Plattform
+type RemotePost struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// PostReceipt ist der Nachweis eines veröffentlichten Posts in receipts.jsonl
+type PostReceipt struct {
+	Platform  string    `json:"platform"`
+	Link      string    `json:"link"`
+	RemoteID  string    `json:"remote_id"`
+	RemoteURL string    `json:"remote_url"`
+	Text      string    `json:"text"`
+	Timestamp time.Time `json:"timestamp"`
+	// Forgotten hebt alle früheren Nachweise für Link auf (forget), damit er erneut gepostet wird
+	Forgotten bool `json:"forgotten,omitempty"`
+}
+
+// receiptsFile gibt den Pfad der Post-Nachweise neben der Datendatei zurück
+func receiptsFile(config Config) string {
+	return filepath.Join(filepath.Dir(config.DataFile), "receipts.jsonl")
+}
+
+// receiptsMu serialisiert das Anhängen an receipts.jsonl
+var receiptsMu sync.Mutex
+
+// appendReceipt hängt einen Post-Nachweis als JSON-Zeile an receipts.jsonl an
+func appendReceipt(config Config, receipt PostReceipt) error {
+	line, err := json.Marshal(receipt)
+	if err != nil {
+		return fmt.Errorf("Fehler beim Marshalling des Post-Nachweises: %v", err)
+	}
+	receiptsMu.Lock()
+	defer receiptsMu.Unlock()
+	file, err := os.OpenFile(receiptsFile(config), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("Fehler beim Öffnen von %s: %v", receiptsFile(config), err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// recordReceipt schreibt einen Post-Nachweis und protokolliert Fehler nur als Warnung
+func recordReceipt(config Config, platform, link, text string, remote RemotePost) {
+	err := appendReceipt(config, PostReceipt{
+		Platform:  platform,
+		Link:      link,
+		RemoteID:  remote.ID,
+		RemoteURL: remote.URL,
+		Text:      text,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		slog.Warn("Post-Nachweis konnte nicht geschrieben werden", "platform", platform, "link", link, "error", err)
+	}
+}
+
+// receiptIndex enthält die Links, die laut receipts.jsonl bereits je Plattform gepostet
+// wurden, mit dem Zeitpunkt des letzten Posts
+type receiptIndex map[string]map[string]time.Time
+
+// has meldet, ob für Plattform und Link bereits ein Post-Nachweis existiert
+func (r receiptIndex) has(platform, link string) bool {
+	_, ok := r[platform][link]
+	return ok
+}
+
+// forgetBefore entfernt die Nachweise für link, die vor t geschrieben wurden. So wird ein
+// entfernter und zurückgekehrter Link erneut gepostet, ohne dass Plattformen, auf denen er
+// seit seiner Rückkehr bereits gepostet wurde, ihn doppelt erhalten.
+func (r receiptIndex) forgetBefore(link string, t time.Time) {
+	for _, links := range r {
+		if posted, ok := links[link]; ok && posted.Before(t) {
+			delete(links, link)
+		}
+	}
+}
+
+// loadReceiptIndex liest receipts.jsonl ein. Eine fehlende Datei ergibt einen leeren Index.
+func loadReceiptIndex(config Config) (receiptIndex, error) {
+	index := receiptIndex{}
+	data, err := os.ReadFile(receiptsFile(config))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return index, nil
+		}
+		return index, fmt.Errorf("Fehler beim Lesen von %s: %v", receiptsFile(config), err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var receipt PostReceipt
+		if err := json.Unmarshal([]byte(line), &receipt); err != nil {
+			continue
+		}
+		if receipt.Forgotten {
+			for _, links := range index {
+				delete(links, receipt.Link)
+			}
+			continue
+		}
+		if index[receipt.Platform] == nil {
+			index[receipt.Platform] = map[string]time.Time{}
+		}
+		index[receipt.Platform][receipt.Link] = receipt.Timestamp
+	}
+	return index, nil
+}
+
 // DefaultConfig gibt die Standard-Konfiguration zurück
 func DefaultConfig() Config {
 	return Config{
 		URL:            "http://www.grundstueckverkehrsgesetz.nrw.de",
 		CheckInterval:  12 * time.Hour,
+		HTTPTimeout:    defaultHTTPTimeout,
 		DataFile:       "links.json",
 		LemmyServer:    "https://natur.23.nu",
 		LemmyCommunity: "kulturlandschaft",
@@ -77,6 +767,7 @@ func DefaultConfig() Config {
 		LemmyToken:     "",
 		LemmyTokenExp:  time.Time{},
 		IgnoreDirs:     []string{"guetersloh"},
+		LinkPattern:    defaultLinkPattern,
 
 		MastodonServer:      "",
 		MastodonAccessToken: "",
@@ -84,15 +775,50 @@ func DefaultConfig() Config {
 		MastodonPassword:    "",
 		MastodonClientID:    "",
 		MastodonClientSecret: "",
-		MastodonToken:       "",
 		MastodonTokenExp:    time.Time{},
 		MastodonVisibility:  "unlisted",
+		MastodonFlavor:      "mastodon",
+		MastodonMaxChars:    500,
+
+		MastodonImages:        true,
+		MastodonMaxImageBytes: 8 << 20,
+		StaticMapZoom:         14,
+		GeocodeURL:            defaultGeocodeURL,
+
+		BlueskyPDS: "https://bsky.social",
+
+		UserAgent:         defaultUserAgent,
+		MaxRetries:        3,
+		MaxFailedAttempts: 5,
+		MaxPages:          20,
+		FetchConcurrency:  4,
+
+		RepostReturning: true,
+		MaxPageBytes:    5 << 20,
+		HRSectionStart:  1,
+		HRSectionEnd:    2,
+
+		TitleNormalization: TitleNormalization{
+			CollapseWhitespace:  true,
+			StripTrailingColons: true,
+		},
 	}
 }
 
 // loadConfig lädt die Konfiguration aus einer JSON-Datei oder erstellt eine Standard-Konfiguration
+// und übernimmt die globalen Einstellungen (HTTP-Client, Rate-Limits, User-Agent, Lemmy-Auth)
 func loadConfig(configFile string) (Config, error) {
+	config, err := readConfig(configFile)
+	if err != nil {
+		return config, err
+	}
+	return config, applyConfig(config)
+}
+
+// readConfig liest und prüft die Konfiguration, ohne globale Einstellungen zu verändern
+func readConfig(configFile string) (Config, error) {
 	config := DefaultConfig()
+	config.configFile = configFile
 
 	if configFile != "" {
 		data, err := os.ReadFile(configFile)
@@ -101,22 +827,182 @@ func loadConfig(configFile string) (Config, error) {
 			if err != nil {
 				return config, fmt.Errorf("Fehler beim Parsen der Konfigurationsdatei: %v", err)
 			}
+			migrateMastodonToken(&config, data)
+		}
+	}
+	applyEnvSecrets(&config)
+
+	for platform, limit := range config.PlatformLimits {
+		if limit <= 0 {
+			return config, fmt.Errorf("Ungültiges Zeichenlimit %d für Plattform %s", limit, platform)
+		}
+	}
+	if _, ok := mastodonFlavors[config.MastodonFlavor]; !ok {
+		return config, fmt.Errorf("Unbekannter mastodon_flavor %q (erlaubt: mastodon, gotosocial, akkoma)", config.MastodonFlavor)
+	}
+	switch config.LemmyAuthMode {
+	case "", lemmyAuthHeader, lemmyAuthBody:
+	default:
+		return config, fmt.Errorf("Unbekannter lemmy_auth_mode %q (erlaubt: header, body)", config.LemmyAuthMode)
+	}
+	if config.LinkPattern != "" {
+		re, err := regexp.Compile(config.LinkPattern)
+		if err != nil {
+			return config, fmt.Errorf("Ungültiges link_pattern %q: %v", config.LinkPattern, err)
+		}
+		config.linkRe = re
+	}
+	if config.MastodonMaxChars < 0 {
+		return config, fmt.Errorf("Ungültige mastodon_max_chars %d", config.MastodonMaxChars)
+	}
+	if config.RequestsPerSecond < 0 || config.PlatformRequestsPerSecond < 0 {
+		return config, fmt.Errorf("requests_per_second und platform_requests_per_second dürfen nicht negativ sein")
+	}
+	if config.CheckJitter < 0 || config.StartupJitter < 0 {
+		return config, fmt.Errorf("check_jitter und startup_jitter dürfen nicht negativ sein")
+	}
+	if config.HTTPTimeout < 0 {
+		return config, fmt.Errorf("Ungültiges http_timeout %v", config.HTTPTimeout)
+	}
+	if config.Timezone != "" {
+		loc, err := time.LoadLocation(config.Timezone)
+		if err != nil {
+			return config, fmt.Errorf("Ungültige timezone %q: %v", config.Timezone, err)
+		}
+		config.location = loc
+	}
+	if (config.QuietHoursStart == "") != (config.QuietHoursEnd == "") {
+		return config, fmt.Errorf("quiet_hours_start und quiet_hours_end müssen gemeinsam gesetzt werden")
+	}
+	for _, clock := range []string{config.QuietHoursStart, config.QuietHoursEnd} {
+		if _, err := parseClock(clock); clock != "" && err != nil {
+			return config, err
+		}
+	}
+	if config.HTTPTimeout == 0 {
+		config.HTTPTimeout = defaultHTTPTimeout
+	}
+	if config.FetchConcurrency < 0 {
+		return config, fmt.Errorf("Ungültige fetch_concurrency %d", config.FetchConcurrency)
+	}
+	if config.MaxRetries < 0 {
+		return config, fmt.Errorf("Ungültige max_retries %d", config.MaxRetries)
+	}
+	if config.MaxFailedAttempts < 0 {
+		return config, fmt.Errorf("Ungültige max_failed_attempts %d", config.MaxFailedAttempts)
+	}
+	if config.ExcerptLength < 0 {
+		return config, fmt.Errorf("Ungültige excerpt_length %d", config.ExcerptLength)
+	}
+	if config.HRSectionStart < 1 || config.HRSectionEnd <= config.HRSectionStart {
+		return config, fmt.Errorf("Ungültige <hr>-Abschnittsgrenzen %d bis %d", config.HRSectionStart, config.HRSectionEnd)
+	}
+	dataFiles := map[string]bool{config.DataFile: true}
+	for i, source := range config.Sources {
+		if source.URL == "" || source.DataFile == "" {
+			return config, fmt.Errorf("Quelle %d: url und data_file müssen gesetzt sein", i+1)
+		}
+		if dataFiles[source.DataFile] {
+			return config, fmt.Errorf("Quelle %d: data_file %s wird bereits verwendet", i+1, source.DataFile)
+		}
+		dataFiles[source.DataFile] = true
+	}
+	for platform, delay := range config.PlatformPostDelays {
+		if delay < 0 {
+			return config, fmt.Errorf("Ungültige Pause %d für Plattform %s", delay, platform)
 		}
 	}
 
 	return config, nil
 }
 
-// saveConfig speichert die Konfiguration in eine JSON-Datei
+// applyConfig übernimmt die prozessweiten Einstellungen einer geprüften Konfiguration
+func applyConfig(config Config) error {
+	transport, err := proxyTransport(config.ProxyURL)
+	if err != nil {
+		return err
+	}
+	httpClient.Timeout = config.HTTPTimeout
+	httpClient.Transport = rateLimitedTransport{transport}
+	sourceLimiter = newRateLimiter(config.RequestsPerSecond)
+	platformLimiter = newRateLimiter(config.PlatformRequestsPerSecond)
+	if config.LemmyAuthMode == lemmyAuthBody {
+		lemmyAuthMode = lemmyAuthBody
+	} else {
+		lemmyAuthMode = lemmyAuthHeader
+	}
+	if config.UserAgent != "" {
+		userAgent = config.UserAgent
+	}
+	return nil
+}
+
+// migrateMastodonToken übernimmt das früher getrennt gespeicherte, per Passwort geholte
+// mastodon_token in mastodon_access_token, damit es nur noch ein Token mit Ablaufzeit gibt
+func migrateMastodonToken(config *Config, raw []byte) {
+	var legacy struct {
+		MastodonToken string `json:"mastodon_token"`
+	}
+	if err := json.Unmarshal(raw, &legacy); err != nil || legacy.MastodonToken == "" {
+		return
+	}
+	if config.MastodonAccessToken == "" || time.Now().Before(config.MastodonTokenExp) {
+		config.MastodonAccessToken = legacy.MastodonToken
+	}
+}
+
+// saveConfig speichert die Konfiguration in eine JSON-Datei. Geheimnisse aus der
+// Umgebung werden nicht in die Datei geschrieben.
 func saveConfig(config Config, configFile string) error {
-	data, err := json.MarshalIndent(config, "", "  ")
+	data, err := json.MarshalIndent(withoutEnvSecrets(config), "", "  ")
 	if err != nil {
 		return fmt.Errorf("Fehler beim Marshalling der Konfiguration: %v", err)
 	}
 
-	return os.WriteFile(configFile, data, 0644)
+	configMu.Lock()
+	defer configMu.Unlock()
+	return writeFileAtomic(configFile, data, 0644)
+}
+
+// writeFileAtomic schreibt data zunächst in eine temporäre Datei im selben Verzeichnis,
+// synchronisiert sie und benennt sie dann in filename um. Ein Absturz während des
+// Schreibens hinterlässt so nie eine halb geschriebene Datei. Die Rechte einer bereits
+// vorhandenen Datei bleiben erhalten, neue Dateien erhalten perm.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	if info, err := os.Stat(filename); err == nil {
+		perm = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("Fehler beim Anlegen der temporären Datei für %s: %v", filename, err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // nach erfolgreichem Umbenennen wirkungslos
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Fehler beim Schreiben von %s: %v", tmpName, err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Fehler beim Setzen der Rechte von %s: %v", tmpName, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Fehler beim Synchronisieren von %s: %v", tmpName, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("Fehler beim Schließen von %s: %v", tmpName, err)
+	}
+	if err := os.Rename(tmpName, filename); err != nil {
+		return fmt.Errorf("Fehler beim Umbenennen von %s nach %s: %v", tmpName, filename, err)
+	}
+	return nil
 }
 
+// configMu serialisiert das Schreiben der Konfiguration bei parallel geprüften Quellen
+var configMu sync.Mutex
+
 // loadLinkData lädt die gespeicherten Link-Daten
 func loadLinkData(filename string) (LinkData, error) {
 	var data LinkData
@@ -125,871 +1011,8409 @@ func loadLinkData(filename string) (LinkData, error) {
 	if err != nil {
 		if os.IsNotExist(err) {
 			// Datei existiert nicht, erstelle leere Daten
-			return LinkData{
-				Links:       []string{},
-				FailedLinks: []string{},
-				LastSeen:    time.Now(),
-			}, nil
+			return newLinkData(), nil
 		}
 		return data, fmt.Errorf("Fehler beim Lesen der Link-Datei: %v", err)
 	}
 
-	err = json.Unmarshal(file, &data)
+	data, err = unmarshalLinkData(file)
 	if err != nil {
-		return data, fmt.Errorf("Fehler beim Parsen der Link-Datei: %v", err)
+		return recoverLinkData(filename, err)
 	}
+	return data, nil
+}
 
-	// Stelle sicher, dass FailedLinks initialisiert ist (für alte Dateien)
-	if data.FailedLinks == nil {
-		data.FailedLinks = []string{}
+// unmarshalLinkData parst den Inhalt einer Datendatei. Ältere Dateien mit bloßen
+// Strings in links und den Maps first_seen, cities, titles und texts werden dabei
+// in LinkRecords umgewandelt und beim nächsten Speichern im neuen Format geschrieben.
+func unmarshalLinkData(raw []byte) (LinkData, error) {
+	var data LinkData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return data, err
 	}
-
+	var legacy legacyLinkFields
+	if err := json.Unmarshal(raw, &legacy); err == nil {
+		for i := range data.Links {
+			record := &data.Links[i]
+			if t, ok := legacy.FirstSeen[record.Href]; ok && record.FirstSeen.IsZero() {
+				record.FirstSeen = t
+			}
+			if record.City == "" {
+				record.City = legacy.Cities[record.Href]
+			}
+			if record.Title == "" {
+				record.Title = legacy.Titles[record.Href]
+			}
+			if record.Text == "" {
+				record.Text = legacy.Texts[record.Href]
+			}
+		}
+	}
+	initLinkData(&data)
 	return data, nil
 }
 
-// saveLinkData speichert die Link-Daten
-func saveLinkData(data LinkData, filename string) error {
-	jsonData, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return fmt.Errorf("Fehler beim Marshalling der Link-Daten: %v", err)
+// recoverLinkData wird aufgerufen, wenn die Link-Datei nicht geparst werden kann.
+// Die defekte Datei wird beiseite verschoben, danach wird das Backup geladen.
+// Gibt es kein brauchbares Backup, wird mit leeren Daten neu begonnen.
+func recoverLinkData(filename string, parseErr error) (LinkData, error) {
+	corruptName := fmt.Sprintf("%s.corrupt-%s", filename, time.Now().Format("20060102-150405"))
+	if err := os.Rename(filename, corruptName); err != nil {
+		return LinkData{}, fmt.Errorf("Fehler beim Parsen der Link-Datei: %v (Verschieben nach %s fehlgeschlagen: %v)", parseErr, corruptName, err)
+	}
+	slog.Warn("Link-Datei ist beschädigt und wurde verschoben", "file", filename, "moved_to", corruptName, "error", parseErr)
+
+	backupName := filename + ".bak"
+	backup, err := os.ReadFile(backupName)
+	if err == nil {
+		data, err := unmarshalLinkData(backup)
+		if err == nil {
+			slog.Info("♻️  Link-Daten aus Backup wiederhergestellt", "file", backupName, "links", len(data.Links))
+			return data, nil
+		}
+		slog.Warn("Backup ist ebenfalls beschädigt", "file", backupName, "error", err)
 	}
 
-	return os.WriteFile(filename, jsonData, 0644)
+	slog.Error("Kein brauchbares Backup gefunden, starte mit leeren Link-Daten", "file", filename, "moved_to", corruptName)
+	return newLinkData(), nil
 }
 
-// fetchURL ruft eine URL ab und gibt den HTML-Inhalt zurück
-func fetchURL(url string) (string, error) {
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+// newLinkData gibt leere, vollständig initialisierte Link-Daten zurück
+func newLinkData() LinkData {
+	data := LinkData{LastSeen: time.Now()}
+	initLinkData(&data)
+	return data
+}
 
-	resp, err := client.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("Fehler beim Abrufen der URL %s: %v", url, err)
+// initLinkData stellt sicher, dass alle Felder initialisiert sind (für alte Dateien)
+func initLinkData(data *LinkData) {
+	if data.Links == nil {
+		data.Links = []LinkRecord{}
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP-Fehler %d für URL %s", resp.StatusCode, url)
+	if data.FailedLinks == nil {
+		data.FailedLinks = []string{}
 	}
+	if data.PostNotBefore == nil {
+		data.PostNotBefore = map[string]time.Time{}
+	}
+	if data.Deadlines == nil {
+		data.Deadlines = map[string]time.Time{}
+	}
+	if data.RemindersSent == nil {
+		data.RemindersSent = map[string]time.Time{}
+	}
+	if data.RemovedLinks == nil {
+		data.RemovedLinks = map[string]time.Time{}
+	}
+	if data.ContentHashes == nil {
+		data.ContentHashes = map[string]string{}
+	}
+	if data.FirstObserved == nil {
+		data.FirstObserved = map[string]time.Time{}
+	}
+	if data.PostCounts == nil {
+		data.PostCounts = map[string]int{}
+	}
+	if data.Validators == nil {
+		data.Validators = map[string]CacheValidators{}
+	}
+	if data.FailedAttempts == nil {
+		data.FailedAttempts = map[string]FailedAttempt{}
+	}
+}
 
-	body, err := io.ReadAll(resp.Body)
+// platformDeferred prüft, ob eine Plattform wegen eines früheren Retry-After noch gesperrt ist
+func (d *LinkData) platformDeferred(platform string, now time.Time) (time.Time, bool) {
+	until, ok := d.PostNotBefore[platform]
+	if !ok {
+		return time.Time{}, false
+	}
+	if !now.Before(until) {
+		delete(d.PostNotBefore, platform)
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// sortedPlatforms gibt die Plattformen der Zähler alphabetisch sortiert zurück
+func sortedPlatforms(counts map[string]int) []string {
+	platforms := make([]string, 0, len(counts))
+	for platform := range counts {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+// countPost zählt einen erfolgreich erstellten Post für die Plattform
+func (d *LinkData) countPost(platform string, now time.Time) {
+	if d.CountingSince.IsZero() {
+		d.CountingSince = now
+	}
+	d.PostCounts[platform]++
+}
+
+// noteRateLimit merkt sich einen Retry-After-Zeitpunkt, falls err ein RateLimitError ist
+func (d *LinkData) noteRateLimit(platform string, err error, now time.Time) {
+	var rl *RateLimitError
+	if errors.As(err, &rl) && rl.RetryAfter > 0 {
+		d.PostNotBefore[platform] = now.Add(rl.RetryAfter)
+		slog.Warn("Rate-Limit erreicht, Plattform gesperrt", "platform", platform, "until", d.PostNotBefore[platform])
+	}
+}
+
+// saveLinkData speichert die Link-Daten
+// Vor dem Schreiben wird der bisherige Stand als <datei>.bak gesichert,
+// sofern er gültiges JSON enthält.
+func saveLinkData(data LinkData, filename string) error {
+	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
-		return "", fmt.Errorf("Fehler beim Lesen der Antwort: %v", err)
+		return fmt.Errorf("Fehler beim Marshalling der Link-Daten: %v", err)
 	}
 
-	return string(body), nil
+	if old, err := os.ReadFile(filename); err == nil && json.Valid(old) {
+		if err := writeFileAtomic(filename+".bak", old, 0644); err != nil {
+			slog.Warn("Backup der Link-Datei konnte nicht geschrieben werden", "file", filename+".bak", "error", err)
+		}
+	}
+
+	return writeFileAtomic(filename, jsonData, 0644)
 }
 
-// extractLinks extrahiert alle Links aus dem HTML-Inhalt
-func extractLinks(htmlContent string, ignoreDirs []string) ([]string, error) {
-	doc, err := htmlquery.Parse(strings.NewReader(htmlContent))
+// LinkStore speichert die Link-Daten einer Quelle. Ein Durchlauf lädt die Daten mit Load,
+// bearbeitet sie und schreibt sie mit Save zurück; die übrigen Methoden ändern einzelne
+// Links und speichern sofort.
+type LinkStore interface {
+	Load() (LinkData, error)
+	Save(data LinkData) error
+	// IsSeen meldet, ob href als gesehen (gepostet) gespeichert ist
+	IsSeen(href string) (bool, error)
+	// MarkSeen speichert einen Link als gesehen und entfernt ihn aus den fehlgeschlagenen,
+	// zurückgestellten und entfernten Links
+	MarkSeen(record LinkRecord) error
+	// MarkFailed merkt einen Link für den nächsten Versuch vor und zählt den Fehlschlag
+	MarkFailed(href string) error
+	// RemoveMissing entfernt alle gespeicherten Links, die nicht in currentLinks stehen,
+	// und gibt sie zurück
+	RemoveMissing(currentLinks []string) ([]LinkRecord, error)
+	Close() error
+}
+
+// openLinkStore öffnet den Speicher für die Link-Daten einer Quelle: die SQLite-Datenbank,
+// wenn database gesetzt ist, sonst die JSON-Datei data_file
+func openLinkStore(config Config) (LinkStore, error) {
+	if config.Database != "" {
+		return openSQLiteLinkStore(config.Database, config.DataFile)
+	}
+	return jsonLinkStore{filename: config.DataFile}, nil
+}
+
+// loadSourceData lädt die Link-Daten einer Quelle aus ihrem LinkStore
+func loadSourceData(config Config) (LinkData, error) {
+	store, err := openLinkStore(config)
 	if err != nil {
-		return nil, fmt.Errorf("Fehler beim Parsen des HTML: %v", err)
+		return LinkData{}, err
 	}
+	defer store.Close()
+	return store.Load()
+}
 
-	// XPath-Abfrage für alle Links
-	nodes, err := htmlquery.QueryAll(doc, "//a[@href]")
+// saveSourceData speichert die Link-Daten einer Quelle in ihrem LinkStore
+func saveSourceData(config Config, data LinkData) error {
+	store, err := openLinkStore(config)
 	if err != nil {
-		return nil, fmt.Errorf("Fehler bei XPath-Abfrage: %v", err)
+		return err
 	}
+	defer store.Close()
+	return store.Save(data)
+}
 
-	var links []string
-	for _, node := range nodes {
-		href := htmlquery.SelectAttr(node, "href")
-		if href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(href, "javascript:") {
-			// Nur Links zu Unterverzeichnissen mit index.htm filtern
-			if strings.Contains(href, "/") && strings.HasSuffix(href, "/index.htm") {
-				// Verzeichnisname extrahieren
-				parts := strings.Split(href, "/")
-				if len(parts) > 1 {
-					dir := parts[0]
-					ignore := false
-					for _, ign := range ignoreDirs {
-						if strings.EqualFold(dir, ign) {
-							ignore = true
-							break
-						}
-					}
-					if ignore {
-						continue
-					}
-				}
-				links = append(links, href)
-			}
-		}
+// updateLinkStore lädt die Link-Daten, wendet update an und speichert sie wieder
+func updateLinkStore(store LinkStore, update func(data *LinkData)) error {
+	data, err := store.Load()
+	if err != nil {
+		return err
 	}
+	update(&data)
+	return store.Save(data)
+}
 
-	return links, nil
+// markSeen speichert einen Link als gesehen, siehe LinkStore.MarkSeen
+func (d *LinkData) markSeen(record LinkRecord) {
+	d.addLink(record)
+	d.FailedLinks = removeString(d.FailedLinks, record.Href)
+	d.PendingLinks = removeString(d.PendingLinks, record.Href)
+	delete(d.RemovedLinks, record.Href)
 }
 
-// findNewLinks findet neue Links im Vergleich zu den gespeicherten
-func findNewLinks(currentLinks, savedLinks, failedLinks []string) []string {
-	savedMap := make(map[string]bool)
-	for _, link := range savedLinks {
-		savedMap[link] = true
+// markFailed merkt einen Link für den nächsten Versuch vor und zählt den Fehlschlag
+func (d *LinkData) markFailed(href string) {
+	if containsString(d.FailedLinks, href) {
+		return
+	}
+	d.FailedLinks = append(d.FailedLinks, href)
+	now := time.Now()
+	attempt := d.FailedAttempts[href]
+	if attempt.Count == 0 {
+		attempt.First = now
+	}
+	attempt.Count++
+	attempt.Last = now
+	d.FailedAttempts[href] = attempt
+}
+
+// markLinkSeen speichert einen geposteten Link in savedData und, falls vorhanden, sofort im
+// LinkStore des Durchlaufs. So wird er nach einem Abbruch vor dem abschließenden Save nicht
+// erneut gepostet.
+func markLinkSeen(store LinkStore, savedData *LinkData, record LinkRecord) {
+	savedData.markSeen(record)
+	if store == nil {
+		return
+	}
+	if err := store.MarkSeen(record); err != nil {
+		slog.Warn("Geposteter Link konnte nicht gespeichert werden", "link", record.Href, "error", err)
 	}
+}
 
-	var newLinks []string
-	for _, link := range currentLinks {
-		if !savedMap[link] {
-			newLinks = append(newLinks, link)
+// markLinkFailed merkt einen Link in savedData und, falls vorhanden, sofort im LinkStore
+// des Durchlaufs für den nächsten Versuch vor
+func markLinkFailed(store LinkStore, savedData *LinkData, href string) {
+	savedData.markFailed(href)
+	if store == nil {
+		return
+	}
+	if err := store.MarkFailed(href); err != nil {
+		slog.Warn("Fehlgeschlagener Link konnte nicht gespeichert werden", "link", href, "error", err)
+	}
+}
+
+// markPostFailed merkt einen Link nach einem Plattformfehler für den nächsten Versuch vor.
+// Anders als bei markFailed zählt das nicht als Fehlschlag: Der Link selbst ist in Ordnung,
+// ein Ausfall der Plattform darf nicht dazu führen, dass er aufgegeben wird.
+func (d *LinkData) markPostFailed(href string) {
+	if !containsString(d.FailedLinks, href) {
+		d.FailedLinks = append(d.FailedLinks, href)
+	}
+	delete(d.FailedAttempts, href)
+}
+
+// markPostPending stellt einen Link zurück, dessen Posts nur wegen Rate-Limit-Sperren
+// ausstehen. Er wird beim nächsten Durchlauf ohne Zählung als Fehlschlag erneut versucht.
+func (d *LinkData) markPostPending(href string) {
+	d.FailedLinks = removeString(d.FailedLinks, href)
+	if !containsString(d.PendingLinks, href) {
+		d.PendingLinks = append(d.PendingLinks, href)
+	}
+	delete(d.FailedAttempts, href)
+}
+
+// abandonFailedLinks gibt Links auf, die maxAttempts Durchläufe in Folge fehlgeschlagen
+// sind: Sie werden ohne Post als gesehen markiert und nicht mehr versucht. Zähler von
+// Links, die nicht mehr fehlschlagen, werden verworfen. maxAttempts 0 versucht unbegrenzt.
+func (d *LinkData) abandonFailedLinks(maxAttempts int) []string {
+	for href := range d.FailedAttempts {
+		if !containsString(d.FailedLinks, href) && !containsString(d.PendingLinks, href) {
+			delete(d.FailedAttempts, href)
 		}
 	}
+	if maxAttempts <= 0 {
+		return nil
+	}
+	var abandoned []string
+	for _, href := range d.FailedLinks {
+		if d.FailedAttempts[href].Count >= maxAttempts {
+			abandoned = append(abandoned, href)
+		}
+	}
+	for _, href := range abandoned {
+		d.FailedLinks = removeString(d.FailedLinks, href)
+		delete(d.FailedAttempts, href)
+		d.addLink(LinkRecord{Href: href, Skipped: skipAbandoned})
+	}
+	return abandoned
+}
 
-	// Füge fehlgeschlagene Links hinzu, die erneut versucht werden sollen
-	for _, link := range failedLinks {
-		newLinks = append(newLinks, link)
+// logAbandonedLinks gibt die Links mit zu vielen Fehlschlägen auf und protokolliert sie
+func logAbandonedLinks(config Config, data *LinkData) {
+	for _, link := range data.abandonFailedLinks(config.MaxFailedAttempts) {
+		slog.Warn("Link nach zu vielen Fehlschlägen aufgegeben, wird nicht mehr versucht (erneut versuchen mit forget)", "link", link, "attempts", config.MaxFailedAttempts)
 	}
+}
 
-	return newLinks
+// forget entfernt href aus den gesehenen, fehlgeschlagenen und zurückgestellten Links
+// samt seinen Angaben, damit er beim nächsten Durchlauf als neu gilt. Die gespeicherten
+// Validatoren werden verworfen, damit die Übersichtsseite vollständig ausgewertet wird.
+func (d *LinkData) forget(href string) bool {
+	found := d.record(href) != nil || containsString(d.FailedLinks, href) || containsString(d.PendingLinks, href)
+	if !found {
+		return false
+	}
+	links := []LinkRecord{}
+	for _, record := range d.Links {
+		if record.Href != href {
+			links = append(links, record)
+		}
+	}
+	d.Links = links
+	d.FailedLinks = removeString(d.FailedLinks, href)
+	d.PendingLinks = removeString(d.PendingLinks, href)
+	for _, m := range []map[string]time.Time{d.Deadlines, d.RemindersSent, d.FirstObserved, d.RemovedLinks} {
+		delete(m, href)
+	}
+	delete(d.ContentHashes, href)
+	delete(d.FailedAttempts, href)
+	d.Validators = map[string]CacheValidators{}
+	return true
 }
 
-// findRemovedLinks findet Links, die nicht mehr auf der Website erscheinen
-func findRemovedLinks(currentLinks, savedLinks []string) []string {
+// forgetAll leert die gesehenen Links für eine vollständige Neuauswertung und gibt die
+// Anzahl der entfernten Links zurück. Post-Zähler und Rate-Limit-Sperren bleiben erhalten.
+func (d *LinkData) forgetAll() int {
+	n := len(d.Links) + len(d.FailedLinks) + len(d.PendingLinks)
+	d.Links = []LinkRecord{}
+	d.FailedLinks = []string{}
+	d.PendingLinks = []string{}
+	d.Deadlines = map[string]time.Time{}
+	d.RemindersSent = map[string]time.Time{}
+	d.ContentHashes = map[string]string{}
+	d.FirstObserved = map[string]time.Time{}
+	d.RemovedLinks = map[string]time.Time{}
+	d.PendingRemovalNotices = nil
+	d.FailedAttempts = map[string]FailedAttempt{}
+	d.Validators = map[string]CacheValidators{}
+	return n
+}
+
+// removeMissing entfernt alle gespeicherten Links, die nicht in currentLinks stehen.
+// Ihre Frist, Erinnerung und ihr Inhalts-Hash werden verworfen, der Zeitpunkt der
+// Entfernung wird in RemovedLinks festgehalten.
+func (d *LinkData) removeMissing(currentLinks []string, now time.Time) []LinkRecord {
 	currentMap := make(map[string]bool)
 	for _, link := range currentLinks {
 		currentMap[link] = true
 	}
 
-	var removedLinks []string
-	for _, link := range savedLinks {
-		if !currentMap[link] {
-			removedLinks = append(removedLinks, link)
+	var removed []LinkRecord
+	updatedLinks := []LinkRecord{}
+	for _, record := range d.Links {
+		link := record.Href
+		if currentMap[link] {
+			updatedLinks = append(updatedLinks, record)
+			continue
 		}
+		delete(d.Deadlines, link)
+		delete(d.RemindersSent, link)
+		d.RemovedLinks[link] = now
+		delete(d.ContentHashes, link)
+		removed = append(removed, record)
 	}
+	d.Links = updatedLinks
+	return removed
+}
 
-	return removedLinks
+// jsonLinkStore speichert die Link-Daten einer Quelle in einer JSON-Datei (data_file)
+type jsonLinkStore struct {
+	filename string
 }
 
-// extractTextBetweenHR extrahiert den Text zwischen den ersten beiden <hr>-Tags aus HTML
-func extractTextBetweenHR(htmlContent string) (string, string, error) {
-	doc, err := html.Parse(strings.NewReader(htmlContent))
+func (s jsonLinkStore) Load() (LinkData, error) { return loadLinkData(s.filename) }
+
+func (s jsonLinkStore) Save(data LinkData) error { return saveLinkData(data, s.filename) }
+
+func (s jsonLinkStore) IsSeen(href string) (bool, error) {
+	data, err := s.Load()
 	if err != nil {
-		return "", "", fmt.Errorf("fehler beim Parsen des HTML: %v", err)
+		return false, err
 	}
+	return data.record(href) != nil, nil
+}
 
-	var title string
-	var textContent strings.Builder
-	var hrCount int
-	var inSection bool
+func (s jsonLinkStore) MarkSeen(record LinkRecord) error {
+	return updateLinkStore(s, func(data *LinkData) { data.markSeen(record) })
+}
 
-	var f func(*html.Node)
-	f = func(n *html.Node) {
-		if n.Type == html.ElementNode && n.Data == "hr" {
-			hrCount++
-			if hrCount == 1 {
-				inSection = true
-				return
-			} else if hrCount == 2 {
-				inSection = false
-				return
-			}
-		}
+func (s jsonLinkStore) MarkFailed(href string) error {
+	return updateLinkStore(s, func(data *LinkData) { data.markFailed(href) })
+}
 
-		if inSection {
-			if n.Type == html.ElementNode && n.Data == "h3" {
-				// Überschrift extrahieren
-				for c := n.FirstChild; c != nil; c = c.NextSibling {
-					if c.Type == html.TextNode {
-						title += c.Data
-					}
-				}
-			} else if n.Type == html.ElementNode && (n.Data == "strong" || n.Data == "b") {
-				// Fett-Text extrahieren
-				textContent.WriteString("**")
-				for c := n.FirstChild; c != nil; c = c.NextSibling {
-					if c.Type == html.TextNode {
-						textContent.WriteString(c.Data)
-					}
-				}
-				textContent.WriteString("**")
-			} else if n.Type == html.ElementNode && (n.Data == "em" || n.Data == "i") {
-				// Kursiv-Text extrahieren
-				textContent.WriteString("*")
-				for c := n.FirstChild; c != nil; c = c.NextSibling {
-					if c.Type == html.TextNode {
-						textContent.WriteString(c.Data)
-					}
-				}
-				textContent.WriteString("*")
-			} else if n.Type == html.ElementNode && n.Data == "br" {
-				textContent.WriteString("\n")
-			} else if n.Type == html.ElementNode && n.Data == "p" {
-				textContent.WriteString("\n\n")
-			} else if n.Type == html.TextNode {
-				// Nur Text extrahieren, wenn es nicht in einem bereits verarbeiteten Tag ist
-				parent := n.Parent
-				if parent != nil && parent.Type == html.ElementNode {
-					if parent.Data != "strong" && parent.Data != "b" && parent.Data != "em" && parent.Data != "i" {
-						textContent.WriteString(n.Data)
-					}
-				} else {
-					textContent.WriteString(n.Data)
-				}
-			}
-		}
+func (s jsonLinkStore) RemoveMissing(currentLinks []string) ([]LinkRecord, error) {
+	var removed []LinkRecord
+	err := updateLinkStore(s, func(data *LinkData) { removed = data.removeMissing(currentLinks, time.Now()) })
+	return removed, err
+}
 
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			f(c)
-		}
-	}
-	f(doc)
+func (s jsonLinkStore) Close() error { return nil }
 
-	text := strings.TrimSpace(textContent.String())
-	title = strings.TrimSpace(title)
+// memLinkStore hält die Link-Daten im Speicher, z.B. um die Erkennung neuer und entfernter
+// Links ohne Dateien zu prüfen. Gespeichert wird wie bei jsonLinkStore das JSON, damit jeder
+// Load eine unabhängige Kopie liefert.
+type memLinkStore struct {
+	mu  sync.Mutex
+	raw []byte
+}
 
-	// Standard-Formularzeile entfernen
-	text = strings.ReplaceAll(text, "Erwerbsinteressierte Landwirtinnen und Landwirte können ihr Erwerbsinteresse mit dem unten stehenden Formular bekunden.", "")
-	text = strings.ReplaceAll(text, "  ", " ")
-	text = strings.TrimSpace(text)
+// newMemLinkStore gibt einen Speicher mit den Link-Daten data zurück
+func newMemLinkStore(data LinkData) (*memLinkStore, error) {
+	s := &memLinkStore{}
+	return s, s.Save(data)
+}
 
-	return title, text, nil
+func (s *memLinkStore) Load() (LinkData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.raw == nil {
+		return newLinkData(), nil
+	}
+	return unmarshalLinkData(s.raw)
 }
 
-// truncateString kürzt einen String auf die angegebene Länge
-func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+func (s *memLinkStore) Save(data LinkData) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("Fehler beim Marshalling der Link-Daten: %v", err)
 	}
-	return s[:maxLen] + "..."
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.raw = raw
+	return nil
 }
 
-// extractCityName extrahiert den Stadtnamen aus der Detailseite
-func extractCityName(htmlContent string) string {
-	doc, err := html.Parse(strings.NewReader(htmlContent))
+func (s *memLinkStore) IsSeen(href string) (bool, error) {
+	data, err := s.Load()
 	if err != nil {
-		return ""
+		return false, err
 	}
+	return data.record(href) != nil, nil
+}
 
-	var cityName string
-	var f func(*html.Node)
-	f = func(n *html.Node) {
-		// 1. Suche nach h1-Tag (Hauptüberschrift)
-		if n.Type == html.ElementNode && n.Data == "h1" {
-			for c := n.FirstChild; c != nil; c = c.NextSibling {
-				if c.Type == html.TextNode {
-					cityName = strings.TrimSpace(c.Data)
-					return
-				}
-			}
-		}
+func (s *memLinkStore) MarkSeen(record LinkRecord) error {
+	return updateLinkStore(s, func(data *LinkData) { data.markSeen(record) })
+}
 
-		// 2. Fallback: Suche nach "Sie sind hier:" Zeile
-		if n.Type == html.TextNode {
-			text := strings.TrimSpace(n.Data)
-			if strings.Contains(text, "Sie sind hier:") {
-				// Suche nach dem letzten ">" um den Stadtnamen zu finden
-				parts := strings.Split(text, ">")
-				if len(parts) > 1 {
-					cityName = strings.TrimSpace(parts[len(parts)-1])
-					return
-				}
-			}
-		}
+func (s *memLinkStore) MarkFailed(href string) error {
+	return updateLinkStore(s, func(data *LinkData) { data.markFailed(href) })
+}
 
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			f(c)
-		}
-	}
-	f(doc)
+func (s *memLinkStore) RemoveMissing(currentLinks []string) ([]LinkRecord, error) {
+	var removed []LinkRecord
+	err := updateLinkStore(s, func(data *LinkData) { removed = data.removeMissing(currentLinks, time.Now()) })
+	return removed, err
+}
 
-	return cityName
+func (s *memLinkStore) Close() error { return nil }
+
+// sqliteSchema legt die Tabellen der SQLite-Datenbank an. Mehrere Quellen teilen sich eine
+// Datenbank und werden über ihre data_file unterschieden. Links und fehlgeschlagene Links
+// liegen in eigenen Tabellen, damit pro Durchlauf nur geänderte Zeilen geschrieben werden;
+// die übrigen Felder von LinkData stehen als JSON in state.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS links (
+	source     TEXT NOT NULL,
+	href       TEXT NOT NULL,
+	position   INTEGER NOT NULL,
+	title      TEXT NOT NULL DEFAULT '',
+	city       TEXT NOT NULL DEFAULT '',
+	text       TEXT NOT NULL DEFAULT '',
+	first_seen TEXT NOT NULL DEFAULT '',
+	posted_to  TEXT NOT NULL DEFAULT '',
+	skipped    TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (source, href)
+);
+CREATE TABLE IF NOT EXISTS failed_links (
+	source   TEXT NOT NULL,
+	href     TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	PRIMARY KEY (source, href)
+);
+CREATE TABLE IF NOT EXISTS state (
+	source TEXT PRIMARY KEY,
+	data   TEXT NOT NULL
+);
+`
+
+// sqliteLinkStore speichert die Link-Daten einer Quelle in einer SQLite-Datenbank
+type sqliteLinkStore struct {
+	db     *sql.DB
+	source string
 }
 
-// checkWebsite überprüft die Website auf neue Links
-func checkWebsite(config Config, testMode bool) error {
-	log.Printf("Überprüfe Website: %s", config.URL)
+// openSQLiteLinkStore öffnet die Datenbank und legt bei Bedarf das Schema an. Gibt es für
+// die Quelle noch keine Daten, wird eine vorhandene JSON-Datei dataFile übernommen.
+func openSQLiteLinkStore(filename, dataFile string) (*sqliteLinkStore, error) {
+	db, err := openSQLiteDB(filename)
+	if err != nil {
+		return nil, err
+	}
+	s := &sqliteLinkStore{db: db, source: dataFile}
+	if err := s.importJSON(dataFile); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
 
-	// HTML-Inhalt abrufen
-	htmlContent, err := fetchURL(config.URL)
+// openSQLiteDB öffnet die Datenbank filename und legt bei Bedarf das Schema an. Bei
+// ":memory:" nutzen alle Anfragen dieselbe Verbindung, sonst hätte jede ihre eigene Datenbank.
+func openSQLiteDB(filename string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+filename+"?_pragma=busy_timeout(5000)")
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("Fehler beim Öffnen der Datenbank %s: %v", filename, err)
 	}
+	if filename == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Fehler beim Anlegen des Schemas in %s: %v", filename, err)
+	}
+	// Datenbanken aus älteren Versionen erhalten die Spalte skipped nachträglich
+	var hasSkipped int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('links') WHERE name = 'skipped'`).Scan(&hasSkipped); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Fehler beim Lesen des Schemas in %s: %v", filename, err)
+	}
+	if hasSkipped == 0 {
+		if _, err := db.Exec(`ALTER TABLE links ADD COLUMN skipped TEXT NOT NULL DEFAULT ''`); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("Fehler beim Erweitern des Schemas in %s: %v", filename, err)
+		}
+	}
+	return db, nil
+}
 
-	// Links extrahieren
-	currentLinks, err := extractLinks(htmlContent, config.IgnoreDirs)
+// importJSON übernimmt beim ersten Öffnen die Link-Daten aus der JSON-Datei der Quelle.
+// Die Datei bleibt unverändert liegen.
+func (s *sqliteLinkStore) importJSON(dataFile string) error {
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM state WHERE source = ?`, s.source).Scan(&n); err != nil {
+		return fmt.Errorf("Fehler beim Lesen der Datenbank: %v", err)
+	}
+	if n > 0 {
+		return nil
+	}
+	if _, err := os.Stat(dataFile); err != nil {
+		return nil
+	}
+	data, err := loadLinkData(dataFile)
 	if err != nil {
 		return err
 	}
+	if err := s.Save(data); err != nil {
+		return err
+	}
+	log.Printf("📦 %d Links aus %s in die Datenbank übernommen", len(data.Links), dataFile)
+	return nil
+}
 
-	log.Printf("Gefundene Links: %d", len(currentLinks))
+// migrationResult beschreibt die übernommenen Link-Daten einer Quelle
+type migrationResult struct {
+	DataFile string
+	Links    int
+	Failed   int
+	Pending  int
+}
 
-	// Gespeicherte Links laden
-	savedData, err := loadLinkData(config.DataFile)
+// migrateToSQLite übernimmt die JSON-Dateien aller Quellen in die Datenbank db und prüft
+// danach, ob die Anzahl der Links, fehlgeschlagenen und zurückgestellten Links übereinstimmt.
+// Die JSON-Dateien bleiben unverändert. Quellen, die bereits Daten in der Datenbank haben,
+// werden nicht überschrieben.
+func migrateToSQLite(db *sql.DB, config Config) ([]migrationResult, error) {
+	var results []migrationResult
+	for _, source := range sourceConfigs(config) {
+		if _, err := os.Stat(source.DataFile); err != nil {
+			return results, fmt.Errorf("Link-Datei %s nicht gefunden: %v", source.DataFile, err)
+		}
+		store := &sqliteLinkStore{db: db, source: source.DataFile}
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM state WHERE source = ?`, store.source).Scan(&n); err != nil {
+			return results, fmt.Errorf("Fehler beim Lesen der Datenbank: %v", err)
+		}
+		if n > 0 {
+			return results, fmt.Errorf("Die Datenbank enthält bereits Link-Daten für %s", source.DataFile)
+		}
+		data, err := loadLinkData(source.DataFile)
+		if err != nil {
+			return results, err
+		}
+		if err := store.Save(data); err != nil {
+			return results, err
+		}
+		migrated, err := store.Load()
+		if err != nil {
+			return results, err
+		}
+		result := migrationResult{DataFile: source.DataFile, Links: len(migrated.Links), Failed: len(migrated.FailedLinks), Pending: len(migrated.PendingLinks)}
+		if result.Links != len(data.Links) || result.Failed != len(data.FailedLinks) || result.Pending != len(data.PendingLinks) {
+			return results, fmt.Errorf("Anzahl in der Datenbank weicht ab für %s: %d/%d/%d Links/fehlgeschlagen/zurückgestellt statt %d/%d/%d",
+				source.DataFile, result.Links, result.Failed, result.Pending, len(data.Links), len(data.FailedLinks), len(data.PendingLinks))
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s *sqliteLinkStore) Load() (LinkData, error) {
+	var raw string
+	err := s.db.QueryRow(`SELECT data FROM state WHERE source = ?`, s.source).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return newLinkData(), nil
+	}
 	if err != nil {
-		return err
+		return LinkData{}, fmt.Errorf("Fehler beim Lesen der Link-Daten: %v", err)
+	}
+	var data LinkData
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return LinkData{}, fmt.Errorf("Fehler beim Parsen der Link-Daten: %v", err)
 	}
 
-	// Neue Links finden (inklusive fehlgeschlagene Links)
-	newLinks := findNewLinks(currentLinks, savedData.Links, savedData.FailedLinks)
-	
-	// Logge fehlgeschlagene Links, die erneut versucht werden
-	if len(savedData.FailedLinks) > 0 {
-		log.Printf("🔄 Fehlgeschlagene Links werden erneut versucht (%d):", len(savedData.FailedLinks))
-		for i, link := range savedData.FailedLinks {
-			log.Printf("  %d. %s", i+1, link)
+	stored, err := s.storedLinks()
+	if err != nil {
+		return LinkData{}, err
+	}
+	data.Links = make([]LinkRecord, len(stored))
+	for i, link := range stored {
+		data.Links[i] = link.record
+	}
+
+	rows, err := s.db.Query(`SELECT href FROM failed_links WHERE source = ? ORDER BY position`, s.source)
+	if err != nil {
+		return LinkData{}, fmt.Errorf("Fehler beim Lesen der fehlgeschlagenen Links: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var href string
+		if err := rows.Scan(&href); err != nil {
+			return LinkData{}, err
 		}
+		data.FailedLinks = append(data.FailedLinks, href)
 	}
-	// Entfernte Links finden
-	removedLinks := findRemovedLinks(currentLinks, savedData.Links)
+	if err := rows.Err(); err != nil {
+		return LinkData{}, err
+	}
+	initLinkData(&data)
+	return data, nil
+}
 
-	// Lemmy-Login nur durchführen, wenn neue Links gefunden wurden
-	var jwt string
-	var communityID int
-	if len(newLinks) > 0 {
-		log.Printf("🚨 NEUE LINKS GEFUNDEN (%d):", len(newLinks))
-		
-		// Fehlgeschlagene Links für diesen Durchgang zurücksetzen
-		savedData.FailedLinks = []string{}
+// storedLink ist eine Zeile der Tabelle links. position gibt die Reihenfolge vor, in der
+// die Links gespeichert wurden; beim Entfernen eines Links entstehen Lücken.
+type storedLink struct {
+	record   LinkRecord
+	position int
+}
 
-		// Lemmy-Login nur einmal pro Check durchführen
-		if config.LemmyToken != "" && time.Now().Before(config.LemmyTokenExp) {
-			// Verwende gespeichertes Token
-			jwt = config.LemmyToken
-			log.Printf("Verwende gespeichertes Lemmy-Token (gültig bis %v)", config.LemmyTokenExp)
-		} else {
-			// Hole neues Token
-			jwt, err = lemmyLogin(config.LemmyServer, config.LemmyUsername, config.LemmyPassword)
-			if err != nil {
-				log.Printf("Fehler beim Lemmy-Login: %v", err)
-				jwt = ""
-			} else {
-				// Token für 1 Stunde speichern
-				config.LemmyToken = jwt
-				config.LemmyTokenExp = time.Now().Add(1 * time.Hour)
-				log.Printf("Neues Lemmy-Token geholt und gespeichert (gültig bis %v)", config.LemmyTokenExp)
-			}
+// storedLinks liest alle Links der Quelle in gespeicherter Reihenfolge aus der Datenbank
+func (s *sqliteLinkStore) storedLinks() ([]storedLink, error) {
+	rows, err := s.db.Query(`SELECT href, position, title, city, text, first_seen, posted_to, skipped FROM links WHERE source = ? ORDER BY position`, s.source)
+	if err != nil {
+		return nil, fmt.Errorf("Fehler beim Lesen der Links: %v", err)
+	}
+	defer rows.Close()
+	var links []storedLink
+	for rows.Next() {
+		var link storedLink
+		var firstSeen, postedTo string
+		r := &link.record
+		if err := rows.Scan(&r.Href, &link.position, &r.Title, &r.City, &r.Text, &firstSeen, &postedTo, &r.Skipped); err != nil {
+			return nil, err
 		}
-
-		// Community-ID für neue Links abfragen
-		if jwt != "" {
-			communityID, err = lemmyGetCommunityID(config.LemmyServer, jwt, config.LemmyCommunity)
-			if err != nil {
-				log.Printf("Fehler beim Abrufen der Community-ID: %v", err)
-				communityID = 0
-			} else {
-				log.Printf("Community-ID für '%s': %d", config.LemmyCommunity, communityID)
+		if firstSeen != "" {
+			if r.FirstSeen, err = time.Parse(time.RFC3339Nano, firstSeen); err != nil {
+				return nil, fmt.Errorf("Ungültiger Zeitpunkt %q für %s: %v", firstSeen, r.Href, err)
 			}
 		}
+		if postedTo != "" {
+			r.PostedTo = strings.Split(postedTo, "\n")
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
 
-		for i, link := range newLinks {
-			log.Printf("  %d. %s", i+1, link)
-
-			// Detailseite abrufen und Text extrahieren
-			pageURL := config.URL
-			if !strings.HasSuffix(pageURL, "/") {
-				pageURL += "/"
-			}
-			pageURL += link
-			log.Printf("    Abrufe Detailseite: %s", pageURL)
-			pageContent, err := fetchURL(pageURL)
-			if err != nil {
-				log.Printf("    Fehler beim Abrufen der Detailseite %s: %v", pageURL, err)
-				continue
-			}
-			log.Printf("    Detailseite erfolgreich abgerufen, Länge: %d Zeichen", len(pageContent))
-			extractedTitle, text, err := extractTextBetweenHR(pageContent)
-			if err != nil {
-				log.Printf("    Fehler beim Extrahieren des Textes aus %s: %v", pageURL, err)
+// Save schreibt nur die Links, die sich gegenüber der Datenbank geändert haben. Neue Links
+// werden hinter den bereits gespeicherten einsortiert.
+func (s *sqliteLinkStore) Save(data LinkData) error {
+	links, err := s.storedLinks()
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]storedLink, len(links))
+	nextPosition := 0
+	for _, link := range links {
+		stored[link.record.Href] = link
+		nextPosition = link.position + 1
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Fehler beim Speichern der Link-Daten: %v", err)
+	}
+	defer tx.Rollback()
+
+	seen := make(map[string]bool, len(data.Links))
+	for _, record := range data.Links {
+		seen[record.Href] = true
+		position := nextPosition
+		if old, ok := stored[record.Href]; ok {
+			if old.record.Title == record.Title && old.record.City == record.City && old.record.Text == record.Text &&
+				old.record.FirstSeen.Equal(record.FirstSeen) && slices.Equal(old.record.PostedTo, record.PostedTo) && old.record.Skipped == record.Skipped {
 				continue
 			}
-			log.Printf("    Text extrahiert, Länge: %d Zeichen", len(text))
+			position = old.position
+		} else {
+			nextPosition++
+		}
+		firstSeen := ""
+		if !record.FirstSeen.IsZero() {
+			firstSeen = record.FirstSeen.Format(time.RFC3339Nano)
+		}
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO links (source, href, position, title, city, text, first_seen, posted_to, skipped) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			s.source, record.Href, position, record.Title, record.City, record.Text, firstSeen, strings.Join(record.PostedTo, "\n"), record.Skipped); err != nil {
+			return fmt.Errorf("Fehler beim Speichern von %s: %v", record.Href, err)
+		}
+	}
+	for href := range stored {
+		if !seen[href] {
+			if _, err := tx.Exec(`DELETE FROM links WHERE source = ? AND href = ?`, s.source, href); err != nil {
+				return fmt.Errorf("Fehler beim Entfernen von %s: %v", href, err)
+			}
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM failed_links WHERE source = ?`, s.source); err != nil {
+		return fmt.Errorf("Fehler beim Speichern der fehlgeschlagenen Links: %v", err)
+	}
+	for i, href := range data.FailedLinks {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO failed_links (source, href, position) VALUES (?, ?, ?)`, s.source, href, i); err != nil {
+			return fmt.Errorf("Fehler beim Speichern der fehlgeschlagenen Links: %v", err)
+		}
+	}
+
+	rest := data
+	rest.Links = nil
+	rest.FailedLinks = nil
+	raw, err := json.Marshal(rest)
+	if err != nil {
+		return fmt.Errorf("Fehler beim Marshalling der Link-Daten: %v", err)
+	}
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO state (source, data) VALUES (?, ?)`, s.source, string(raw)); err != nil {
+		return fmt.Errorf("Fehler beim Speichern der Link-Daten: %v", err)
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteLinkStore) IsSeen(href string) (bool, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM links WHERE source = ? AND href = ?`, s.source, href).Scan(&n)
+	return n > 0, err
+}
+
+// MarkSeen schreibt nur die Zeile des Links und die übrigen Felder in state
+func (s *sqliteLinkStore) MarkSeen(record LinkRecord) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Fehler beim Speichern von %s: %v", record.Href, err)
+	}
+	defer tx.Rollback()
+
+	var position int
+	var firstSeen string
+	err = tx.QueryRow(`SELECT position, first_seen FROM links WHERE source = ? AND href = ?`, s.source, record.Href).Scan(&position, &firstSeen)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = tx.QueryRow(`SELECT COALESCE(MAX(position) + 1, 0) FROM links WHERE source = ?`, s.source).Scan(&position)
+		firstSeen = ""
+	}
+	if err != nil {
+		return fmt.Errorf("Fehler beim Lesen von %s: %v", record.Href, err)
+	}
+	// Wie bei addLink bleibt ein vorhandener FirstSeen-Zeitpunkt erhalten
+	if firstSeen == "" && !record.FirstSeen.IsZero() {
+		firstSeen = record.FirstSeen.Format(time.RFC3339Nano)
+	}
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO links (source, href, position, title, city, text, first_seen, posted_to, skipped) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.source, record.Href, position, record.Title, record.City, record.Text, firstSeen, strings.Join(record.PostedTo, "\n"), record.Skipped); err != nil {
+		return fmt.Errorf("Fehler beim Speichern von %s: %v", record.Href, err)
+	}
+	if _, err := tx.Exec(`DELETE FROM failed_links WHERE source = ? AND href = ?`, s.source, record.Href); err != nil {
+		return fmt.Errorf("Fehler beim Speichern von %s: %v", record.Href, err)
+	}
+	err = s.updateState(tx, func(data *LinkData) {
+		data.PendingLinks = removeString(data.PendingLinks, record.Href)
+		delete(data.RemovedLinks, record.Href)
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MarkFailed fügt den Link an die fehlgeschlagenen Links an und zählt den Fehlschlag in state
+func (s *sqliteLinkStore) MarkFailed(href string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Fehler beim Speichern von %s: %v", href, err)
+	}
+	defer tx.Rollback()
+
+	var n int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM failed_links WHERE source = ? AND href = ?`, s.source, href).Scan(&n); err != nil {
+		return fmt.Errorf("Fehler beim Lesen der fehlgeschlagenen Links: %v", err)
+	}
+	if n > 0 {
+		return nil
+	}
+	if _, err := tx.Exec(`INSERT INTO failed_links (source, href, position) SELECT ?, ?, COALESCE(MAX(position) + 1, 0) FROM failed_links WHERE source = ?`, s.source, href, s.source); err != nil {
+		return fmt.Errorf("Fehler beim Speichern der fehlgeschlagenen Links: %v", err)
+	}
+	// In state stehen keine fehlgeschlagenen Links, markFailed zählt also immer
+	if err := s.updateState(tx, func(data *LinkData) { data.markFailed(href) }); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveMissing löscht nur die Zeilen der entfernten Links
+func (s *sqliteLinkStore) RemoveMissing(currentLinks []string) ([]LinkRecord, error) {
+	stored, err := s.storedLinks()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("Fehler beim Entfernen der Links: %v", err)
+	}
+	defer tx.Rollback()
+
+	var removed []LinkRecord
+	err = s.updateState(tx, func(data *LinkData) {
+		data.Links = make([]LinkRecord, len(stored))
+		for i, link := range stored {
+			data.Links[i] = link.record
+		}
+		removed = data.removeMissing(currentLinks, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range removed {
+		if _, err := tx.Exec(`DELETE FROM links WHERE source = ? AND href = ?`, s.source, record.Href); err != nil {
+			return nil, fmt.Errorf("Fehler beim Entfernen von %s: %v", record.Href, err)
+		}
+	}
+	return removed, tx.Commit()
+}
+
+// updateState wendet update auf die in state gespeicherten Felder der Quelle an. Links und
+// fehlgeschlagene Links stehen in eigenen Tabellen und werden nicht nach state geschrieben.
+func (s *sqliteLinkStore) updateState(tx *sql.Tx, update func(data *LinkData)) error {
+	var raw string
+	data := newLinkData()
+	err := tx.QueryRow(`SELECT data FROM state WHERE source = ?`, s.source).Scan(&raw)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("Fehler beim Lesen der Link-Daten: %v", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal([]byte(raw), &data); err != nil {
+			return fmt.Errorf("Fehler beim Parsen der Link-Daten: %v", err)
+		}
+		initLinkData(&data)
+	}
+	update(&data)
+	data.Links = nil
+	data.FailedLinks = nil
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("Fehler beim Marshalling der Link-Daten: %v", err)
+	}
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO state (source, data) VALUES (?, ?)`, s.source, string(encoded)); err != nil {
+		return fmt.Errorf("Fehler beim Speichern der Link-Daten: %v", err)
+	}
+	return nil
+}
+
+func (s *sqliteLinkStore) Close() error { return s.db.Close() }
+
+// ErrPageTooLarge wird zurückgegeben, wenn eine Seite größer als MaxPageBytes ist
+var ErrPageTooLarge = errors.New("Seite überschreitet die maximale Größe")
+
+// HTTPStatusError wird von fetchURL bei einer Antwort ungleich 200 zurückgegeben
+type HTTPStatusError struct {
+	StatusCode int
+	URL        string
+	RetryAfter time.Duration // aus dem Retry-After-Header, 0 wenn nicht gesetzt
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("HTTP-Fehler %d für URL %s", e.StatusCode, e.URL)
+}
+
+// defaultUserAgent identifiziert den Bot gegenüber der Quelle und den Plattformen
+const defaultUserAgent = "gvgbot/1.0 (+https://github.com/mdornseif/grundstueckverkehrsgesetz-nrw)"
+
+// userAgent wird von newRequest gesetzt; loadConfig übernimmt den Wert aus Config.UserAgent
+var userAgent = defaultUserAgent
+
+// defaultHTTPTimeout ist die Standard-Zeitbegrenzung für ausgehende HTTP-Anfragen
+const defaultHTTPTimeout = 30 * time.Second
+
+// httpClient wird für alle ausgehenden HTTP-Anfragen verwendet; loadConfig setzt das
+// Timeout aus Config.HTTPTimeout
+var httpClient = &http.Client{Timeout: defaultHTTPTimeout, Transport: rateLimitedTransport{http.DefaultTransport}}
+
+// sourceLimiter begrenzt die Anfragen an die überwachte Website, platformLimiter alle
+// übrigen Anfragen (Plattform-APIs). loadConfig setzt beide aus der Konfiguration.
+var (
+	sourceLimiter   = newRateLimiter(0)
+	platformLimiter = newRateLimiter(0)
+)
+
+// newRateLimiter erstellt einen Token-Bucket mit perSecond Anfragen pro Sekunde, 0 = unbegrenzt
+func newRateLimiter(perSecond float64) *rate.Limiter {
+	if perSecond <= 0 {
+		return rate.NewLimiter(rate.Inf, 1)
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), 1)
+}
+
+// sourceRequestKey markiert im Kontext Anfragen, die bereits über sourceLimiter begrenzt wurden
+type sourceRequestKey struct{}
+
+// waitForSource wartet auf sourceLimiter und gibt einen Kontext zurück, dessen Anfragen
+// nicht zusätzlich über platformLimiter laufen
+func waitForSource(ctx context.Context) (context.Context, error) {
+	if err := sourceLimiter.Wait(ctx); err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, sourceRequestKey{}, true), nil
+}
+
+// rateLimitedTransport wartet vor jeder Anfrage, die nicht an die Quelle geht, auf platformLimiter
+type rateLimitedTransport struct {
+	base http.RoundTripper
+}
+
+// proxyTransport gibt den Transport für httpClient zurück: ohne proxyURL den Standard-Transport,
+// der die Proxy-Umgebungsvariablen beachtet, sonst eine Kopie, die alle Anfragen über proxyURL leitet
+func proxyTransport(proxyURL string) (http.RoundTripper, error) {
+	if proxyURL == "" {
+		return http.DefaultTransport, nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("Ungültige proxy_url %q", proxyURL)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("Ungültige proxy_url %q (erlaubt: http://, https://, socks5://)", proxyURL)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(u)
+	return transport, nil
+}
+
+func (t rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Context().Value(sourceRequestKey{}) == nil {
+		if err := platformLimiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// newRequest erstellt eine HTTP-Anfrage mit gesetztem User-Agent
+func newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	return req, nil
+}
+
+// maxDrainBytes begrenzt, wie viel einer nicht mehr benötigten Antwort vor dem Schließen
+// noch gelesen wird. Längere Reste werden verworfen, die Verbindung dann neu aufgebaut.
+const maxDrainBytes = 64 << 10
+
+// closeBody liest den Rest einer Antwort und schließt sie. Nur vollständig gelesene Antworten
+// geben ihre Keep-Alive-Verbindung an den Transport zurück, was im Loop-Modus zählt.
+func closeBody(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+	resp.Body.Close()
+}
+
+// doRequest sendet req über httpClient und gibt die Antwort mit vollständig gelesenem Body
+// zurück. Der Body der Antwort ist danach bereits geschlossen.
+func doRequest(req *http.Request) (*http.Response, []byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeBody(resp)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp, nil, fmt.Errorf("Fehler beim Lesen der Antwort: %v", err)
+	}
+	return resp, body, nil
+}
+
+// httpPost sendet body per POST mit dem angegebenen Content-Type über httpClient
+func httpPost(ctx context.Context, url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := newRequest(ctx, "POST", url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return httpClient.Do(req)
+}
+
+// fetchSourceURL ruft eine Seite der Quelle ab. SourceHeaders werden nur mitgesendet,
+// wenn die Seite auf dem Host der Quelle liegt.
+func fetchSourceURL(ctx context.Context, config Config, pageURL string) (string, error) {
+	body, _, err := fetchURLWithRetry(ctx, pageURL, config.MaxPageBytes, sourceHeaders(config, pageURL), config.MaxRetries)
+	return body, err
+}
+
+// ErrNotModified wird zurückgegeben, wenn der Server eine Seite mit HTTP 304 als unverändert meldet
+var ErrNotModified = errors.New("Seite unverändert (HTTP 304)")
 
-			// Stadtnamen extrahieren
-			cityName := extractCityName(pageContent)
-			if cityName != "" {
-				log.Printf("    Stadtnamen extrahiert: %s", cityName)
+// fetchSourceURLConditional ruft eine Seite der Quelle wie fetchSourceURL ab und sendet dabei
+// If-None-Match/If-Modified-Since aus validators. Meldet der Server die Seite als unverändert,
+// wird ErrNotModified zurückgegeben. Sonst werden die neuen Validatoren mit zurückgegeben.
+func fetchSourceURLConditional(ctx context.Context, config Config, pageURL string, validators CacheValidators) (string, CacheValidators, error) {
+	headers := map[string]string{}
+	for name, value := range sourceHeaders(config, pageURL) {
+		headers[name] = value
+	}
+	if validators.ETag != "" {
+		headers["If-None-Match"] = validators.ETag
+	}
+	if validators.LastModified != "" {
+		headers["If-Modified-Since"] = validators.LastModified
+	}
+	body, header, err := fetchURLWithRetry(ctx, pageURL, config.MaxPageBytes, headers, config.MaxRetries)
+	if err != nil {
+		return "", validators, err
+	}
+	return body, CacheValidators{ETag: header.Get("ETag"), LastModified: header.Get("Last-Modified")}, nil
+}
+
+// retryBaseDelay und retryMaxDelay begrenzen das exponentielle Backoff von fetchURLWithRetry
+const (
+	retryBaseDelay = 2 * time.Second
+	retryMaxDelay  = time.Minute
+)
+
+// fetchURLWithRetry ruft pageURL wie fetchURL ab und wiederholt den Abruf bis zu maxRetries
+// Mal bei Verbindungsfehlern sowie HTTP 5xx und 429. Andere 4xx-Antworten schlagen sofort fehl.
+func fetchURLWithRetry(ctx context.Context, pageURL string, maxBytes int64, headers map[string]string, maxRetries int) (string, http.Header, error) {
+	for attempt := 0; ; attempt++ {
+		body, header, err := fetchURLHeader(ctx, pageURL, maxBytes, headers)
+		if err == nil || attempt >= maxRetries || !retryableFetchError(err) || ctx.Err() != nil {
+			return body, header, err
+		}
+		delay := retryDelay(attempt)
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			if statusErr.RetryAfter > maxInlineRetryAfter {
+				return body, header, err
 			}
+			delay = statusErr.RetryAfter
+		}
+		slog.Warn("Anfrage fehlgeschlagen, neuer Versuch", "attempt", attempt+1, "max_retries", maxRetries, "delay", delay.Round(time.Millisecond), "error", err)
+		if !sleepContext(ctx, delay) {
+			return body, header, err
+		}
+	}
+}
 
-			if text != "" {
-				log.Printf("--- Auszug aus %s ---\n%s\n--------------------------", link, text)
+// retryableFetchError gibt an, ob ein Fehler von fetchURL vorübergehend sein kann
+func retryableFetchError(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
 
-				// --- NEU: Plattform-Checks ---
-				lemmyConfigured := config.LemmyServer != "" && config.LemmyCommunity != "" && config.LemmyUsername != "" && config.LemmyPassword != ""
-				mastodonConfigured := config.MastodonServer != "" && config.MastodonAccessToken != ""
+// retryDelay gibt die Wartezeit vor dem Versuch attempt+1 zurück: exponentiell wachsend
+// mit zufälligem Jitter zwischen der Hälfte und dem vollen Wert
+func retryDelay(attempt int) time.Duration {
+	delay := retryMaxDelay
+	if attempt < 10 {
+		delay = min(retryBaseDelay<<attempt, retryMaxDelay)
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
 
-				if !lemmyConfigured && !mastodonConfigured {
-					log.Printf("    ❌ Weder Lemmy noch Mastodon sind konfiguriert. Link wird nicht als erledigt markiert.")
-					savedData.FailedLinks = append(savedData.FailedLinks, link)
-					continue
-				}
+// defaultAcceptLanguage fordert deutsche Inhalte an, falls die Quelle die Sprache aushandelt
+const defaultAcceptLanguage = "de-DE,de;q=0.9"
 
-				var postErrs []string
-				lemmySuccess := true
-				mastodonSuccess := true
+// sourceHeaders gibt die zusätzlichen Header für pageURL zurück, sofern pageURL auf dem
+// Host von config.URL liegt: Accept-Language (Standard deutsch) und SourceHeaders
+func sourceHeaders(config Config, pageURL string) map[string]string {
+	source, err := url.Parse(config.URL)
+	if err != nil {
+		return nil
+	}
+	page, err := url.Parse(pageURL)
+	if err != nil || !strings.EqualFold(page.Host, source.Host) {
+		return nil
+	}
+	headers := map[string]string{"Accept-Language": defaultAcceptLanguage}
+	for name, value := range config.SourceHeaders {
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+	return headers
+}
 
-				// --- Lemmy Post ---
-				if lemmyConfigured {
-					title := cityName + ": Grundstücksverkauf an Nicht-LandwirtIn"
-					if cityName == "" {
-						title = strings.Title(strings.Split(link, "/")[0]) + ": Grundstücksverkauf an Nicht-LandwirtIn"
-					}
-					if extractedTitle != "" {
-						title += " " + extractedTitle
-					}
-					if !testMode {
-						if jwt != "" {
-							err = lemmyCreatePost(config.LemmyServer, jwt, communityID, title, text, pageURL)
-							if err != nil {
-								log.Printf("    ❌ Fehler beim Erstellen des Lemmy-Posts: %v", err)
-								lemmySuccess = false
-								postErrs = append(postErrs, "Lemmy: "+err.Error())
-							} else {
-								log.Printf("    ✅ Lemmy-Post erfolgreich erstellt für %s", link)
-							}
-						} else {
-							log.Printf("    ❌ Kein gültiges Lemmy-Token, Lemmy-Post übersprungen.")
-							lemmySuccess = false
-							postErrs = append(postErrs, "Lemmy: Kein gültiges Token")
-						}
-					} else {
-						log.Printf("🧪 TEST: Lemmy-Post würde erstellt werden:")
-						log.Printf("    Server: %s", config.LemmyServer)
-						log.Printf("    Community: %s (ID: %d)", config.LemmyCommunity, communityID)
-						log.Printf("    URL: %s", pageURL)
-						log.Printf("    Titel: %s", title)
-						log.Printf("    Text (erste 200 Zeichen): %s", truncateString(text, 200))
-						if len(text) > 200 {
-							log.Printf("    ... (Text ist %d Zeichen lang)", len(text))
-						}
-						log.Printf("    Vollständiger Text:")
-						log.Printf("    ---")
-						log.Printf("%s", text)
-						log.Printf("    ---")
-					}
-				}
+// fetchURL ruft eine URL ab und gibt den HTML-Inhalt zurück.
+// Bei maxBytes > 0 wird höchstens so viel gelesen, größere Seiten führen zu ErrPageTooLarge.
+// headers werden zusätzlich zur Anfrage gesetzt. Wird ctx abgebrochen, bricht auch der Abruf ab.
+func fetchURL(ctx context.Context, url string, maxBytes int64, headers map[string]string) (string, error) {
+	body, _, err := fetchURLHeader(ctx, url, maxBytes, headers)
+	return body, err
+}
 
-				// --- Mastodon Post ---
-				if mastodonConfigured {
-					// Token-Handling wie bei Lemmy
-					mastodonToken := config.MastodonAccessToken
-					if mastodonToken == "" || (config.MastodonToken != "" && time.Now().After(config.MastodonTokenExp)) {
-						if config.MastodonUsername != "" && config.MastodonPassword != "" && config.MastodonClientID != "" && config.MastodonClientSecret != "" {
-							log.Printf("    Mastodon: Hole neues Access Token per Passwort...")
-							token, exp, err := mastodonLogin(config.MastodonServer, config.MastodonClientID, config.MastodonClientSecret, config.MastodonUsername, config.MastodonPassword)
-							if err != nil {
-								log.Printf("    ❌ Fehler beim Mastodon-Login: %v", err)
-								mastodonSuccess = false
-								postErrs = append(postErrs, "Mastodon-Login: "+err.Error())
-							} else {
-								mastodonToken = token
-								config.MastodonToken = token
-								config.MastodonTokenExp = exp
-								log.Printf("    Mastodon: Neues Token geholt und gespeichert (gültig bis %v)", exp)
-							}
-						}
-					}
-					if mastodonToken == "" {
-						if config.MastodonUsername != "" || config.MastodonPassword != "" || config.MastodonClientID != "" || config.MastodonClientSecret != "" {
-							log.Printf("    ❌ Mastodon: Kein Access Token verfügbar und Login mit Username/Passwort/ClientID/Secret nicht möglich (z.B. GoToSocial). Bitte ein App-Passwort (mastodon_access_token) verwenden.")
-						}
-						log.Printf("    ❌ Kein Mastodon-Token verfügbar, Mastodon-Post übersprungen.")
-						mastodonSuccess = false
-						postErrs = append(postErrs, "Mastodon: Kein Token")
-					} else if !testMode {
-						mastodonText := text
-						if cityName != "" {
-							mastodonText = cityName + ": Grundstücksverkauf an Nicht-LandwirtIn\n" + text
-						}
-						err = mastodonCreatePost(config.MastodonServer, mastodonToken, mastodonText, config.MastodonVisibility)
-						if err != nil {
-							log.Printf("    ❌ Fehler beim Erstellen des Mastodon-Posts: %v", err)
-							mastodonSuccess = false
-							postErrs = append(postErrs, "Mastodon: "+err.Error())
-						} else {
-							log.Printf("    ✅ Mastodon-Post erfolgreich erstellt für %s", link)
-						}
-					} else if testMode {
-						mastodonText := text
-						if cityName != "" {
-							mastodonText = cityName + ": Grundstücksverkauf an Nicht-LandwirtIn\n" + text
-						}
-						log.Printf("🧪 TEST: Mastodon-Post würde erstellt werden:")
-						log.Printf("    Server: %s", config.MastodonServer)
-						log.Printf("    Sichtbarkeit: %s", config.MastodonVisibility)
-						log.Printf("    Text (erste 200 Zeichen): %s", truncateString(mastodonText, 200))
-						if len(mastodonText) > 200 {
-							log.Printf("    ... (Text ist %d Zeichen lang)", len(mastodonText))
-						}
-						log.Printf("    Vollständiger Text:")
-						log.Printf("    ---")
-						log.Printf("%s", mastodonText)
-						log.Printf("    ---")
-					}
-				}
+// fetchURLHeader arbeitet wie fetchURL und gibt zusätzlich die Header der Antwort zurück.
+// Eine Antwort mit HTTP 304 ergibt ErrNotModified.
+func fetchURLHeader(ctx context.Context, url string, maxBytes int64, headers map[string]string) (string, http.Header, error) {
+	ctx, err := waitForSource(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("Fehler beim Abrufen der URL %s: %w", url, err)
+	}
+	req, err := newRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("Fehler beim Abrufen der URL %s: %v", url, err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("Fehler beim Abrufen der URL %s: %w", url, err)
+	}
+	defer closeBody(resp)
 
-				if (lemmyConfigured && !lemmySuccess) || (mastodonConfigured && !mastodonSuccess) {
-					log.Printf("    ❌ Mindestens ein Post fehlgeschlagen (%s). Link wird erneut versucht.", strings.Join(postErrs, "; "))
-					savedData.FailedLinks = append(savedData.FailedLinks, link)
-				} else {
-					log.Printf("    ✅ Link erfolgreich auf allen konfigurierten Plattformen gepostet: %s", link)
-					savedData.Links = append(savedData.Links, link)
-				}
-			} else {
-				log.Printf("    Kein Text zwischen <hr>-Tags gefunden")
+	if resp.StatusCode == http.StatusNotModified {
+		return "", resp.Header, ErrNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", resp.Header, &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	var reader io.Reader = resp.Body
+	if maxBytes > 0 {
+		reader = io.LimitReader(resp.Body, maxBytes+1)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return "", resp.Header, fmt.Errorf("Fehler beim Lesen der Antwort: %v", err)
+	}
+	if maxBytes > 0 && int64(len(body)) > maxBytes {
+		return "", resp.Header, fmt.Errorf("%w: %s ist größer als %d Bytes", ErrPageTooLarge, url, maxBytes)
+	}
+
+	return string(body), resp.Header, nil
+}
+
+// defaultLinkPattern entspricht Links auf Unterverzeichnisse mit index.htm (z.B. "koeln/index.htm")
+const defaultLinkPattern = `/index\.htm$`
+
+var defaultLinkRe = regexp.MustCompile(defaultLinkPattern)
+
+// linkPatternWarnAnchors ist die Anzahl an Links auf der Übersichtsseite, ab der eine Warnung
+// ausgegeben wird, wenn keiner davon LinkPattern entspricht
+const linkPatternWarnAnchors = 10
+
+// linkPattern gibt das kompilierte LinkPattern zurück, ohne Angabe das Standardmuster
+func linkPattern(config Config) *regexp.Regexp {
+	if config.linkRe != nil {
+		return config.linkRe
+	}
+	return defaultLinkRe
+}
+
+// extractLinks extrahiert alle Links aus dem HTML-Inhalt, die LinkPattern entsprechen
+// und in einem überwachten Verzeichnis liegen (siehe watchedDir)
+func extractLinks(htmlContent string, config Config) ([]string, error) {
+	base, err := linkBase(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("Ungültige URL der Übersichtsseite %s: %v", config.URL, err)
+	}
+	doc, err := htmlquery.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, fmt.Errorf("Fehler beim Parsen des HTML: %v", err)
+	}
+	return extractDocLinks(doc, base, config)
+}
+
+// extractDocLinks gibt die Listing-Links eines geparsten Dokuments zurück. Relative hrefs
+// werden gegen pageBase aufgelöst und danach relativ zur Übersichtsseite normalisiert.
+func extractDocLinks(doc *html.Node, pageBase *url.URL, config Config) ([]string, error) {
+	base, err := linkBase(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("Ungültige URL der Übersichtsseite %s: %v", config.URL, err)
+	}
+	pattern := linkPattern(config)
+
+	// XPath-Abfrage für alle Links
+	nodes, err := htmlquery.QueryAll(doc, "//a[@href]")
+	if err != nil {
+		return nil, fmt.Errorf("Fehler bei XPath-Abfrage: %v", err)
+	}
+
+	var links []string
+	for _, node := range nodes {
+		href := htmlquery.SelectAttr(node, "href")
+		if href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(href, "javascript:") {
+			ref, err := url.Parse(strings.TrimSpace(href))
+			if err != nil {
+				continue
+			}
+			href, err = canonicalLink(base, pageBase.ResolveReference(ref).String())
+			if err != nil {
+				continue
+			}
+			// Nur Links filtern, die dem Muster entsprechen (Standard: Unterverzeichnisse mit index.htm)
+			if pattern.MatchString(href) && watchedDir(href, config) {
+				links = append(links, href)
 			}
 		}
 	}
 
-	if len(removedLinks) > 0 {
-		log.Printf("🗑️  ENTFERNTE LINKS (%d):", len(removedLinks))
-		for i, link := range removedLinks {
-			log.Printf("  %d. %s", i+1, link)
+	if len(links) == 0 && len(nodes) >= linkPatternWarnAnchors {
+		slog.Warn("Keiner der Links auf der Übersichtsseite entspricht link_pattern, hat sich das URL-Schema geändert?", "url", config.URL, "anchors", len(nodes), "link_pattern", pattern.String())
+	}
+	return links, nil
+}
+
+// watchedDir prüft das oberste Verzeichnis eines normalisierten Links: Ist WatchDirs gesetzt,
+// muss es darin enthalten sein (Allowlist); danach schließt IgnoreDirs Verzeichnisse aus
+// (Denylist). Links ohne Verzeichnis werden nur ohne WatchDirs berücksichtigt.
+func watchedDir(href string, config Config) bool {
+	dir, _, found := strings.Cut(href, "/")
+	if len(config.WatchDirs) > 0 && (!found || !containsFold(config.WatchDirs, dir)) {
+		return false
+	}
+	return !found || !containsFold(config.IgnoreDirs, dir)
+}
+
+// extractAllLinks wertet die Übersichtsseite aus und folgt, wenn NextPageXPath gesetzt ist,
+// den Links zu den Folgeseiten, bis keine nächste Seite mehr gefunden wird, eine Seite
+// erneut erscheint oder MaxPages erreicht ist. Die Links aller Seiten werden ohne
+// Duplikate zusammengefasst. Schlägt der Abruf einer Folgeseite fehl, wird ein Fehler
+// zurückgegeben, damit die Links späterer Seiten nicht als entfernt gelten.
+func extractAllLinks(ctx context.Context, config Config, htmlContent string) ([]string, error) {
+	pageBase, err := linkBase(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("Ungültige URL der Übersichtsseite %s: %v", config.URL, err)
+	}
+	doc, err := htmlquery.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, fmt.Errorf("Fehler beim Parsen des HTML: %v", err)
+	}
+	links, err := extractDocLinks(doc, pageBase, config)
+	if err != nil || config.NextPageXPath == "" {
+		return links, err
+	}
+	// Der Link zur Folgeseite bezieht sich auf die tatsächliche Seiten-URL, nicht auf das
+	// Verzeichnis, gegen das Listing-Links aufgelöst werden
+	pageURL, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("Ungültige URL der Übersichtsseite %s: %v", config.URL, err)
+	}
+
+	seen := make(map[string]bool)
+	for _, link := range links {
+		seen[link] = true
+	}
+	visited := map[string]bool{pageURL.String(): true}
+	for pages := 1; ; pages++ {
+		next, err := nextPageURL(doc, pageURL, config.NextPageXPath)
+		if err != nil {
+			return nil, err
 		}
-		
-		// Entferne gelöschte Links aus der gespeicherten Liste
-		currentMap := make(map[string]bool)
-		for _, link := range currentLinks {
-			currentMap[link] = true
+		if next == nil || visited[next.String()] {
+			break
 		}
-		
-		var updatedLinks []string
-		for _, link := range savedData.Links {
-			if currentMap[link] {
-				updatedLinks = append(updatedLinks, link)
-			}
+		if pages >= config.MaxPages {
+			slog.Warn("Maximale Seitenzahl der Übersicht erreicht, weitere Seiten werden ignoriert", "url", next.String(), "max_pages", config.MaxPages)
+			break
+		}
+		visited[next.String()] = true
+		if !robotsAllowedURL(ctx, next.String()) {
+			return nil, fmt.Errorf("robots.txt verbietet den Abruf von %s", next)
+		}
+		slog.Info("Folgeseite", "page", pages+1, "url", next.String())
+		content, err := fetchSourceURL(ctx, config, next.String())
+		if err != nil {
+			return nil, fmt.Errorf("Fehler beim Abrufen der Folgeseite %s: %v", next, err)
+		}
+		if doc, err = htmlquery.Parse(strings.NewReader(content)); err != nil {
+			return nil, fmt.Errorf("Fehler beim Parsen der Folgeseite %s: %v", next, err)
 		}
-		savedData.Links = updatedLinks
+		pageURL = next
+		pageLinks, err := extractDocLinks(doc, pageURL, config)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range pageLinks {
+			if !seen[link] {
+				seen[link] = true
+				links = append(links, link)
+			}
+		}
+	}
+	return links, nil
+}
+
+// nextPageURL sucht mit xpath den Link zur nächsten Seite. Der Ausdruck darf ein Element
+// mit href oder direkt das href-Attribut auswählen. Ohne Treffer wird nil zurückgegeben.
+func nextPageURL(doc *html.Node, pageURL *url.URL, xpath string) (*url.URL, error) {
+	node, err := htmlquery.Query(doc, xpath)
+	if err != nil {
+		return nil, fmt.Errorf("Ungültiger next_page_xpath %q: %v", xpath, err)
+	}
+	if node == nil {
+		return nil, nil
+	}
+	href := htmlquery.SelectAttr(node, "href")
+	if href == "" {
+		href = htmlquery.InnerText(node)
+	}
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "javascript:") {
+		return nil, nil
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return nil, nil
+	}
+	next := pageURL.ResolveReference(ref)
+	next.Fragment = ""
+	return next, nil
+}
+
+// findNewLinks findet neue Links im Vergleich zu den gespeicherten
+func findNewLinks(currentLinks []string, savedLinks []LinkRecord, failedLinks []string) []string {
+	savedMap := make(map[string]bool)
+	for _, record := range savedLinks {
+		savedMap[record.Href] = true
+	}
+
+	var newLinks []string
+	for _, link := range currentLinks {
+		if !savedMap[link] {
+			newLinks = append(newLinks, link)
+		}
+	}
+
+	// Füge fehlgeschlagene Links hinzu, die erneut versucht werden sollen
+	// (jeder Link höchstens einmal pro Durchlauf)
+	seen := make(map[string]bool)
+	for _, link := range newLinks {
+		seen[link] = true
+	}
+	for _, link := range failedLinks {
+		if !seen[link] {
+			seen[link] = true
+			newLinks = append(newLinks, link)
+		}
+	}
+
+	return newLinks
+}
+
+// debounceLinks gibt die neuen Links zurück, die seit mindestens debounce auf der Website
+// stehen. Jüngere Links werden mit dem Zeitpunkt der ersten Beobachtung vorgemerkt;
+// verschwundene oder bereits gepostete Links werden aus den Vormerkungen entfernt.
+// Fehlgeschlagene und zurückgestellte Links behalten ihren Zeitpunkt, auch wenn sie nicht
+// mehr auf der Übersichtsseite stehen, damit sie weiter erneut versucht werden.
+func debounceLinks(data *LinkData, newLinks, currentLinks []string, debounce time.Duration, now time.Time) []string {
+	current := make(map[string]bool, len(currentLinks))
+	for _, link := range currentLinks {
+		current[link] = true
+	}
+	for _, link := range append(data.FailedLinks, data.PendingLinks...) {
+		current[link] = true
+	}
+	for _, record := range data.Links {
+		delete(data.FirstObserved, record.Href)
+	}
+	for link := range data.FirstObserved {
+		if !current[link] {
+			delete(data.FirstObserved, link)
+		}
+	}
+
+	var ready []string
+	for _, link := range newLinks {
+		observed, ok := data.FirstObserved[link]
+		if !ok {
+			observed = now
+			data.FirstObserved[link] = now
+		}
+		if now.Sub(observed) >= debounce {
+			ready = append(ready, link)
+			continue
+		}
+		log.Printf("⏱️  %s ist erst seit %v sichtbar und wird frühestens nach %v gepostet", link, now.Sub(observed).Round(time.Second), debounce)
+	}
+	return ready
+}
+
+// findRemovedLinks findet Links, die nicht mehr auf der Website erscheinen
+func findRemovedLinks(currentLinks []string, savedLinks []LinkRecord) []string {
+	currentMap := make(map[string]bool)
+	for _, link := range currentLinks {
+		currentMap[link] = true
+	}
+
+	var removedLinks []string
+	for _, record := range savedLinks {
+		if !currentMap[record.Href] {
+			removedLinks = append(removedLinks, record.Href)
+		}
+	}
+
+	return removedLinks
+}
+
+// extractTextBetweenHR extrahiert den Text zwischen dem start-ten und dem end-ten <hr>-Tag
+// (1-basiert, Standard 1 und 2) aus HTML
+func extractTextBetweenHR(htmlContent string, start, end int) (string, string, error) {
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return "", "", fmt.Errorf("fehler beim Parsen des HTML: %v", err)
+	}
+
+	var section sectionText
+	var hrCount int
+	var inSection bool
+
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "hr" {
+			hrCount++
+			if hrCount == start {
+				inSection = true
+				return
+			} else if hrCount == end {
+				inSection = false
+				return
+			}
+		}
+
+		if inSection {
+			section.add(n)
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+
+		if inSection {
+			section.end(n)
+		}
+	}
+	f(doc)
+
+	title, text := section.result()
+	return title, text, nil
+}
+
+// sectionText sammelt Überschrift (<h3>) und Text eines Seitenabschnitts im Markdown-Stil
+type sectionText struct {
+	title     string
+	text      strings.Builder
+	listDepth int // Verschachtelungstiefe von <ul>/<ol>
+}
+
+// add übernimmt einen einzelnen Knoten; Kindknoten werden vom Aufrufer durchlaufen
+func (s *sectionText) add(n *html.Node) {
+	if n.Type == html.ElementNode && n.Data == "h3" {
+		// Überschrift extrahieren
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode {
+				s.title += c.Data
+			}
+		}
+	} else if marker := emphasisMarker(n); marker != "" {
+		// Fett- bzw. Kursiv-Text: der Text selbst folgt über die Kindknoten, geschlossen wird in end
+		s.text.WriteString(marker)
+	} else if n.Type == html.ElementNode && n.Data == "br" {
+		s.text.WriteString("\n")
+	} else if n.Type == html.ElementNode && n.Data == "p" {
+		s.text.WriteString("\n\n")
+	} else if n.Type == html.ElementNode && (n.Data == "ul" || n.Data == "ol") {
+		// Listen als Markdown-Aufzählung mit Leerzeile davor ausgeben
+		if s.listDepth == 0 {
+			s.text.WriteString("\n")
+		}
+		s.listDepth++
+	} else if n.Type == html.ElementNode && n.Data == "li" {
+		depth := s.listDepth
+		if depth < 1 {
+			depth = 1
+		}
+		s.text.WriteString("\n" + strings.Repeat("  ", depth-1) + "- ")
+	} else if n.Type == html.TextNode {
+		// Text übernehmen; in Listen wird der Leerraum aus dem Quelltext bereinigt
+		parent := n.Parent
+		if parent != nil && parent.Type == html.ElementNode {
+			switch parent.Data {
+			case "ul", "ol":
+				// Zeilenumbrüche und Einrückung zwischen den <li> des Quelltexts
+				if strings.TrimSpace(n.Data) != "" {
+					s.text.WriteString(n.Data)
+				}
+			case "li":
+				s.text.WriteString(listItemText(n))
+			default:
+				s.text.WriteString(n.Data)
+			}
+		} else {
+			s.text.WriteString(n.Data)
+		}
+	}
+}
+
+// emphasisMarker gibt die Markdown-Markierung für <strong>/<b> bzw. <em>/<i> zurück, sonst ""
+func emphasisMarker(n *html.Node) string {
+	if n.Type != html.ElementNode {
+		return ""
+	}
+	switch n.Data {
+	case "strong", "b":
+		return "**"
+	case "em", "i":
+		return "*"
+	}
+	return ""
+}
+
+// end wird nach den Kindknoten eines Knotens aufgerufen und schließt Hervorhebungen und Listen ab
+func (s *sectionText) end(n *html.Node) {
+	if marker := emphasisMarker(n); marker != "" {
+		s.text.WriteString(marker)
+	}
+	if n.Type == html.ElementNode && (n.Data == "ul" || n.Data == "ol") && s.listDepth > 0 {
+		s.listDepth--
+		if s.listDepth == 0 {
+			s.text.WriteString("\n\n")
+		}
+	}
+}
+
+// listItemText fasst Leerraum im Text eines <li> zusammen, damit jeder Listeneintrag
+// eine Zeile bleibt. Am Anfang und Ende des Eintrags wird Leerraum entfernt.
+func listItemText(n *html.Node) string {
+	text := strings.Join(strings.Fields(n.Data), " ")
+	if text == "" {
+		if n.PrevSibling != nil && n.NextSibling != nil {
+			return " "
+		}
+		return ""
+	}
+	if n.PrevSibling != nil && unicode.IsSpace(rune(n.Data[0])) {
+		text = " " + text
+	}
+	if next := n.NextSibling; next != nil && next.Data != "ul" && next.Data != "ol" && unicode.IsSpace(rune(n.Data[len(n.Data)-1])) {
+		text += " "
+	}
+	return text
+}
+
+// addTree übernimmt einen Knoten mit allen Kindknoten
+func (s *sectionText) addTree(n *html.Node) {
+	s.add(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		s.addTree(c)
+	}
+	s.end(n)
+}
+
+// excessNewlinesRe findet mehr als eine Leerzeile, z.B. nach einer Liste vor einem <p>
+var excessNewlinesRe = regexp.MustCompile(`\n[ \t]*\n(?:[ \t]*\n)+`)
+
+// collapseDoubleSpaces ersetzt doppelte Leerzeichen durch einfache. Die Einrückung
+// verschachtelter Listeneinträge bleibt erhalten.
+func collapseDoubleSpaces(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		item := strings.TrimLeft(line, " ")
+		if indent := line[:len(line)-len(item)]; indent != "" && strings.HasPrefix(item, "- ") {
+			lines[i] = indent + strings.ReplaceAll(item, "  ", " ")
+		} else {
+			lines[i] = strings.ReplaceAll(line, "  ", " ")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// result gibt die bereinigte Überschrift und den bereinigten Text zurück
+func (s *sectionText) result() (string, string) {
+	text := strings.TrimSpace(s.text.String())
+	title := strings.TrimSpace(s.title)
+
+	// Standard-Formularzeile entfernen
+	text = strings.ReplaceAll(text, "Erwerbsinteressierte Landwirtinnen und Landwirte können ihr Erwerbsinteresse mit dem unten stehenden Formular bekunden.", "")
+	text = collapseDoubleSpaces(text)
+	text = excessNewlinesRe.ReplaceAllString(text, "\n\n")
+	text = strings.TrimSpace(text)
+
+	return title, text
+}
+
+// extractListingText extrahiert Überschrift und Text einer Detailseite. Bevorzugt wird der
+// Abschnitt zwischen den <hr>-Tags; hat die Seite dafür zu wenige <hr>-Tags, wird der
+// Container aus ContentXPath bzw. der Block mit dem meisten Fließtext verwendet.
+func extractListingText(htmlContent string, config Config) (string, string, error) {
+	doc, err := htmlquery.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return "", "", fmt.Errorf("fehler beim Parsen des HTML: %v", err)
+	}
+	if len(htmlquery.Find(doc, "//hr")) >= config.HRSectionEnd {
+		return extractTextBetweenHR(htmlContent, config.HRSectionStart, config.HRSectionEnd)
+	}
+
+	var container *html.Node
+	if config.ContentXPath != "" {
+		container, err = htmlquery.Query(doc, config.ContentXPath)
+		if err != nil {
+			return "", "", fmt.Errorf("Ungültiger content_xpath %q: %v", config.ContentXPath, err)
+		}
+	} else {
+		container = densestTextBlock(doc)
+	}
+	if container == nil {
+		return "", "", nil
+	}
+
+	log.Printf("    Weniger als %d <hr>-Tags gefunden, verwende Inhalts-Container <%s>", config.HRSectionEnd, container.Data)
+	var section sectionText
+	section.addTree(container)
+	title, text := section.result()
+	return title, text, nil
+}
+
+// densestTextBlock sucht das Element, dessen direkte Absätze zusammen den meisten Text
+// enthalten. Navigation und Fußzeilen bestehen meist aus Links und kurzen Zeilen und
+// verlieren so gegen den eigentlichen Inhalt.
+func densestTextBlock(doc *html.Node) *html.Node {
+	scores := map[*html.Node]int{}
+	var best *html.Node
+
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "p" || n.Data == "h3") && n.Parent != nil {
+			scores[n.Parent] += len(strings.TrimSpace(htmlquery.InnerText(n)))
+			if best == nil || scores[n.Parent] > scores[best] {
+				best = n.Parent
+			}
+		}
+		switch n.Data {
+		case "script", "style", "nav", "header", "footer":
+			if n.Type == html.ElementNode {
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(doc)
+	return best
+}
+
+// truncateBacktrack ist die größte Anzahl Zeichen, die truncateString für eine Wortgrenze zurückgeht
+const truncateBacktrack = 20
+
+// truncateString kürzt einen String auf höchstens maxLen Zeichen (nicht Bytes) und hängt "..." an.
+// Geschnitten wird möglichst am letzten Leerzeichen vor dem Limit, damit kein Wort zerteilt wird.
+// Liegt es mehr als truncateBacktrack Zeichen zurück, wird hart am Limit geschnitten.
+func truncateString(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	cut := maxLen
+	for i := maxLen; i > 0 && i >= maxLen-truncateBacktrack; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace) + "..."
+}
+
+// platformLimit gibt das Zeichenlimit für eine Plattform zurück
+func platformLimit(config Config, platform string) int {
+	if limit, ok := config.PlatformLimits[platform]; ok && limit > 0 {
+		return limit
+	}
+	if platform == "mastodon" && config.MastodonMaxChars > 0 {
+		return config.MastodonMaxChars
+	}
+	return defaultPlatformLimits[platform]
+}
+
+// truncateForPlatform kürzt einen Text auf höchstens limit Zeichen (inklusive Auslassungszeichen)
+func truncateForPlatform(s string, limit int) string {
+	runes := []rune(s)
+	if limit <= 0 || len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// excerptText kürzt einen Text auf höchstens length Zeichen und hängt einen Link auf die
+// Quelle an. Geschnitten wird möglichst am letzten Satzende innerhalb des Limits, sonst am
+// letzten Leerzeichen. Texte, die bereits ins Limit passen, bleiben unverändert.
+func excerptText(text string, length int, sourceURL string) string {
+	runes := []rune(strings.TrimSpace(text))
+	if length <= 0 || len(runes) <= length {
+		return text
+	}
+	cut := -1
+	for i := 0; i < length; i++ {
+		switch runes[i] {
+		case '.', '!', '?':
+			if unicode.IsSpace(runes[i+1]) {
+				cut = i + 1
+			}
+		}
+	}
+	if cut < 0 {
+		for i := length; i > 0; i-- {
+			if unicode.IsSpace(runes[i]) {
+				cut = i
+				break
+			}
+		}
+	}
+	if cut <= 0 {
+		cut = length
+	}
+	return strings.TrimSpace(string(runes[:cut])) + " … (mehr: " + sourceURL + ")"
+}
+
+// disclaimerSeparators trennt den Disclaimer je Plattform vom Text. Lemmy rendert
+// Markdown und erhält eine Trennlinie, Mastodon nur eine Leerzeile.
+var disclaimerSeparators = map[string]string{
+	"lemmy":    "\n\n---\n",
+	"mastodon": "\n\n",
+	"bluesky":  "\n\n",
+	"telegram": "\n\n",
+	"discord":  "\n\n",
+}
+
+// fitForPlatform hängt den Disclaimer an und kürzt auf das Zeichenlimit der Plattform.
+// Gekürzt wird nur der eigentliche Text, damit der Disclaimer immer vollständig bleibt.
+func fitForPlatform(config Config, platform, text string) string {
+	return fitToLimit(config, platform, text, platformLimit(config, platform))
+}
+
+// fitToLimit arbeitet wie fitForPlatform, aber mit einem vorgegebenen Limit
+func fitToLimit(config Config, platform, text string, limit int) string {
+	if config.Disclaimer == "" {
+		return truncateForPlatform(text, limit)
+	}
+	separator, ok := disclaimerSeparators[platform]
+	if !ok {
+		separator = "\n\n"
+	}
+	suffix := separator + config.Disclaimer
+	if limit <= 0 {
+		return text + suffix
+	}
+	room := limit - utf8.RuneCountInString(suffix)
+	if room < 1 {
+		// Der Disclaimer allein füllt das Limit bereits aus
+		return truncateForPlatform(config.Disclaimer, limit)
+	}
+	return truncateForPlatform(text, room) + suffix
+}
+
+// ListingDetails enthält die strukturierten Angaben zu einem Flurstück
+type ListingDetails struct {
+	Gemarkung        string  `json:"gemarkung,omitempty"`
+	Flur             string  `json:"flur,omitempty"`
+	Flurstueck       string  `json:"flurstueck,omitempty"`
+	AreaSquareMeters float64 `json:"area_square_meters,omitempty"`
+	PriceEuro        float64 `json:"price_euro,omitempty"`
+	Category         string    `json:"category,omitempty"`
+	Deadline         time.Time `json:"deadline,omitempty"`
+}
+
+var deadlineRe = regexp.MustCompile(`(?i)bis\s+(?:zum|spätestens|einschließlich)?\s*(?:(?:Montag|Dienstag|Mittwoch|Donnerstag|Freitag|Samstag|Sonntag),?\s*(?:den\s+)?)?(\d{1,2})\.\s*(\d{1,2})\.\s*(\d{4})`)
+
+// parseDeadline sucht eine Frist wie "bis zum 15.03.2025" im Text
+func parseDeadline(text string) (time.Time, bool) {
+	m := deadlineRe.FindStringSubmatch(text)
+	if m == nil {
+		return time.Time{}, false
+	}
+	day, _ := strconv.Atoi(m[1])
+	month, _ := strconv.Atoi(m[2])
+	year, _ := strconv.Atoi(m[3])
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+	deadline := time.Date(year, time.Month(month), day, 23, 59, 59, 0, time.Local)
+	if deadline.Day() != day {
+		return time.Time{}, false
+	}
+	return deadline, true
+}
+
+// knownCategories sind die Nutzungsarten, die im Text erkannt werden
+var knownCategories = []string{"Ackerland", "Grünland", "Wald", "Gartenland", "Unland"}
+
+var (
+	categoryRe        = regexp.MustCompile(`(?i)Nutzungsart:?\s+([\pL\-]+)`)
+	categoryKeywordRe = regexp.MustCompile(`(?i)\b(` + strings.Join(knownCategories, "|") + `)\b`)
+)
+
+// parseCategory bestimmt die Nutzungsart aus dem Text oder gibt "" zurück
+func parseCategory(text string) string {
+	if m := categoryRe.FindStringSubmatch(text); m != nil {
+		for _, c := range knownCategories {
+			if strings.EqualFold(m[1], c) {
+				return c
+			}
+		}
+		return m[1]
+	}
+	if m := categoryKeywordRe.FindStringSubmatch(text); m != nil {
+		for _, c := range knownCategories {
+			if strings.EqualFold(m[1], c) {
+				return c
+			}
+		}
+	}
+	return ""
+}
+
+// categoryAllowed prüft die Nutzungsart gegen IncludeCategories und ExcludeCategories
+func categoryAllowed(config Config, category string) bool {
+	if category == "" {
+		return true
+	}
+	if len(config.IncludeCategories) > 0 && !containsFold(config.IncludeCategories, category) {
+		return false
+	}
+	return !containsFold(config.ExcludeCategories, category)
+}
+
+// containsFold prüft, ob list s enthält (ohne Beachtung der Groß-/Kleinschreibung)
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	gemarkungRe  = regexp.MustCompile(`Gemarkung:?\s+([A-ZÄÖÜ][\pL\-]*(?:[ \-][A-ZÄÖÜ(][\pL\-()]*)*)`)
+	flurRe       = regexp.MustCompile(`\bFlur:?\s+(\d+)`)
+	flurstueckRe = regexp.MustCompile(`Flurst(?:ück|ueck|\.)(?:e)?:?\s+(\d+(?:/\d+)?(?:\s*(?:,|und)\s*\d+(?:/\d+)?)*)`)
+	areaRe       = regexp.MustCompile(`(\d{1,3}(?:\.\d{3})+(?:,\d+)?|\d+(?:,\d+)?)\s*(m²|m2|qm|ha)(?:[^\pL\d]|$)`) // \b greift nach "²" nicht
+	priceRe      = regexp.MustCompile(`(\d{1,3}(?:\.\d{3})+(?:,\d+)?|\d+(?:,\d+)?)\s*(?:€|EUR\b|Euro\b)`)
+)
+
+// parseGermanNumber wandelt eine Zahl in deutscher Schreibweise ("1.234,56") in float64 um
+func parseGermanNumber(s string) (float64, error) {
+	s = strings.ReplaceAll(s, ".", "")
+	s = strings.ReplaceAll(s, ",", ".")
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseListingDetails sucht Gemarkung, Flur, Flurstück, Fläche und Preis im Text.
+// Nicht gefundene Angaben bleiben leer.
+func parseListingDetails(text string) ListingDetails {
+	plain := strings.NewReplacer("**", "", "*", "").Replace(text)
+	var d ListingDetails
+	if m := gemarkungRe.FindStringSubmatch(plain); m != nil {
+		d.Gemarkung = strings.TrimSpace(m[1])
+	}
+	if m := flurRe.FindStringSubmatch(plain); m != nil {
+		d.Flur = m[1]
+	}
+	if m := flurstueckRe.FindStringSubmatch(plain); m != nil {
+		d.Flurstueck = strings.TrimSpace(m[1])
+	}
+	if m := areaRe.FindStringSubmatch(plain); m != nil {
+		if v, err := parseGermanNumber(m[1]); err == nil {
+			if m[2] == "ha" {
+				v *= 10000
+			}
+			d.AreaSquareMeters = v
+		}
+	}
+	if m := priceRe.FindStringSubmatch(plain); m != nil {
+		if v, err := parseGermanNumber(m[1]); err == nil {
+			d.PriceEuro = v
+		}
+	}
+	d.Category = parseCategory(plain)
+	if deadline, ok := parseDeadline(plain); ok {
+		d.Deadline = deadline
+	}
+	return d
+}
+
+// formatGermanNumber formatiert eine Zahl mit Tausenderpunkten und Dezimalkomma.
+// Nachkommastellen, die nur aus Nullen bestehen, werden weggelassen.
+func formatGermanNumber(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	fracPart = strings.TrimRight(fracPart, "0")
+
+	var b strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	if fracPart != "" {
+		b.WriteString("," + fracPart)
+	}
+	return b.String()
+}
+
+// PostData enthält die Variablen für die Post-Vorlagen
+type PostData struct {
+	City       string
+	Title      string
+	Text       string
+	URL        string
+	Permalink  string // leer, wenn StatusBaseURL nicht gesetzt ist
+	Area       string // z.B. "2,3 ha" oder "850 m²"
+	Price      string // z.B. "45.000 €"
+	Gemarkung  string
+	Flurstueck string
+	Category   string // z.B. "Ackerland"
+	Hashtag    string // z.B. "#Ackerland"
+	Deadline   string // z.B. "15.03.2025"
+
+	// Attachments enthält die Formulare und Anhänge (PDF, DOC, DOCX) der Detailseite
+	Attachments []Attachment
+
+	deadline time.Time
+}
+
+// Attachment ist ein auf der Detailseite verlinktes Dokument, z.B. das Formular zur
+// Bekundung des Erwerbsinteresses
+type Attachment struct {
+	Title string
+	URL   string
+}
+
+// attachmentExtensions sind die Dateiendungen, die als Anhang erkannt werden
+var attachmentExtensions = []string{".pdf", ".doc", ".docx"}
+
+// extractAttachmentLinks sammelt die Links auf PDF- und Word-Dokumente der Detailseite.
+// Relative Links werden gegen pageURL aufgelöst, doppelte Ziele nur einmal übernommen.
+func extractAttachmentLinks(htmlContent, pageURL string) ([]Attachment, error) {
+	doc, err := htmlquery.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, fmt.Errorf("Fehler beim Parsen des HTML: %v", err)
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("Ungültige URL %s: %v", pageURL, err)
+	}
+
+	var attachments []Attachment
+	seen := make(map[string]bool)
+	for _, node := range htmlquery.Find(doc, "//a[@href]") {
+		ref, err := url.Parse(strings.TrimSpace(htmlquery.SelectAttr(node, "href")))
+		if err != nil {
+			continue
+		}
+		isAttachment := false
+		for _, ext := range attachmentExtensions {
+			if strings.HasSuffix(strings.ToLower(ref.Path), ext) {
+				isAttachment = true
+				break
+			}
+		}
+		if !isAttachment {
+			continue
+		}
+		target := base.ResolveReference(ref).String()
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+		title := strings.Join(strings.Fields(htmlquery.InnerText(node)), " ")
+		if title == "" {
+			title = filepath.Base(ref.Path)
+		}
+		attachments = append(attachments, Attachment{Title: title, URL: target})
+	}
+	return attachments, nil
+}
+
+// listingImage ist das erste Bild im Inhalt einer Detailseite
+type listingImage struct {
+	URL string
+	Alt string
+}
+
+// defaultGeocodeURL ist die Suche von Nominatim (OpenStreetMap), beschränkt auf Deutschland
+const defaultGeocodeURL = "https://nominatim.openstreetmap.org/search?format=jsonv2&limit=1&countrycodes=de&q={query}"
+
+// geocodeParcel ermittelt die ungefähre Lage eines Flurstücks über Gemarkung und Ort.
+// Die Flurstücksnummer kennt der Geocoder nicht, die Karte zeigt also die Gemarkung.
+func geocodeParcel(ctx context.Context, config Config, data PostData) (lat, lon float64, err error) {
+	var parts []string
+	for _, part := range []string{data.Gemarkung, data.City} {
+		if part != "" && !containsFold(parts, part) {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return 0, 0, fmt.Errorf("weder Gemarkung noch Ort bekannt")
+	}
+	query := strings.Join(parts, ", ") + ", Nordrhein-Westfalen"
+	body, err := fetchURL(ctx, strings.ReplaceAll(config.GeocodeURL, "{query}", url.QueryEscape(query)), 1<<20, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("Geocoding fehlgeschlagen: %v", err)
+	}
+	var results []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := json.Unmarshal([]byte(body), &results); err != nil {
+		return 0, 0, fmt.Errorf("Antwort des Geocoders nicht lesbar: %v", err)
+	}
+	if len(results) == 0 {
+		return 0, 0, fmt.Errorf("%q nicht gefunden", query)
+	}
+	if lat, err = strconv.ParseFloat(results[0].Lat, 64); err == nil {
+		lon, err = strconv.ParseFloat(results[0].Lon, 64)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("ungültige Koordinaten für %q: %v", query, err)
+	}
+	return lat, lon, nil
+}
+
+// staticMapImage gibt die Karte zur Lage des Flurstücks nach StaticMapTemplate zurück.
+// Ohne StaticMapTemplate wird ein leeres listingImage zurückgegeben.
+func staticMapImage(ctx context.Context, config Config, data PostData) (listingImage, error) {
+	if config.StaticMapTemplate == "" {
+		return listingImage{}, nil
+	}
+	lat, lon, err := geocodeParcel(ctx, config, data)
+	if err != nil {
+		return listingImage{}, err
+	}
+	mapURL := strings.NewReplacer(
+		"{lat}", strconv.FormatFloat(lat, 'f', 6, 64),
+		"{lon}", strconv.FormatFloat(lon, 'f', 6, 64),
+		"{zoom}", strconv.Itoa(config.StaticMapZoom),
+	).Replace(config.StaticMapTemplate)
+	place := data.City
+	if data.Gemarkung != "" && !strings.EqualFold(data.Gemarkung, data.City) {
+		place = strings.TrimSuffix("Gemarkung "+data.Gemarkung+", "+data.City, ", ")
+	}
+	return listingImage{URL: mapURL, Alt: "Karte: ungefähre Lage des Grundstücks (" + place + ")"}, nil
+}
+
+// extractListingImage sucht das erste <img> im Abschnitt zwischen den <hr>-Tags bzw. im
+// Inhalts-Container, wie ihn extractListingText verwendet. Relative src werden gegen pageURL
+// aufgelöst. Ohne Bild wird ein leeres listingImage zurückgegeben.
+func extractListingImage(htmlContent, pageURL string, config Config) (listingImage, error) {
+	doc, err := htmlquery.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return listingImage{}, fmt.Errorf("Fehler beim Parsen des HTML: %v", err)
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return listingImage{}, fmt.Errorf("Ungültige URL %s: %v", pageURL, err)
+	}
+
+	var img *html.Node
+	if len(htmlquery.Find(doc, "//hr")) >= config.HRSectionEnd {
+		hrCount := 0
+		var f func(*html.Node) bool
+		f = func(n *html.Node) bool {
+			if n.Type == html.ElementNode && n.Data == "hr" {
+				hrCount++
+				return hrCount >= config.HRSectionEnd
+			}
+			if hrCount >= config.HRSectionStart && n.Type == html.ElementNode && n.Data == "img" {
+				img = n
+				return true
+			}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if f(c) {
+					return true
+				}
+			}
+			return false
+		}
+		f(doc)
+	} else {
+		var container *html.Node
+		if config.ContentXPath != "" {
+			container, _ = htmlquery.Query(doc, config.ContentXPath)
+		} else {
+			container = densestTextBlock(doc)
+		}
+		if container != nil {
+			img = htmlquery.FindOne(container, ".//img[@src]")
+		}
+	}
+	if img == nil {
+		return listingImage{}, nil
+	}
+
+	src := strings.TrimSpace(htmlquery.SelectAttr(img, "src"))
+	ref, err := url.Parse(src)
+	if err != nil || src == "" || ref.Scheme == "data" {
+		return listingImage{}, nil
+	}
+	alt := strings.Join(strings.Fields(htmlquery.SelectAttr(img, "alt")), " ")
+	return listingImage{URL: base.ResolveReference(ref).String(), Alt: alt}, nil
+}
+
+// formatAttachments gibt die Anhänge als Liste aus; mit markdown als Markdown-Links für Lemmy
+func formatAttachments(attachments []Attachment, markdown bool) string {
+	if len(attachments) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Anhänge:")
+	for _, a := range attachments {
+		if markdown {
+			b.WriteString("\n- [" + a.Title + "](" + a.URL + ")")
+		} else {
+			b.WriteString("\n- " + a.Title + ": " + a.URL)
+		}
+	}
+	return b.String()
+}
+
+var (
+	emailRe = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	phoneRe = regexp.MustCompile(`(?:(?:\+|00)49[\s\-]?(?:\(0\)[\s\-]?)?|\(?0)[1-9]\d{1,4}\)?[\s/\-]?\d(?:[\s\-]?\d){3,}`)
+)
+
+// contactPlaceholder ersetzt entfernte Kontaktdaten
+const contactPlaceholder = "[Kontakt siehe Original]"
+
+// redactContacts ersetzt E-Mail-Adressen und deutsche Telefonnummern im Text
+func redactContacts(text string) string {
+	text = emailRe.ReplaceAllString(text, contactPlaceholder)
+	return phoneRe.ReplaceAllString(text, contactPlaceholder)
+}
+
+// contentHash gibt einen Hash des extrahierten Textes zurück. Leerzeichen werden
+// normalisiert, damit reine Formatierungsunterschiede keinen neuen Hash ergeben.
+func contentHash(text string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(text), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// findDuplicateContent gibt einen anderen bereits geposteten Link mit demselben Inhalt zurück
+func findDuplicateContent(data LinkData, link, hash string) (string, bool) {
+	for other, h := range data.ContentHashes {
+		if h == hash && other != link {
+			return other, true
+		}
+	}
+	return "", false
+}
+
+// linkHash gibt einen stabilen Kurz-Hash für einen Link zurück
+func linkHash(link string) string {
+	sum := sha256.Sum256([]byte(link))
+	return hex.EncodeToString(sum[:8])
+}
+
+// permalinkFor gibt den Permalink eines Links auf dem eigenen Feed zurück
+func permalinkFor(config Config, link string) string {
+	if config.StatusBaseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(config.StatusBaseURL, "/") + "/item/" + linkHash(link)
+}
+
+// newPostData baut die Vorlagen-Variablen für einen Link auf
+func newPostData(city, title, text, pageURL, link string) PostData {
+	if city == "" {
+		city = strings.Title(strings.Split(link, "/")[0])
+	}
+	details := parseListingDetails(text)
+	data := PostData{
+		City:       city,
+		Title:      title,
+		Text:       text,
+		URL:        pageURL,
+		Gemarkung:  details.Gemarkung,
+		Flurstueck: details.Flurstueck,
+		Category:   details.Category,
+	}
+	if !details.Deadline.IsZero() {
+		data.Deadline = details.Deadline.Format("02.01.2006")
+		data.deadline = details.Deadline
+	}
+	if details.Category != "" {
+		data.Hashtag = "#" + strings.NewReplacer(" ", "", "-", "").Replace(details.Category)
+	}
+	if details.AreaSquareMeters >= 10000 {
+		data.Area = formatGermanNumber(details.AreaSquareMeters/10000, 2) + " ha"
+	} else if details.AreaSquareMeters > 0 {
+		data.Area = formatGermanNumber(details.AreaSquareMeters, 0) + " m²"
+	}
+	if details.PriceEuro > 0 {
+		data.Price = formatGermanNumber(details.PriceEuro, 2) + " €"
+	}
+	return data
+}
+
+// renderPost erzeugt Titel und Text eines Posts aus den konfigurierten Vorlagen.
+// Ist keine Vorlage gesetzt, wird das Standardformat verwendet.
+func renderPost(config Config, data PostData) (string, string, error) {
+	title := data.City + ": Grundstücksverkauf an Nicht-LandwirtIn"
+	if data.Title != "" {
+		title += " " + data.Title
+	}
+	body := data.Text
+	if data.Permalink != "" {
+		body += "\n\nPermalink: " + data.Permalink
+	}
+
+	if config.TitleTemplate != "" {
+		rendered, err := executeTemplate("title", config.TitleTemplate, data)
+		if err != nil {
+			return "", "", err
+		}
+		title = rendered
+	}
+	if config.BodyTemplate != "" {
+		rendered, err := executeTemplate("body", config.BodyTemplate, data)
+		if err != nil {
+			return "", "", err
+		}
+		body = rendered
+	}
+	return title, body, nil
+}
+
+// templatePlatforms sind die Plattformen, für die PlatformTemplates gesetzt werden können
+var templatePlatforms = []string{"lemmy", "mastodon", "bluesky", "telegram", "discord"}
+
+// postTexts sind die gerenderten Bestandteile eines Posts für eine Plattform
+type postTexts struct {
+	title       string // Titel (Lemmy, Discord)
+	body        string // Markdown-Text inklusive Anhängen (Lemmy, Discord)
+	status      string // Titelzeile und Text für Plattformen ohne Titelfeld
+	attachments string // Anhänge als Klartext, leer bei eigener body-Vorlage
+	hashtag     string // leer bei eigener body-Vorlage
+}
+
+// withPlatformTemplates gibt die Konfiguration mit den Vorlagen aus PlatformTemplates zurück
+func withPlatformTemplates(config Config, platform string) Config {
+	if tmpl, ok := config.PlatformTemplates[platform]; ok {
+		if tmpl.Title != "" {
+			config.TitleTemplate = tmpl.Title
+		}
+		if tmpl.Body != "" {
+			config.BodyTemplate = tmpl.Body
+		}
+	}
+	return config
+}
+
+// renderPostTexts rendert einen Post mit den Vorlagen der Plattform. Ohne Titelvorlage
+// beginnt status mit "<Stadt>: Grundstücksverkauf an Nicht-LandwirtIn", sofern eine Stadt
+// auf der Detailseite gefunden wurde.
+func renderPostTexts(config Config, platform string, data PostData, cityName string) (postTexts, error) {
+	config = withPlatformTemplates(config, platform)
+	title, body, err := renderPost(config, data)
+	if err != nil {
+		return postTexts{}, err
+	}
+	texts := postTexts{title: title, body: body, status: body}
+	if config.TitleTemplate != "" {
+		texts.status = title + "\n" + body
+	} else if cityName != "" {
+		texts.status = cityName + ": Grundstücksverkauf an Nicht-LandwirtIn\n" + body
+	}
+	if config.BodyTemplate == "" && len(data.Attachments) > 0 {
+		texts.body += "\n\n" + formatAttachments(data.Attachments, true)
+		texts.attachments = "\n\n" + formatAttachments(data.Attachments, false)
+	}
+	if config.BodyTemplate == "" && data.Hashtag != "" {
+		texts.hashtag = "\n\n" + data.Hashtag
+	}
+	return texts, nil
+}
+
+// formatLemmyPost gibt Titel und Markdown-Text für Lemmy zurück. Die Quelle wird über das
+// url-Feld des Posts verlinkt und deshalb nicht in den Text übernommen.
+func formatLemmyPost(config Config, texts postTexts) (string, string) {
+	return texts.title, fitForPlatform(config, "lemmy", texts.body)
+}
+
+// formatMastodonPost gibt den Status für Mastodon zurück: Titelzeile, Text, Anhänge und
+// Hashtag als Klartext, gefolgt von der Quelle, für die immer Platz gelassen wird
+func formatMastodonPost(config Config, texts postTexts, pageURL string) string {
+	return mastodonPostText(config, texts.status+texts.attachments+texts.hashtag, pageURL)
+}
+
+// formatBlueskyPost gibt den Text für Bluesky zurück; Anhänge passen nicht in 300 Zeichen
+func formatBlueskyPost(config Config, texts postTexts, pageURL string) string {
+	return blueskyPostText(config, texts.status+texts.hashtag, pageURL)
+}
+
+// formatTelegramPost gibt die Nachricht für Telegram zurück
+func formatTelegramPost(config Config, texts postTexts, pageURL string) string {
+	return telegramPostText(config, texts.status+texts.attachments+texts.hashtag, pageURL)
+}
+
+// formatDiscordPost gibt Titel und Beschreibung des Discord-Embeds zurück, die Quelle steht im url-Feld
+func formatDiscordPost(config Config, texts postTexts) (string, string) {
+	return texts.title, fitForPlatform(config, "discord", texts.body+texts.hashtag)
+}
+
+// noticeTexts gibt die Bestandteile einer Mitteilung (z.B. Erinnerung) für die Formatierer zurück
+func noticeTexts(title, text string) postTexts {
+	return postTexts{title: title, body: text, status: title + "\n" + text}
+}
+
+// executeTemplate parst und rendert eine text/template-Vorlage
+func executeTemplate(name, tmpl string, data PostData) (string, error) {
+	t, err := template.New(name).Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("Fehler beim Parsen der Vorlage %s: %v", name, err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("Fehler beim Rendern der Vorlage %s: %v", name, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// titleCaseSmallWords bleiben bei TitleCase klein (außer am Anfang)
+var titleCaseSmallWords = map[string]bool{
+	"a": true, "am": true, "an": true, "auf": true, "aus": true, "bei": true, "das": true, "dem": true,
+	"den": true, "der": true, "des": true, "die": true, "ein": true, "eine": true, "einer": true, "für": true,
+	"im": true, "in": true, "mit": true, "nach": true, "oder": true, "und": true, "von": true, "vom": true,
+	"zu": true, "zum": true, "zur": true,
+}
+
+// normalizeTitle bereinigt eine extrahierte Überschrift. Die Regeln sind bewusst
+// zurückhaltend, damit Eigennamen nicht verändert werden.
+func normalizeTitle(title string, opts TitleNormalization) string {
+	title = strings.TrimSpace(title)
+	if opts.CollapseWhitespace {
+		title = strings.Join(strings.Fields(title), " ")
+	}
+	if opts.StripTrailingColons {
+		title = strings.TrimSpace(strings.TrimRight(title, ":"))
+	}
+	if opts.TitleCase {
+		words := strings.Split(title, " ")
+		for i, w := range words {
+			words[i] = titleCaseWord(w, i == 0)
+		}
+		title = strings.Join(words, " ")
+	}
+	return title
+}
+
+// titleCaseWord setzt den ersten Buchstaben eines Wortes groß. Gemischt geschriebene
+// Wörter und kurze Abkürzungen (z.B. "NRW") bleiben unverändert.
+func titleCaseWord(w string, first bool) string {
+	runes := []rune(w)
+	if len(runes) == 0 {
+		return w
+	}
+	lower, upper := strings.ToLower(w), strings.ToUpper(w)
+	if w == upper && len(runes) > 3 {
+		runes = []rune(lower)
+	} else if w != lower {
+		return w
+	}
+	if !first && titleCaseSmallWords[lower] {
+		return lower
+	}
+	runes[0] = []rune(strings.ToUpper(string(runes[0])))[0]
+	return string(runes)
+}
+
+// extractCityName extrahiert den Stadtnamen aus der Detailseite: aus der ersten <h1>,
+// sonst aus einer "Sie sind hier:"-Zeile mit ">" als Trenner, sonst aus dem letzten Eintrag
+// einer Brotkrumen-Navigation aus einzelnen Links. Angehängte Seitennamen wie
+// "Köln - Landwirtschaftskammer NRW" werden abgeschnitten.
+func extractCityName(htmlContent string) string {
+	doc, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return ""
+	}
+
+	// 1. Hauptüberschrift
+	if h1 := htmlquery.FindOne(doc, "//h1"); h1 != nil {
+		for c := h1.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode && strings.TrimSpace(c.Data) != "" {
+				return trimSiteSuffix(c.Data)
+			}
+		}
+	}
+
+	// 2. "Sie sind hier:"-Zeile als einzelner Text mit ">" als Trenner
+	var containers []*html.Node
+	for _, n := range htmlquery.Find(doc, "//text()[contains(., 'Sie sind hier')]") {
+		text := strings.TrimSpace(n.Data)
+		if parts := strings.Split(text, ">"); len(parts) > 1 {
+			if name := trimSiteSuffix(parts[len(parts)-1]); name != "" {
+				return name
+			}
+		}
+		// Die Einträge folgen als eigene Elemente, z.B. <p>Sie sind hier: <a>…</a> > <a>…</a></p>
+		if n.Parent != nil {
+			containers = append(containers, n.Parent)
+		}
+	}
+
+	// 3. Brotkrumen-Navigation aus einzelnen Einträgen
+	const upper, lower = "ABCDEFGHIJKLMNOPQRSTUVWXYZ", "abcdefghijklmnopqrstuvwxyz"
+	breadcrumbs := htmlquery.Find(doc, "//*[contains(translate(@class, '"+upper+"', '"+lower+"'), 'breadcrumb') or "+
+		"contains(translate(@id, '"+upper+"', '"+lower+"'), 'breadcrumb') or "+
+		"contains(translate(@aria-label, '"+upper+"', '"+lower+"'), 'breadcrumb')]")
+	for _, container := range append(containers, breadcrumbs...) {
+		if name := lastBreadcrumb(container); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// lastBreadcrumb gibt den letzten nicht leeren Eintrag einer Brotkrumen-Navigation zurück.
+// Einträge sind die <li>-Elemente, ohne Liste die Links des Containers.
+func lastBreadcrumb(container *html.Node) string {
+	items := htmlquery.Find(container, ".//li")
+	if len(items) == 0 {
+		items = htmlquery.Find(container, ".//a")
+	}
+	for i := len(items) - 1; i >= 0; i-- {
+		text := strings.Trim(strings.Join(strings.Fields(htmlquery.InnerText(items[i])), " "), "> ")
+		text = strings.TrimSpace(strings.TrimPrefix(text, "Sie sind hier:"))
+		if text != "" {
+			return trimSiteSuffix(text)
+		}
+	}
+	return ""
+}
+
+// siteSuffixSeparators trennen einen angehängten Seitennamen vom eigentlichen Titel
+var siteSuffixSeparators = []string{" | ", " - ", " – ", " — "}
+
+// trimSiteSuffix entfernt Leerraum und einen mit " - " oder " | " angehängten Seitennamen.
+// Bindestriche ohne Leerzeichen (z.B. "Castrop-Rauxel") bleiben erhalten.
+func trimSiteSuffix(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	for _, sep := range siteSuffixSeparators {
+		if i := strings.Index(name, sep); i > 0 {
+			name = name[:i]
+		}
+	}
+	return strings.TrimSpace(name)
+}
+
+// CheckResult fasst das Ergebnis eines Durchlaufs von checkWebsite zusammen
+type CheckResult struct {
+	Checked  int           `json:"checked"`  // Anzahl der auf der Website gefundenen Links
+	New      int           `json:"new"`      // Neue Links inklusive erneut versuchter fehlgeschlagener Links
+	Posted   int           `json:"posted"`   // Erfolgreich auf allen Plattformen gepostete Links
+	Failed   int           `json:"failed"`   // Links, die beim nächsten Durchlauf erneut versucht werden
+	Removed  int           `json:"removed"`  // Links, die nicht mehr auf der Website erscheinen
+	Updated  int           `json:"updated"`  // Bereits gepostete Links mit geändertem Text (PostUpdates)
+	Duration time.Duration `json:"duration"` // Dauer des Durchlaufs
+
+	// Posts fasst die Post-Ergebnisse je Link und je Plattform zusammen
+	Posts PostSummary `json:"posts"`
+
+	// PostResults enthält die Ergebnisse jeder Plattform für jeden geposteten Link
+	PostResults []LinkPostResult `json:"-"`
+
+	// WouldPost enthält im Testmodus die Posts, die erstellt worden wären
+	WouldPost []PlannedPost `json:"-"`
+}
+
+// PlatformResult ist das Ergebnis eines Posts auf einer Plattform
+type PlatformResult struct {
+	Name    string
+	Success bool
+	Err     error
+}
+
+// LinkPostResult enthält die Ergebnisse aller Plattformen für einen Link
+type LinkPostResult struct {
+	Link      string
+	Platforms []PlatformResult
+}
+
+// PostSummary zählt, wie viele Links vollständig, teilweise oder gar nicht gepostet wurden
+type PostSummary struct {
+	Full      int             `json:"full"`
+	Partial   int             `json:"partial"`
+	Failed    int             `json:"failed"`
+	Platforms []PlatformStats `json:"platforms,omitempty"`
+}
+
+// PlatformStats zählt die Post-Ergebnisse einer Plattform
+type PlatformStats struct {
+	Name      string `json:"name"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Deferred  int    `json:"deferred"` // wegen Retry-After zurückgestellt
+}
+
+// summarizePostResults fasst die Ergebnisse je Link und je Plattform zusammen. Die
+// Plattformen erscheinen in der Reihenfolge ihres ersten Auftretens.
+func summarizePostResults(results []LinkPostResult) PostSummary {
+	var summary PostSummary
+	index := make(map[string]int)
+	for _, r := range results {
+		succeeded := 0
+		for _, pr := range r.Platforms {
+			i, ok := index[pr.Name]
+			if !ok {
+				i = len(summary.Platforms)
+				index[pr.Name] = i
+				summary.Platforms = append(summary.Platforms, PlatformStats{Name: pr.Name})
+			}
+			switch {
+			case pr.Success:
+				succeeded++
+				summary.Platforms[i].Succeeded++
+			case errors.Is(pr.Err, errPlatformDeferred):
+				summary.Platforms[i].Deferred++
+			default:
+				summary.Platforms[i].Failed++
+			}
+		}
+		switch succeeded {
+		case len(r.Platforms):
+			summary.Full++
+		case 0:
+			summary.Failed++
+		default:
+			summary.Partial++
+		}
+	}
+	return summary
+}
+
+// logPostSummary protokolliert die Post-Ergebnisse eines Durchlaufs als Tabelle, damit
+// der Ausfall einer einzelnen Plattform sofort auffällt
+func logPostSummary(summary PostSummary) {
+	if len(summary.Platforms) == 0 {
+		return
+	}
+	level := slog.LevelInfo
+	if summary.Partial > 0 || summary.Failed > 0 {
+		level = slog.LevelWarn
+	}
+	slog.Log(context.Background(), level, "Post-Ergebnisse", "full", summary.Full, "partial", summary.Partial, "failed", summary.Failed)
+	for _, p := range summary.Platforms {
+		level := slog.LevelInfo
+		if p.Failed > 0 {
+			level = slog.LevelError
+		}
+		slog.Log(context.Background(), level, "  Plattform", "platform", p.Name, "succeeded", p.Succeeded, "failed", p.Failed, "deferred", p.Deferred)
+	}
+}
+
+// PlannedPost ist ein Post, den ein Testlauf erstellt hätte
+type PlannedPost struct {
+	Link  string `json:"link"`
+	Title string `json:"title"`
+}
+
+// saveSnapshot speichert die geplanten Posts eines Testlaufs
+func saveSnapshot(posts []PlannedPost, filename string) error {
+	if posts == nil {
+		posts = []PlannedPost{}
+	}
+	data, err := json.MarshalIndent(posts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Fehler beim Marshalling des Snapshots: %v", err)
+	}
+	return writeFileAtomic(filename, data, 0644)
+}
+
+// loadSnapshot lädt die geplanten Posts eines früheren Testlaufs
+func loadSnapshot(filename string) ([]PlannedPost, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Fehler beim Lesen des Snapshots: %v", err)
+	}
+	var posts []PlannedPost
+	if err := json.Unmarshal(data, &posts); err != nil {
+		return nil, fmt.Errorf("Fehler beim Parsen des Snapshots: %v", err)
+	}
+	return posts, nil
+}
+
+// compareSnapshots gibt die Unterschiede zwischen zwei Testläufen als lesbare Zeilen zurück
+func compareSnapshots(old, current []PlannedPost) []string {
+	oldMap := make(map[string]string)
+	for _, p := range old {
+		oldMap[p.Link] = p.Title
+	}
+	currentMap := make(map[string]string)
+	for _, p := range current {
+		currentMap[p.Link] = p.Title
+	}
+
+	var diffs []string
+	for _, p := range current {
+		oldTitle, ok := oldMap[p.Link]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("+ %s: %s", p.Link, p.Title))
+		} else if oldTitle != p.Title {
+			diffs = append(diffs, fmt.Sprintf("~ %s: %q -> %q", p.Link, oldTitle, p.Title))
+		}
+	}
+	for _, p := range old {
+		if _, ok := currentMap[p.Link]; !ok {
+			diffs = append(diffs, fmt.Sprintf("- %s: %s", p.Link, p.Title))
+		}
+	}
+	return diffs
+}
+
+// isolateDataFile kopiert die Datendateien bzw. die Datenbank in ein temporäres Verzeichnis,
+// damit ein Testlauf den gespeicherten Zustand nicht verändert
+func isolateDataFile(config Config) (Config, func(), error) {
+	dir, err := os.MkdirTemp("", "gvg-dry-run-")
+	if err != nil {
+		return config, nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	if config.Database != "" {
+		tmpDB := filepath.Join(dir, filepath.Base(config.Database))
+		if data, err := os.ReadFile(config.Database); err == nil {
+			if err := os.WriteFile(tmpDB, data, 0644); err != nil {
+				cleanup()
+				return config, nil, err
+			}
+		}
+		// Die Quellen werden in der Datenbank über data_file unterschieden, die JSON-Dateien
+		// werden nur beim ersten Öffnen gelesen
+		config.Database = tmpDB
+		return config, cleanup, nil
+	}
+	tmpFile := filepath.Join(dir, filepath.Base(config.DataFile))
+	if data, err := os.ReadFile(config.DataFile); err == nil {
+		if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+			cleanup()
+			return config, nil, err
+		}
+	}
+	config.DataFile = tmpFile
+
+	sources := make([]SourceConfig, len(config.Sources))
+	copy(sources, config.Sources)
+	for i := range sources {
+		tmpFile := filepath.Join(dir, fmt.Sprintf("source-%d-%s", i+1, filepath.Base(sources[i].DataFile)))
+		if data, err := os.ReadFile(sources[i].DataFile); err == nil {
+			if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+				cleanup()
+				return config, nil, err
+			}
+		}
+		sources[i].DataFile = tmpFile
+	}
+	config.Sources = sources
+	return config, cleanup, nil
+}
+
+// Summary gibt eine einzeilige, maschinenlesbare Zusammenfassung des Durchlaufs zurück
+func (r CheckResult) Summary() string {
+	return fmt.Sprintf("run complete: checked=%d new=%d posted=%d failed=%d removed=%d duration=%.1fs",
+		r.Checked, r.New, r.Posted, r.Failed, r.Removed, r.Duration.Seconds())
+}
+
+// checkWebsite überprüft alle Quellen auf neue Links. Zusätzliche Quellen aus Sources
+// werden mit höchstens SourceConcurrency gleichzeitigen Überprüfungen bearbeitet.
+func checkWebsite(ctx context.Context, config Config, testMode bool) (CheckResult, error) {
+	start := time.Now()
+	// robots.txt wird einmal pro Durchlauf abgerufen
+	robotsCache.Reset()
+	sources := sourceConfigs(config)
+	results := make([]CheckResult, len(sources))
+	datas := make([]LinkData, len(sources))
+	errs := make([]error, len(sources))
+
+	// Jede Quelle wird mit ihrem eigenen LinkStore überprüft
+	check := func(i int) {
+		store, err := openLinkStore(sources[i])
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		defer store.Close()
+		results[i], datas[i], errs[i] = checkSource(ctx, &sources[i], store, testMode)
+	}
+
+	if len(sources) == 1 {
+		check(0)
+	} else {
+		concurrency := config.SourceConcurrency
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for i := range sources {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				check(i)
+				if errs[i] == nil {
+					log.Printf("%s: %s", sources[i].URL, results[i].Summary())
+				}
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	// Konfiguration mit den neuesten Tokens aller Quellen speichern
+	for _, source := range sources {
+		mergeTokens(&config, source)
+	}
+	if err := saveConfig(config, config.configFile); err != nil {
+		slog.Warn("Konfiguration konnte nicht gespeichert werden", "file", config.configFile, "error", err)
+	}
+
+	// Ergebnisse aller Quellen zusammenfassen
+	var result CheckResult
+	var combined LinkData
+	var failedSources []string
+	for i, source := range sources {
+		if errs[i] != nil {
+			failedSources = append(failedSources, fmt.Sprintf("%s: %v", source.URL, errs[i]))
+			continue
+		}
+		result.Checked += results[i].Checked
+		result.New += results[i].New
+		result.Posted += results[i].Posted
+		result.Failed += results[i].Failed
+		result.Removed += results[i].Removed
+		result.Updated += results[i].Updated
+		result.WouldPost = append(result.WouldPost, results[i].WouldPost...)
+		result.PostResults = append(result.PostResults, results[i].PostResults...)
+		combined.Links = append(combined.Links, datas[i].Links...)
+		combined.FailedLinks = append(combined.FailedLinks, datas[i].FailedLinks...)
+		mergePostCounts(&combined, datas[i])
+	}
+	metricChecks.Inc()
+	if len(failedSources) == len(sources) {
+		return result, errs[0]
+	}
+	for _, msg := range failedSources {
+		slog.Error("Fehler bei der Überprüfung einer Quelle", "error", msg)
+	}
+
+	result.Duration = time.Since(start)
+	result.Posts = summarizePostResults(result.PostResults)
+	logSummary(result.Summary())
+	logPostSummary(result.Posts)
+	statusCache.Update(combined, result, time.Now())
+	statusCache.UpdateFeed(config, time.Now())
+	if config.StatusBaseURL != "" {
+		statusCache.UpdateItems(config)
+	}
+	recordCheckMetrics(result, len(failedSources) == 0, time.Now())
+	if config.FeedFile != "" && !testMode {
+		if err := writeFeed(config, config.FeedFile); err != nil {
+			slog.Warn("Feed konnte nicht geschrieben werden", "file", config.FeedFile, "error", err)
+		}
+	}
+	if config.StatsdAddr != "" {
+		if err := sendStatsd(config.StatsdAddr, result, len(combined.Links), combined.PostCounts); err != nil {
+			slog.Warn("StatsD-Metriken konnten nicht gesendet werden", "addr", config.StatsdAddr, "error", err)
+		}
+	}
+
+	if len(failedSources) > 0 {
+		return result, fmt.Errorf("%d von %d Quellen fehlgeschlagen", len(failedSources), len(sources))
+	}
+	return result, nil
+}
+
+// mergePostCounts addiert die Post-Zähler von src zu dst
+func mergePostCounts(dst *LinkData, src LinkData) {
+	if dst.PostCounts == nil {
+		dst.PostCounts = map[string]int{}
+	}
+	for platform, n := range src.PostCounts {
+		dst.PostCounts[platform] += n
+	}
+	if !src.CountingSince.IsZero() && (dst.CountingSince.IsZero() || src.CountingSince.Before(dst.CountingSince)) {
+		dst.CountingSince = src.CountingSince
+	}
+}
+
+// mergeTokens übernimmt die Plattform-Tokens aus src, wenn sie länger gültig sind als die in dst,
+// und meldet, ob sich dabei etwas geändert hat
+func mergeTokens(dst *Config, src Config) bool {
+	changed := false
+	if src.LemmyTokenExp.After(dst.LemmyTokenExp) {
+		dst.LemmyToken = src.LemmyToken
+		dst.LemmyTokenExp = src.LemmyTokenExp
+		dst.LemmyLanguageID = src.LemmyLanguageID
+		changed = true
+	}
+	for i := range dst.LemmyTargets {
+		if i < len(src.LemmyTargets) && src.LemmyTargets[i].TokenExp.After(dst.LemmyTargets[i].TokenExp) {
+			dst.LemmyTargets[i].Token = src.LemmyTargets[i].Token
+			dst.LemmyTargets[i].TokenExp = src.LemmyTargets[i].TokenExp
+			dst.LemmyTargets[i].LanguageID = src.LemmyTargets[i].LanguageID
+			changed = true
+		}
+	}
+	if src.MastodonAccessToken != dst.MastodonAccessToken && (src.MastodonTokenExp.IsZero() || src.MastodonTokenExp.After(dst.MastodonTokenExp)) {
+		dst.MastodonAccessToken = src.MastodonAccessToken
+		dst.MastodonTokenExp = src.MastodonTokenExp
+		changed = true
+	}
+	return changed
+}
+
+// sourceConfigs gibt pro Quelle eine Konfiguration zurück: zuerst die Hauptquelle aus
+// URL und DataFile, danach die zusätzlichen Quellen aus Sources
+func sourceConfigs(config Config) []Config {
+	// Jede Quelle erhält eigene Lemmy-Ziele, damit parallel erneuerte Tokens sich nicht überschreiben
+	main := config
+	main.LemmyTargets = append([]LemmyTarget(nil), config.LemmyTargets...)
+	configs := []Config{main}
+	for _, source := range config.Sources {
+		c := config
+		c.LemmyTargets = append([]LemmyTarget(nil), config.LemmyTargets...)
+		c.Sources = nil
+		c.URL = source.URL
+		c.DataFile = source.DataFile
+		if source.IgnoreDirs != nil {
+			c.IgnoreDirs = source.IgnoreDirs
+		}
+		if source.WatchDirs != nil {
+			c.WatchDirs = source.WatchDirs
+		}
+		configs = append(configs, c)
+	}
+	return configs
+}
+
+// checkSource überprüft eine einzelne Quelle auf neue Links und gibt die gespeicherten
+// Link-Daten nach dem Durchlauf zurück
+func checkSource(ctx context.Context, config *Config, store LinkStore, testMode bool) (CheckResult, LinkData, error) {
+	log.Printf("Überprüfe Website: %s", config.URL)
+	start := time.Now()
+	var result CheckResult
+
+	// Gespeicherte Links laden
+	savedData, err := store.Load()
+	if err != nil {
+		return result, LinkData{}, err
+	}
+
+	if !robotsAllowedURL(ctx, config.URL) {
+		return result, LinkData{}, fmt.Errorf("robots.txt verbietet den Abruf von %s", config.URL)
+	}
+
+	// HTML-Inhalt bedingt abrufen und Links extrahieren
+	var currentLinks []string
+	// Bei Paginierung sagt eine unveränderte erste Seite nichts über die Folgeseiten aus
+	cached := savedData.Validators[config.URL]
+	if config.NextPageXPath != "" {
+		cached = CacheValidators{}
+	}
+	htmlContent, validators, err := fetchSourceURLConditional(ctx, *config, config.URL, cached)
+	if errors.Is(err, ErrNotModified) {
+		log.Printf("Übersichtsseite unverändert (HTTP 304), keine Änderungen")
+		queued, err := queuedLinks(*config)
+		if err != nil {
+			return result, LinkData{}, err
+		}
+		currentLinks = unchangedIndexLinks(savedData, queued)
+	} else if err != nil {
+		return result, LinkData{}, err
+	} else {
+		currentLinks, err = extractAllLinks(ctx, *config, htmlContent)
+		if err != nil {
+			return result, LinkData{}, err
+		}
+		if validators.ETag != "" || validators.LastModified != "" {
+			savedData.Validators[config.URL] = validators
+		} else {
+			delete(savedData.Validators, config.URL)
+		}
+		log.Printf("Gefundene Links: %d", len(currentLinks))
+	}
+	result.Checked = len(currentLinks)
+
+	// Neue Links finden (inklusive fehlgeschlagene Links)
+	newLinks := findNewLinks(currentLinks, savedData.Links, append(savedData.FailedLinks, savedData.PendingLinks...))
+	if config.DebounceDuration > 0 {
+		newLinks = debounceLinks(&savedData, newLinks, currentLinks, config.DebounceDuration, time.Now())
+	}
+	result.New = len(newLinks)
+	
+	// Logge fehlgeschlagene Links, die erneut versucht werden
+	if len(savedData.FailedLinks) > 0 {
+		log.Printf("🔄 Fehlgeschlagene Links werden erneut versucht (%d):", len(savedData.FailedLinks))
+		for i, link := range savedData.FailedLinks {
+			log.Printf("  %d. %s", i+1, link)
+		}
+	}
+	// Entfernte Links finden
+	removedLinks := findRemovedLinks(currentLinks, savedData.Links)
+	result.Removed = len(removedLinks)
+
+	quietUntil, quiet := quietHoursUntil(*config, time.Now())
+	if len(newLinks) > 0 && quiet {
+		slog.Info("🌙 Ruhezeit, neue Links werden danach gepostet", "until", quietUntil.Format("15:04"), "links", len(newLinks))
+		deferLinks(&savedData, newLinks)
+	} else if len(newLinks) > 0 {
+		log.Printf("🚨 NEUE LINKS GEFUNDEN (%d):", len(newLinks))
+		
+		// Fehlgeschlagene und zurückgestellte Links für diesen Durchgang zurücksetzen
+		savedData.FailedLinks = []string{}
+		savedData.PendingLinks = []string{}
+		if err := postNewLinks(ctx, config, store, &savedData, newLinks, testMode, &result); err != nil {
+			return result, LinkData{}, err
+		}
+		logAbandonedLinks(*config, &savedData)
+	}
+
+	if len(removedLinks) > 0 {
+		log.Printf("🗑️  ENTFERNTE LINKS (%d):", len(removedLinks))
+		for i, link := range removedLinks {
+			log.Printf("  %d. %s", i+1, link)
+		}
+		
+		// Entferne gelöschte Links aus dem Speicher; savedData wird angeglichen, damit Save
+		// sie am Ende nicht wieder anlegt
+		removed, err := store.RemoveMissing(currentLinks)
+		if err != nil {
+			return result, LinkData{}, fmt.Errorf("Fehler beim Entfernen der Links: %v", err)
+		}
+		savedData.removeMissing(currentLinks, time.Now())
+		if config.PostRemovals {
+			savedData.PendingRemovalNotices = append(savedData.PendingRemovalNotices, removed...)
+		}
+	}
+	if len(savedData.PendingRemovalNotices) > 0 && !quiet {
+		sendRemovalNotices(ctx, config, &savedData, testMode)
+	}
+	if config.PostUpdates && !quiet {
+		result.Updated = postUpdatedLinks(ctx, config, &savedData, currentLinks, newLinks, testMode)
+	}
+
+	if len(newLinks) == 0 && len(removedLinks) == 0 {
+		log.Printf("Keine Änderungen gefunden")
+	}
+
+	// Aktuelle Links speichern (nur erfolgreich gepostete Links bleiben in der Liste)
+	// Entfernte Links werden automatisch entfernt, da sie nicht mehr in currentLinks sind
+	savedData.LastSeen = time.Now()
+
+	err = store.Save(savedData)
+	if err != nil {
+		return result, savedData, fmt.Errorf("Fehler beim Speichern der Link-Daten: %v", err)
+	}
+
+	result.Duration = time.Since(start)
+	return result, savedData, nil
+}
+
+// parseClock liest eine Uhrzeit "HH:MM" und gibt die Minuten seit Mitternacht zurück
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("Ungültige Uhrzeit %q (erwartet HH:MM)", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// quietHoursUntil meldet, ob now in der Ruhezeit liegt, und gibt ihr Ende zurück.
+// Gleiche Start- und Endzeit ergeben keine Ruhezeit.
+func quietHoursUntil(config Config, now time.Time) (time.Time, bool) {
+	if config.QuietHoursStart == "" || config.QuietHoursEnd == "" {
+		return time.Time{}, false
+	}
+	start, err := parseClock(config.QuietHoursStart)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := parseClock(config.QuietHoursEnd)
+	if err != nil {
+		return time.Time{}, false
+	}
+	loc := config.location
+	if loc == nil {
+		loc = time.Local
+	}
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	if start <= end && (minute < start || minute >= end) || start > end && minute < start && minute >= end {
+		return time.Time{}, false
+	}
+	until := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, loc)
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until, true
+}
+
+// deferLinks stellt neue Links zurück, ohne sie abzurufen. Fehlgeschlagene Links bleiben
+// fehlgeschlagen, damit ihre Versuche weiter gezählt werden.
+func deferLinks(data *LinkData, links []string) {
+	for _, link := range links {
+		if !containsString(data.FailedLinks, link) && !containsString(data.PendingLinks, link) {
+			data.PendingLinks = append(data.PendingLinks, link)
+		}
+	}
+}
+
+// unchangedIndexLinks gibt die Links zurück, die beim letzten Abruf auf der unveränderten
+// Übersichtsseite standen: gespeicherte, fehlgeschlagene, zurückgestellte, per
+// DebounceDuration vorgemerkte und in der Freigabe-Warteschlange wartende Links (queued).
+// So werden auch bei HTTP 304 fehlgeschlagene und freigegebene Links bearbeitet, ohne dass
+// Links als entfernt gelten.
+func unchangedIndexLinks(data LinkData, queued []string) []string {
+	observed := make([]string, 0, len(data.FirstObserved))
+	for link := range data.FirstObserved {
+		observed = append(observed, link)
+	}
+	sort.Strings(observed)
+
+	seen := make(map[string]bool)
+	var links []string
+	for _, list := range [][]string{data.hrefs(), data.FailedLinks, data.PendingLinks, observed, queued} {
+		for _, link := range list {
+			if !seen[link] {
+				seen[link] = true
+				links = append(links, link)
+			}
+		}
+	}
+	return links
+}
+
+// replayFailed versucht alle fehlgeschlagenen Links sofort erneut zu posten, ohne die
+// Übersichtsseite abzurufen. Plattformen mit vorhandenem Post-Nachweis werden übersprungen.
+func replayFailed(ctx context.Context, config Config, testMode bool) (CheckResult, error) {
+	start := time.Now()
+	var result CheckResult
+
+	savedData, err := loadSourceData(config)
+	if err != nil {
+		return result, err
+	}
+
+	failedLinks := savedData.FailedLinks
+	result.Checked = len(failedLinks)
+	if len(failedLinks) == 0 {
+		log.Printf("Keine fehlgeschlagenen Links vorhanden")
+		return result, nil
+	}
+
+	log.Printf("🔄 Fehlgeschlagene Links werden erneut versucht (%d):", len(failedLinks))
+	savedData.FailedLinks = []string{}
+	if err := postNewLinks(ctx, &config, nil, &savedData, failedLinks, testMode, &result); err != nil {
+		return result, err
+	}
+	for _, link := range failedLinks {
+		switch {
+		case containsString(savedData.FailedLinks, link):
+			slog.Warn("Link konnte erneut nicht gepostet werden", "link", link)
+		case savedData.record(link) != nil:
+			log.Printf("    ✅ %s gepostet", link)
+		case containsString(savedData.PendingLinks, link):
+		default:
+			// Weder gepostet noch erneut fehlgeschlagen (z.B. robots.txt oder Freigabe
+			// ausstehend): der Link bleibt für den nächsten Versuch fehlgeschlagen
+			savedData.FailedLinks = append(savedData.FailedLinks, link)
+		}
+	}
+	logAbandonedLinks(config, &savedData)
+
+	err = saveSourceData(config, savedData)
+	if err != nil {
+		return result, fmt.Errorf("Fehler beim Speichern der Link-Daten: %v", err)
+	}
+
+	// Konfiguration mit Token speichern
+	err = saveConfig(config, config.configFile)
+	if err != nil {
+		slog.Warn("Konfiguration konnte nicht gespeichert werden", "file", config.configFile, "error", err)
+	}
+
+	result.Duration = time.Since(start)
+	result.Posts = summarizePostResults(result.PostResults)
+	logSummary(result.Summary())
+	logPostSummary(result.Posts)
+	return result, nil
+}
+
+// detailPageURL gibt die URL der Detailseite zu einem gespeicherten Link zurück.
+// Absolute Links (z.B. von postSingleURL) werden unverändert übernommen.
+func detailPageURL(config Config, link string) string {
+	return resolveLinkURL(config.URL, link)
+}
+
+// linkBase gibt die Basis-URL zurück, gegen die Links der Übersichtsseite aufgelöst werden.
+// Die URL der Übersichtsseite gilt dabei immer als Verzeichnis.
+func linkBase(indexURL string) (*url.URL, error) {
+	if !strings.HasSuffix(indexURL, "/") {
+		indexURL += "/"
+	}
+	return url.Parse(indexURL)
+}
+
+// resolveLinkURL löst einen gespeicherten Link oder ein href gegen die Übersichtsseite zu einer
+// absoluten URL auf. Absolute, wurzelrelative und ../-Links werden korrekt behandelt.
+func resolveLinkURL(indexURL, link string) string {
+	base, err := linkBase(indexURL)
+	if err != nil {
+		return strings.TrimSuffix(indexURL, "/") + "/" + link
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return base.String() + link
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// canonicalLink normalisiert ein href der Übersichtsseite: Links unterhalb der Basis-URL werden
+// relativ zu ihr gespeichert (z.B. "koeln/index.htm"), alle anderen als absolute URL. So werden
+// "./koeln/index.htm", "/koeln/index.htm" und die absolute Schreibweise als derselbe Link erkannt.
+func canonicalLink(base *url.URL, href string) (string, error) {
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", err
+	}
+	resolved := base.ResolveReference(ref)
+	resolved.Fragment = ""
+	abs := resolved.String()
+	if rel, ok := strings.CutPrefix(abs, base.String()); ok && rel != "" {
+		return rel, nil
+	}
+	return abs, nil
+}
+
+// postSingleURL ruft genau eine Detailseite ab und postet sie auf allen konfigurierten
+// Plattformen, ohne die Übersichtsseite abzurufen. Die Liste der gesehenen Links, die
+// Freigabe-Warteschlange und die Inhalts-Deduplizierung werden dabei nicht beachtet.
+// Ein erfolgreich geposteter Link wird als gesehen gespeichert.
+func postSingleURL(ctx context.Context, config Config, pageURL string, testMode bool) (CheckResult, error) {
+	start := time.Now()
+	result := CheckResult{Checked: 1, New: 1}
+
+	savedData, err := loadSourceData(config)
+	if err != nil {
+		return result, err
+	}
+
+	single := config
+	single.RequireApproval = false
+	single.DedupeByContentAcrossURLs = false
+	single.RepostReturning = true
+	single.MaxDetailFetchesPerRun = 0
+
+	link := approvalHref(config, pageURL)
+	log.Printf("📌 Poste einzelne Detailseite:")
+	var posted LinkData
+	initLinkData(&posted)
+	posted.PostCounts = savedData.PostCounts
+	posted.CountingSince = savedData.CountingSince
+	posted.PostNotBefore = savedData.PostNotBefore
+	if err := postNewLinks(ctx, &single, nil, &posted, []string{link}, testMode, &result); err != nil {
+		return result, err
+	}
+	mergeTokens(&config, single)
+	if err := saveConfig(config, config.configFile); err != nil {
+		slog.Warn("Konfiguration konnte nicht gespeichert werden", "file", config.configFile, "error", err)
+	}
+
+	if result.Posted == 0 {
+		result.Duration = time.Since(start)
+		return result, fmt.Errorf("%s konnte nicht gepostet werden", pageURL)
+	}
+	if !testMode {
+		savedData.PostCounts = posted.PostCounts
+		savedData.CountingSince = posted.CountingSince
+		savedData.PostNotBefore = posted.PostNotBefore
+		if record := posted.record(link); record != nil {
+			savedData.markSeen(*record)
+		}
+		if deadline, ok := posted.Deadlines[link]; ok {
+			savedData.Deadlines[link] = deadline
+		}
+		savedData.ContentHashes[link] = posted.ContentHashes[link]
+		if err := saveSourceData(config, savedData); err != nil {
+			return result, fmt.Errorf("Fehler beim Speichern der Link-Daten: %v", err)
+		}
+	}
+
+	result.Duration = time.Since(start)
+	result.Posts = summarizePostResults(result.PostResults)
+	logSummary(result.Summary())
+	logPostSummary(result.Posts)
+	return result, nil
+}
+
+// backfillPlatform postet gespeicherte Links, deren PostedTo die Plattform noch nicht
+// enthält, nachträglich nur auf dieser Plattform, z.B. nachdem sie neu eingerichtet wurde.
+// Links, die ohne Post als gesehen gespeichert wurden (Skipped), werden nicht nachgeholt.
+// Die Detailseiten werden erneut abgerufen; zwischen zwei Posts wird delay gewartet.
+// Mit limit > 0 werden höchstens so viele Links bearbeitet, die ältesten zuerst.
+func backfillPlatform(ctx context.Context, config Config, platform string, limit int, delay time.Duration, testMode bool) (CheckResult, error) {
+	start := time.Now()
+	var result CheckResult
+	if !containsString(platformKeys(config), platform) {
+		return result, fmt.Errorf("Plattform %s ist nicht konfiguriert (konfiguriert: %s)", platform, strings.Join(platformKeys(config), ", "))
+	}
+
+	savedData, err := loadSourceData(config)
+	if err != nil {
+		return result, err
+	}
+	var links []string
+	skipped := 0
+	for _, record := range savedData.Links {
+		switch {
+		case containsString(record.PostedTo, platform):
+		case record.Skipped != "":
+			skipped++
+		default:
+			links = append(links, record.Href)
+		}
+	}
+	if skipped > 0 {
+		slog.Info("Nie gepostete Links (verworfen, Duplikat, aufgegeben o.ä.) werden nicht nachgeholt", "platform", platform, "links", skipped)
+	}
+	result.Checked = len(links)
+	if len(links) == 0 {
+		slog.Info("Alle gespeicherten Links sind bereits gepostet", "platform", platform)
+		return result, nil
+	}
+	if limit > 0 && len(links) > limit {
+		links = links[:limit]
+	}
+	result.New = len(links)
+	slog.Info("📥 Links werden nachgeholt", "platform", platform, "links", len(links), "missing", result.Checked)
+
+	single := config
+	single.onlyPlatform = platform
+	single.RequireApproval = false
+	single.DedupeByContentAcrossURLs = false
+	single.RepostReturning = true
+	single.MaxDetailFetchesPerRun = 0
+	single.PlatformPostDelays = maps.Clone(config.PlatformPostDelays)
+	if single.PlatformPostDelays == nil {
+		single.PlatformPostDelays = make(map[string]int)
+	}
+	kind, _, _ := strings.Cut(platform, "@")
+	single.PlatformPostDelays[kind] = int(delay.Round(time.Second) / time.Second)
+
+	var posted LinkData
+	initLinkData(&posted)
+	posted.PostCounts = savedData.PostCounts
+	posted.CountingSince = savedData.CountingSince
+	posted.PostNotBefore = savedData.PostNotBefore
+	if err := postNewLinks(ctx, &single, nil, &posted, links, testMode, &result); err != nil {
+		return result, err
+	}
+	mergeTokens(&config, single)
+	if err := saveConfig(config, config.configFile); err != nil {
+		slog.Warn("Konfiguration konnte nicht gespeichert werden", "file", config.configFile, "error", err)
+	}
+
+	// Fehlgeschlagene Links bleiben unverändert und werden beim nächsten Nachholen erneut versucht
+	for _, link := range posted.FailedLinks {
+		slog.Error("Link konnte nicht nachgeholt werden", "link", link, "platform", platform)
+	}
+	if !testMode {
+		savedData.PostCounts = posted.PostCounts
+		savedData.CountingSince = posted.CountingSince
+		savedData.PostNotBefore = posted.PostNotBefore
+		for _, record := range posted.Links {
+			if existing := savedData.record(record.Href); existing != nil && !containsString(existing.PostedTo, platform) {
+				existing.PostedTo = append(existing.PostedTo, platform)
+			}
+		}
+		if err := saveSourceData(config, savedData); err != nil {
+			return result, fmt.Errorf("Fehler beim Speichern der Link-Daten: %v", err)
+		}
+	}
+
+	result.Duration = time.Since(start)
+	result.Posts = summarizePostResults(result.PostResults)
+	logSummary(result.Summary())
+	logPostSummary(result.Posts)
+	return result, nil
+}
+
+// containsString prüft, ob list s enthält
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// removeString gibt list ohne alle Vorkommen von s zurück
+func removeString(list []string, s string) []string {
+	var result []string
+	for _, v := range list {
+		if v != s {
+			result = append(result, v)
+		}
+	}
+	if result == nil {
+		result = []string{}
+	}
+	return result
+}
+
+// detailFetchList gibt die Links zurück, deren Detailseiten postNewLinks abrufen wird:
+// ohne zurückgekehrte Links, die nicht erneut gepostet werden, ohne durch robots.txt
+// verbotene und höchstens MaxDetailFetchesPerRun viele
+func detailFetchList(ctx context.Context, config Config, savedData *LinkData, newLinks []string) []string {
+	var links []string
+	for _, link := range newLinks {
+		if _, returning := savedData.RemovedLinks[link]; returning && !config.RepostReturning {
+			continue
+		}
+		if config.MaxDetailFetchesPerRun > 0 && len(links) >= config.MaxDetailFetchesPerRun {
+			break
+		}
+		if !robotsAllowedURL(ctx, detailPageURL(config, link)) {
+			continue
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
+// detailPage ist der Inhalt einer abgerufenen Detailseite oder der Fehler beim Abruf
+type detailPage struct {
+	content string
+	err     error
+}
+
+// fetchDetailPages ruft die Detailseiten der Links mit höchstens FetchConcurrency
+// gleichzeitigen Abrufen ab. Wird ctx abgebrochen, enden laufende Abrufe mit ctx.Err()
+// und noch nicht begonnene werden nicht mehr gestartet.
+func fetchDetailPages(ctx context.Context, config Config, links []string) map[string]detailPage {
+	concurrency := config.FetchConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	pages := make([]detailPage, len(links))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, link := range links {
+		wg.Add(1)
+		go func(i int, link string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				pages[i].err = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				pages[i].err = ctx.Err()
+				return
+			}
+			pages[i].content, pages[i].err = fetchSourceURL(ctx, config, detailPageURL(config, link))
+		}(i, link)
+	}
+	wg.Wait()
+
+	result := make(map[string]detailPage, len(links))
+	for i, link := range links {
+		result[link] = pages[i]
+	}
+	return result
+}
+
+// postNewLinks ruft die Detailseiten der übergebenen Links ab und postet sie auf allen
+// konfigurierten Plattformen. Das Ergebnis wird in savedData und result eingetragen.
+func postNewLinks(ctx context.Context, config *Config, store LinkStore, savedData *LinkData, newLinks []string, testMode bool, result *CheckResult) error {
+	var approvalQueue *ApprovalQueue
+	var prepared []preparedPost
+	detailFetches := 0
+
+	// Bereits erfolgreich gepostete Plattformen überspringen
+	receipts, err := loadReceiptIndex(*config)
+	if err != nil {
+		slog.Warn("Post-Nachweise konnten nicht gelesen werden", "error", err)
+	}
+	if config.RepostReturning {
+		// Nachweise aus der Zeit vor dem Entfernen verhindern den erneuten Post nicht
+		for _, link := range newLinks {
+			if removedAt, returning := savedData.RemovedLinks[link]; returning {
+				receipts.forgetBefore(link, removedAt)
+			}
+		}
+	}
+
+	// Freigabe-Warteschlange laden, falls neue Links manuell freigegeben werden müssen
+	if config.RequireApproval {
+		queue, err := loadApprovalQueue(approvalQueueFile(*config))
+		if err != nil {
+			return err
+		}
+		approvalQueue = &queue
+	}
+
+	// Detailseiten vorab parallel abrufen; ausgewertet und gepostet wird danach nacheinander,
+	// sodass nur diese Schleife savedData verändert und das Log geordnet bleibt
+	pages := fetchDetailPages(ctx, *config, detailFetchList(ctx, *config, savedData, newLinks))
+
+	for i, link := range newLinks {
+		if ctx.Err() != nil {
+			// Beim Beenden keine weiteren Detailseiten abrufen; die restlichen Links werden
+			// beim nächsten Durchlauf bearbeitet
+			log.Printf("    ⏹️  Abbruch: %d Link(s) werden beim nächsten Durchlauf bearbeitet", len(newLinks)-i)
+			savedData.PendingLinks = append(savedData.PendingLinks, newLinks[i:]...)
+			break
+		}
+		log.Printf("  %d. %s", i+1, link)
+
+		// Ein paralleler Befehl (z.B. approve) kann den Link inzwischen gepostet haben
+		if store != nil {
+			if seen, err := store.IsSeen(link); err != nil {
+				slog.Warn("Link konnte nicht im Speicher nachgeschlagen werden", "link", link, "error", err)
+			} else if seen {
+				log.Printf("    ✅ Link wurde inzwischen gepostet, wird übersprungen")
+				// Den gespeicherten Link übernehmen, damit Save ihn am Ende nicht wieder entfernt
+				if stored, err := store.Load(); err == nil && stored.record(link) != nil {
+					savedData.markSeen(*stored.record(link))
+				}
+				continue
+			}
+		}
+
+		if removedAt, returning := savedData.RemovedLinks[link]; returning && !config.RepostReturning {
+			log.Printf("    ↩️  Link war seit %v entfernt und ist zurückgekehrt, wird ohne erneuten Post übernommen", removedAt.Format("02.01.2006"))
+			savedData.addLink(LinkRecord{Href: link, Skipped: skipReturned})
+			delete(savedData.RemovedLinks, link)
+			continue
+		}
+
+		// Detailseite abrufen und Text extrahieren
+		pageURL := detailPageURL(*config, link)
+		if !robotsAllowedURL(ctx, pageURL) {
+			slog.Warn("robots.txt verbietet den Abruf, Link wird übersprungen", "link", link, "url", pageURL)
+			continue
+		}
+		if config.MaxDetailFetchesPerRun > 0 && detailFetches >= config.MaxDetailFetchesPerRun {
+			log.Printf("    ⏸️  Abruf-Budget (%d) erschöpft, Link wird beim nächsten Durchlauf abgerufen", config.MaxDetailFetchesPerRun)
+			savedData.PendingLinks = append(savedData.PendingLinks, link)
+			continue
+		}
+		detailFetches++
+		log.Printf("    Abrufe Detailseite: %s", pageURL)
+		page, ok := pages[link]
+		if !ok {
+			page.content, page.err = fetchSourceURL(ctx, *config, pageURL)
+		}
+		pageContent, err := page.content, page.err
+		if err != nil {
+			slog.Error("Fehler beim Abrufen der Detailseite", "link", link, "url", pageURL, "error", err)
+			if ctx.Err() == nil {
+				markLinkFailed(store, savedData, link)
+				result.Failed++
+			}
+			continue
+		}
+		log.Printf("    Detailseite erfolgreich abgerufen, Länge: %d Zeichen", len(pageContent))
+		extractedTitle, text, err := extractListingText(pageContent, *config)
+		if err != nil {
+			slog.Error("Fehler beim Extrahieren des Textes", "link", link, "url", pageURL, "error", err)
+			markLinkFailed(store, savedData, link)
+			result.Failed++
+			continue
+		}
+		log.Printf("    Text extrahiert, Länge: %d Zeichen", len(text))
+		extractedTitle = normalizeTitle(extractedTitle, config.TitleNormalization)
+
+		// Stadtnamen extrahieren
+		cityName := extractCityName(pageContent)
+		if cityName != "" {
+			log.Printf("    Stadtnamen extrahiert: %s", cityName)
+		}
+
+		if text != "" {
+			log.Printf("--- Auszug aus %s ---\n%s\n--------------------------", link, text)
+
+			textHash := contentHash(text)
+			if config.DedupeByContentAcrossURLs {
+				if original, dup := findDuplicateContent(*savedData, link, textHash); dup {
+					log.Printf("    ♊ Inhalt identisch mit bereits gepostetem Link %s, wird nicht erneut gepostet", original)
+					savedData.addLink(LinkRecord{Href: link, Skipped: skipDuplicate})
+					savedData.ContentHashes[link] = textHash
+					continue
+				}
+			}
+
+			postData := newPostData(cityName, extractedTitle, text, pageURL, link)
+			postData.Permalink = permalinkFor(*config, link)
+			if attachments, err := extractAttachmentLinks(pageContent, pageURL); err != nil {
+				slog.Warn("Anhänge konnten nicht extrahiert werden", "link", link, "error", err)
+			} else if len(attachments) > 0 {
+				log.Printf("    %d Anhang/Anhänge gefunden", len(attachments))
+				postData.Attachments = attachments
+			}
+			var image listingImage
+			if config.MastodonImages && mastodonConfigured(*config) {
+				if image, err = extractListingImage(pageContent, pageURL, *config); err != nil {
+					slog.Warn("Bild konnte nicht extrahiert werden", "link", link, "error", err)
+				} else if image.URL != "" {
+					log.Printf("    Bild gefunden: %s", image.URL)
+				}
+			}
+			var mapImage listingImage
+			if config.StaticMapTemplate != "" && mastodonConfigured(*config) {
+				if mapImage, err = staticMapImage(ctx, *config, postData); err != nil {
+					slog.Warn("Karte wird nicht angehängt", "link", link, "error", err)
+				} else {
+					log.Printf("    Karte: %s", mapImage.URL)
+				}
+			}
+			if postData.Deadline != "" {
+				log.Printf("    Frist: %s", postData.Deadline)
+			} else {
+				slog.Warn("Keine Frist im Text gefunden", "link", link)
+			}
+			if !categoryAllowed(*config, postData.Category) {
+				log.Printf("    ⏭️  Kategorie %s ist herausgefiltert, Link wird ohne Post als gesehen markiert.", postData.Category)
+				savedData.addLink(LinkRecord{Href: link, Skipped: skipCategory})
+				continue
+			}
+			if config.RedactContacts {
+				postData.Text = redactContacts(postData.Text)
+			}
+			if config.ExcerptLength > 0 {
+				postData.Text = excerptText(postData.Text, config.ExcerptLength, pageURL)
+			}
+			if approvalQueue != nil {
+				if item := approvalQueue.find(link); item == nil {
+					queued := ApprovalItem{
+						Href:     link,
+						URL:      pageURL,
+						City:     postData.City,
+						Title:    extractedTitle,
+						Text:     postData.Text,
+						QueuedAt: time.Now(),
+					}
+					approvalQueue.Items = append(approvalQueue.Items, queued)
+					err := updateApprovalQueue(*config, func(q *ApprovalQueue) error {
+						if q.find(link) == nil {
+							q.Items = append(q.Items, queued)
+						}
+						return nil
+					})
+					if err != nil {
+						slog.Error("Fehler beim Speichern der Freigabe-Warteschlange", "link", link, "error", err)
+					} else {
+						log.Printf("    📝 Link wartet auf Freigabe (%s)", approvalQueueFile(*config))
+					}
+					continue
+				} else if item.Rejected {
+					log.Printf("    🗑️  Link wurde verworfen und wird ohne Post als gesehen markiert")
+					savedData.addLink(LinkRecord{Href: link, Skipped: skipRejected})
+					err := updateApprovalQueue(*config, func(q *ApprovalQueue) error {
+						q.remove(link)
+						return nil
+					})
+					if err != nil {
+						slog.Error("Fehler beim Speichern der Freigabe-Warteschlange", "link", link, "error", err)
+					}
+					continue
+				} else if !item.Approved {
+					log.Printf("    📝 Link wartet weiterhin auf Freigabe")
+					continue
+				}
+				log.Printf("    ✅ Link wurde freigegeben und wird gepostet")
+			}
+			texts := make(map[string]postTexts)
+			for _, platform := range templatePlatforms {
+				if texts[platform], err = renderPostTexts(*config, platform, postData, cityName); err != nil {
+					break
+				}
+			}
+			if err != nil {
+				slog.Error("Fehler beim Rendern der Post-Vorlage", "link", link, "error", err)
+				markLinkFailed(store, savedData, link)
+				result.Failed++
+				continue
+			}
+			// Anhänge erhalten nur Lemmy und Mastodon, bei Bluesky reicht der Platz nicht
+			lemmyTitle, lemmyText := formatLemmyPost(*config, texts["lemmy"])
+			mastodonText := formatMastodonPost(*config, texts["mastodon"], pageURL)
+			blueskyText := formatBlueskyPost(*config, texts["bluesky"], pageURL)
+			telegramText := formatTelegramPost(*config, texts["telegram"], pageURL)
+			discordTitle, discordText := formatDiscordPost(*config, texts["discord"])
+
+			// --- NEU: Plattform-Checks ---
+			if !anyPlatformConfigured(*config) {
+				slog.Error("Weder Lemmy noch Mastodon noch Bluesky noch Telegram noch Discord noch ein Webhook sind konfiguriert. Link wird nicht als erledigt markiert.", "link", link)
+				savedData.markPostFailed(link)
+				result.Failed++
+				continue
+			}
+
+			prepared = append(prepared, preparedPost{
+				link:         link,
+				pageURL:      pageURL,
+				data:         postData,
+				title:        lemmyTitle,
+				lemmyText:    lemmyText,
+				mastodonText: mastodonText,
+				blueskyText:  blueskyText,
+				telegramText: telegramText,
+				discordText:  discordText,
+				discordTitle: discordTitle,
+				image:        image,
+				mapImage:     mapImage,
+				textHash:     textHash,
+			})
+		} else {
+			slog.Error("Kein Text zwischen <hr>-Tags gefunden", "link", link, "url", pageURL)
+			markLinkFailed(store, savedData, link)
+			result.Failed++
+		}
+	}
+
+	if len(prepared) == 0 {
+		return nil
+	}
+
+	// Alle Plattformen parallel bedienen, damit eine langsame Plattform die anderen nicht aufhält
+	posters := newPlatformPosters(config, savedData, testMode)
+	outcomes, created := publishPrepared(ctx, *config, posters, prepared, receipts, testMode)
+	for _, poster := range posters {
+		for _, err := range outcomes[poster.Name()] {
+			savedData.noteRateLimit(poster.Name(), err, time.Now())
+			if err != nil && !errors.Is(err, errPlatformDeferred) {
+				metricPostFailures.WithLabelValues(poster.Name()).Inc()
+			}
+		}
+		for _, ok := range created[poster.Name()] {
+			if ok {
+				savedData.countPost(poster.Name(), time.Now())
+			}
+		}
+	}
+
+	for i, p := range prepared {
+		link := p.link
+		var postErrs []string
+		allDeferred := true
+		linkResult := LinkPostResult{Link: link}
+		for _, poster := range posters {
+			err := outcomes[poster.Name()][i]
+			linkResult.Platforms = append(linkResult.Platforms, PlatformResult{Name: poster.label, Success: err == nil, Err: err})
+			if err != nil {
+				postErrs = append(postErrs, poster.label+": "+err.Error())
+				allDeferred = allDeferred && isDeferral(err)
+			}
+		}
+		result.PostResults = append(result.PostResults, linkResult)
+
+		if len(postErrs) > 0 && allDeferred {
+			slog.Warn("Plattform gesperrt (Retry-After), Link wird zurückgestellt", "link", link, "error", strings.Join(postErrs, "; "))
+			savedData.markPostPending(link)
+		} else if len(postErrs) > 0 {
+			slog.Error("Mindestens ein Post fehlgeschlagen, Link wird erneut versucht", "link", link, "error", strings.Join(postErrs, "; "))
+			savedData.markPostFailed(link)
+			result.Failed++
+		} else {
+			slog.Info("Link auf allen konfigurierten Plattformen gepostet", "link", link)
+			if testMode {
+				result.WouldPost = append(result.WouldPost, PlannedPost{Link: link, Title: p.title})
+				if err := savePostAsJSON(*config, dumpDir(*config), p.title, p.lemmyText, p.pageURL, strings.Join(postTargets(*config), ", ")); err != nil {
+					slog.Warn("Geplanter Post konnte nicht gespeichert werden", "link", link, "error", err)
+				}
+			}
+			record := LinkRecord{
+				Href:      link,
+				Title:     p.data.Title,
+				City:      p.data.City,
+				Text:      p.data.Text,
+				FirstSeen: time.Now(),
+			}
+			if !testMode {
+				for _, poster := range posters {
+					record.PostedTo = append(record.PostedTo, poster.Name())
+				}
+			}
+			markLinkSeen(store, savedData, record)
+			result.Posted++
+			if !p.data.deadline.IsZero() {
+				savedData.Deadlines[link] = p.data.deadline
+			}
+			savedData.ContentHashes[link] = p.textHash
+			if approvalQueue != nil && approvalQueue.remove(link) {
+				err := updateApprovalQueue(*config, func(q *ApprovalQueue) error {
+					q.remove(link)
+					return nil
+				})
+				if err != nil {
+					slog.Error("Fehler beim Speichern der Freigabe-Warteschlange", "link", link, "error", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// preparedPost ist ein fertig gerenderter Post, der auf die Veröffentlichung wartet
+type preparedPost struct {
+	link         string
+	pageURL      string
+	data         PostData
+	title        string
+	lemmyText    string
+	mastodonText string
+	blueskyText  string // endet mit pageURL, das als Link-Facet ausgezeichnet wird
+	telegramText string // unformatiert, wird erst beim Senden für MarkdownV2 maskiert
+	discordText  string // Beschreibung des Embeds
+	discordTitle string
+	image        listingImage // wird nur an Mastodon angehängt
+	mapImage     listingImage // Karte nach StaticMapTemplate, wird nur an Mastodon angehängt
+	textHash     string
+}
+
+// errPlatformDeferred markiert Posts, die wegen eines Rate-Limits zurückgestellt wurden
+var errPlatformDeferred = errors.New("zurückgestellt (Retry-After)")
+
+// isDeferral meldet, ob ein Post wegen eines Rate-Limits nicht erstellt wurde und
+// später ohne Änderung gelingen kann
+func isDeferral(err error) bool {
+	var rl *RateLimitError
+	return errors.Is(err, errPlatformDeferred) || errors.As(err, &rl)
+}
+
+// maxInlineRetryAfter ist die längste Retry-After-Wartezeit, die innerhalb eines
+// Durchlaufs abgewartet wird. Längere Sperren stellen die restlichen Posts zurück.
+const maxInlineRetryAfter = 2 * time.Minute
+
+func lemmyConfigured(config Config) bool {
+	return len(lemmyTargets(config)) > 0
+}
+
+func mastodonConfigured(config Config) bool {
+	return config.MastodonServer != "" && (config.MastodonAccessToken != "" || mastodonCanLogin(config))
+}
+
+// mastodonCanLogin gibt an, ob ein Token per Username/Passwort geholt werden kann
+func mastodonCanLogin(config Config) bool {
+	return mastodonFlavorFor(config).passwordGrant && config.MastodonUsername != "" && config.MastodonPassword != "" && config.MastodonClientID != "" && config.MastodonClientSecret != ""
+}
+
+func blueskyConfigured(config Config) bool {
+	return config.BlueskyPDS != "" && config.BlueskyHandle != "" && config.BlueskyPassword != ""
+}
+
+func telegramConfigured(config Config) bool {
+	return config.TelegramBotToken != "" && config.TelegramChatID != ""
+}
+
+func discordConfigured(config Config) bool {
+	return config.DiscordWebhookURL != ""
+}
+
+func webhooksConfigured(config Config) bool {
+	return len(config.Webhooks) > 0
+}
+
+// anyPlatformConfigured meldet, ob mindestens eine Plattform konfiguriert ist
+func anyPlatformConfigured(config Config) bool {
+	return lemmyConfigured(config) || mastodonConfigured(config) || blueskyConfigured(config) || telegramConfigured(config) || discordConfigured(config) || webhooksConfigured(config)
+}
+
+// platformDelay gibt die konfigurierte Pause zwischen zwei Posts einer Plattform zurück
+func platformDelay(config Config, platform string) time.Duration {
+	return time.Duration(config.PlatformPostDelays[platform]) * time.Second
+}
+
+// Poster veröffentlicht fertig gerenderte Posts auf einer Plattform. Neue Plattformen
+// werden als weitere Implementierung in newPlatformPosters ergänzt.
+type Poster interface {
+	// Name ist der Schlüssel für Nachweise, Limits und Verzögerungen, z.B. "lemmy"
+	Name() string
+	// Post erstellt den Post; im Testmodus wird er nur protokolliert
+	Post(ctx context.Context, p preparedPost) (RemotePost, error)
+	// ReceiptText gibt den Text zurück, der im Post-Nachweis gespeichert wird
+	ReceiptText(p preparedPost) string
+}
+
+// platformPoster ist ein Poster mit den Angaben, die publishPrepared für seine
+// Warteschlange braucht
+type platformPoster struct {
+	Poster
+	label string        // Name für Log- und Fehlermeldungen
+	delay time.Duration // Pause zwischen zwei Posts
+	// unavailable ist gesetzt, wenn auf der Plattform in diesem Durchlauf nicht gepostet werden kann
+	unavailable error
+}
+
+// lemmyPoster postet in eine Lemmy-Community
+type lemmyPoster struct {
+	target      LemmyTarget
+	jwt         string
+	communityID int
+	testMode    bool
+}
+
+func (l lemmyPoster) Name() string { return l.target.key() }
+
+// logTestPost protokolliert im Testmodus einen Post, der erstellt worden wäre: die Angaben als
+// Attribute und den vollständigen Text unverändert darunter, damit er lesbar bleibt
+func logTestPost(platform, text string, args ...any) {
+	args = append([]any{"platform", platform, "chars", utf8.RuneCountInString(text)}, args...)
+	slog.Info("🧪 TEST: Post würde erstellt werden", args...)
+	log.Printf("    ---\n%s\n    ---", text)
+}
+
+func (l lemmyPoster) Post(ctx context.Context, p preparedPost) (RemotePost, error) {
+	if l.testMode {
+		logTestPost(l.Name(), p.lemmyText, "server", l.target.Server, "community", l.target.Community,
+			"community_id", l.communityID, "language", l.target.Language, "language_id", l.target.LanguageID,
+			"url", p.pageURL, "title", p.title)
+		return RemotePost{}, nil
+	}
+	return lemmyCreatePost(ctx, l.target.Server, l.jwt, l.communityID, l.target.LanguageID, p.title, p.lemmyText, p.pageURL)
+}
+
+func (l lemmyPoster) ReceiptText(p preparedPost) string { return p.title + "\n" + p.lemmyText }
+
+// mastodonPoster postet auf Mastodon
+type mastodonPoster struct {
+	config   *Config
+	token    string
+	testMode bool
+}
+
+func (m mastodonPoster) Name() string { return "mastodon" }
+
+func (m mastodonPoster) Post(ctx context.Context, p preparedPost) (RemotePost, error) {
+	if m.testMode {
+		logTestPost(m.Name(), p.mastodonText, "server", m.config.MastodonServer,
+			"visibility", m.config.MastodonVisibility, "image", p.image.URL, "map", p.mapImage.URL)
+		return RemotePost{}, nil
+	}
+	var mediaIDs []string
+	for _, image := range []listingImage{p.image, p.mapImage} {
+		if image.URL == "" {
+			continue
+		}
+		// Ein fehlendes Bild verhindert den Post nicht, er erscheint dann nur als Text
+		if id, err := mastodonUploadImage(ctx, *m.config, m.token, image); err != nil {
+			slog.Warn("Bild wird nicht angehängt", "platform", "mastodon", "image", image.URL, "error", err)
+		} else {
+			mediaIDs = append(mediaIDs, id)
+		}
+	}
+	return mastodonCreatePost(ctx, m.config.MastodonServer, m.token, p.mastodonText, postVisibility(*m.config), mediaIDs)
+}
+
+func (m mastodonPoster) ReceiptText(p preparedPost) string { return p.mastodonText }
+
+// blueskyPoster postet auf Bluesky
+type blueskyPoster struct {
+	config   *Config
+	session  BlueskySession
+	testMode bool
+}
+
+func (b blueskyPoster) Name() string { return "bluesky" }
+
+func (b blueskyPoster) Post(ctx context.Context, p preparedPost) (RemotePost, error) {
+	if b.testMode {
+		logTestPost(b.Name(), p.blueskyText, "pds", b.config.BlueskyPDS, "handle", b.config.BlueskyHandle)
+		return RemotePost{}, nil
+	}
+	return blueskyCreatePost(ctx, b.config.BlueskyPDS, b.session, p.blueskyText, p.pageURL)
+}
+
+func (b blueskyPoster) ReceiptText(p preparedPost) string { return p.blueskyText }
+
+// telegramPoster sendet Nachrichten an einen Telegram-Chat
+type telegramPoster struct {
+	config   *Config
+	testMode bool
+}
+
+func (t telegramPoster) Name() string { return "telegram" }
+
+func (t telegramPoster) Post(ctx context.Context, p preparedPost) (RemotePost, error) {
+	if t.testMode {
+		logTestPost(t.Name(), p.telegramText, "chat", t.config.TelegramChatID)
+		return RemotePost{}, nil
+	}
+	return telegramCreatePost(ctx, t.config.TelegramBotToken, t.config.TelegramChatID, p.telegramText)
+}
+
+func (t telegramPoster) ReceiptText(p preparedPost) string { return p.telegramText }
+
+// discordPoster sendet Embeds an einen Discord-Webhook
+type discordPoster struct {
+	config   *Config
+	testMode bool
+}
+
+func (d discordPoster) Name() string { return "discord" }
+
+func (d discordPoster) Post(ctx context.Context, p preparedPost) (RemotePost, error) {
+	if d.testMode {
+		logTestPost(d.Name(), p.discordText, "title", p.discordTitle, "url", p.pageURL)
+		return RemotePost{}, nil
+	}
+	return discordCreatePost(ctx, d.config.DiscordWebhookURL, p.discordTitle, p.discordText, p.pageURL)
+}
+
+func (d discordPoster) ReceiptText(p preparedPost) string { return p.discordTitle + "\n" + p.discordText }
+
+// webhookPoster sendet Posts als JSON an einen eigenen HTTP-Endpunkt
+type webhookPoster struct {
+	target   WebhookTarget
+	testMode bool
+}
+
+func (w webhookPoster) Name() string { return w.target.key() }
+
+func (w webhookPoster) Post(ctx context.Context, p preparedPost) (RemotePost, error) {
+	payload := newWebhookPayload(p.data.Title, p.data.Text, p.data.City, p.pageURL)
+	if w.testMode {
+		body, err := w.target.render(payload)
+		if err != nil {
+			return RemotePost{}, err
+		}
+		logTestPost(w.Name(), string(body), "target", w.target.String())
+		return RemotePost{}, nil
+	}
+	return webhookSend(ctx, w.target, payload)
+}
+
+func (w webhookPoster) ReceiptText(p preparedPost) string { return p.data.Title + "\n" + p.data.Text }
+
+// newPlatformPosters erstellt die Poster für alle konfigurierten Plattformen.
+// Jedes Lemmy-Ziel erhält einen eigenen Poster; der Login erfolgt einmal pro Durchgang.
+func newPlatformPosters(config *Config, savedData *LinkData, testMode bool) []platformPoster {
+	var posters []platformPoster
+
+	for _, target := range lemmyTargets(*config) {
+		if !config.postsTo(target.key()) {
+			continue
+		}
+		jwt, communityID := lemmyAuthenticate(&target)
+		storeLemmyToken(config, target)
+		poster := platformPoster{
+			Poster: lemmyPoster{target: target, jwt: jwt, communityID: communityID, testMode: testMode},
+			label:  "Lemmy " + target.String(),
+			delay:  platformDelay(*config, "lemmy"),
+		}
+		if !testMode {
+			if until, deferred := savedData.platformDeferred(poster.Name(), time.Now()); deferred {
+				slog.Warn("Plattform gesperrt (Retry-After), Links werden zurückgestellt", "platform", poster.Name(), "until", until)
+				poster.unavailable = errPlatformDeferred
+			} else if jwt == "" {
+				slog.Error("Kein gültiges Token, Posts übersprungen", "platform", poster.Name())
+				poster.unavailable = errors.New("Kein gültiges Token")
+			}
+		}
+		posters = append(posters, poster)
+	}
+
+	if mastodonConfigured(*config) && config.postsTo("mastodon") {
+		// Token-Handling wie bei Lemmy
+		mastodonToken, err := mastodonAuthenticate(config)
+		poster := platformPoster{
+			Poster: mastodonPoster{config: config, token: mastodonToken, testMode: testMode},
+			label:  "Mastodon",
+			delay:  platformDelay(*config, "mastodon"),
+		}
+		if err != nil {
+			poster.unavailable = err
+		} else if until, deferred := savedData.platformDeferred("mastodon", time.Now()); deferred && !testMode {
+			slog.Warn("Plattform gesperrt (Retry-After), Links werden zurückgestellt", "platform", "mastodon", "until", until)
+			poster.unavailable = errPlatformDeferred
+		}
+		posters = append(posters, poster)
+	}
+
+	if blueskyConfigured(*config) && config.postsTo("bluesky") {
+		// Bluesky-Sitzungen werden nicht gespeichert, sondern einmal pro Durchgang angelegt
+		var session BlueskySession
+		var err error
+		if !testMode {
+			session, err = blueskyLogin(config.BlueskyPDS, config.BlueskyHandle, config.BlueskyPassword)
+		}
+		poster := platformPoster{
+			Poster: blueskyPoster{config: config, session: session, testMode: testMode},
+			label:  "Bluesky",
+			delay:  platformDelay(*config, "bluesky"),
+		}
+		if err != nil {
+			slog.Error("Bluesky-Login fehlgeschlagen, Bluesky-Posts übersprungen", "platform", "bluesky", "error", err)
+			poster.unavailable = err
+		} else if until, deferred := savedData.platformDeferred("bluesky", time.Now()); deferred && !testMode {
+			slog.Warn("Plattform gesperrt (Retry-After), Links werden zurückgestellt", "platform", "bluesky", "until", until)
+			poster.unavailable = errPlatformDeferred
+		}
+		posters = append(posters, poster)
+	}
+
+	if telegramConfigured(*config) && config.postsTo("telegram") {
+		poster := platformPoster{
+			Poster: telegramPoster{config: config, testMode: testMode},
+			label:  "Telegram",
+			delay:  platformDelay(*config, "telegram"),
+		}
+		if until, deferred := savedData.platformDeferred("telegram", time.Now()); deferred && !testMode {
+			slog.Warn("Plattform gesperrt (Retry-After), Links werden zurückgestellt", "platform", "telegram", "until", until)
+			poster.unavailable = errPlatformDeferred
+		}
+		posters = append(posters, poster)
+	}
+
+	if discordConfigured(*config) && config.postsTo("discord") {
+		poster := platformPoster{
+			Poster: discordPoster{config: config, testMode: testMode},
+			label:  "Discord",
+			delay:  platformDelay(*config, "discord"),
+		}
+		if until, deferred := savedData.platformDeferred("discord", time.Now()); deferred && !testMode {
+			slog.Warn("Plattform gesperrt (Retry-After), Links werden zurückgestellt", "platform", "discord", "until", until)
+			poster.unavailable = errPlatformDeferred
+		}
+		posters = append(posters, poster)
+	}
+
+	for _, target := range config.Webhooks {
+		if !config.postsTo(target.key()) {
+			continue
+		}
+		poster := platformPoster{
+			Poster: webhookPoster{target: target, testMode: testMode},
+			label:  "Webhook " + target.String(),
+			delay:  platformDelay(*config, "webhook"),
+		}
+		if until, deferred := savedData.platformDeferred(poster.Name(), time.Now()); deferred && !testMode {
+			slog.Warn("Plattform gesperrt (Retry-After), Links werden zurückgestellt", "platform", poster.Name(), "until", until)
+			poster.unavailable = errPlatformDeferred
+		}
+		posters = append(posters, poster)
+	}
+
+	return posters
+}
+
+// publishPrepared veröffentlicht die Posts auf allen Plattformen. Jede Plattform arbeitet
+// ihre eigene Warteschlange in einer eigenen Goroutine ab und hält dabei ihre Pause und
+// kurze Retry-After-Sperren ein, ohne die anderen Plattformen aufzuhalten. Zurückgegeben
+// wird pro Plattform der Fehler je Post (nil bei Erfolg) und ob der Post in diesem Lauf
+// tatsächlich erstellt wurde. Jede Goroutine schreibt nur in die Listen ihrer eigenen Plattform.
+func publishPrepared(ctx context.Context, config Config, posters []platformPoster, posts []preparedPost, receipts receiptIndex, testMode bool) (map[string][]error, map[string][]bool) {
+	outcomes := make(map[string][]error, len(posters))
+	createdPosts := make(map[string][]bool, len(posters))
+	var wg sync.WaitGroup
+
+	for _, poster := range posters {
+		errs := make([]error, len(posts))
+		created := make([]bool, len(posts))
+		outcomes[poster.Name()] = errs
+		createdPosts[poster.Name()] = created
+		if poster.unavailable != nil {
+			for i := range errs {
+				errs[i] = poster.unavailable
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(poster platformPoster) {
+			defer wg.Done()
+			posted := 0
+			for i, p := range posts {
+				if !testMode && receipts.has(poster.Name(), p.link) {
+					slog.Info("Bereits gepostet (Nachweis vorhanden), übersprungen", "platform", poster.Name(), "link", p.link)
+					continue
+				}
+				if posted > 0 && poster.delay > 0 && !testMode && !sleepContext(ctx, poster.delay) {
+					deferRemaining(errs, i)
+					return
+				}
+				posted++
+
+				remote, err := poster.Post(ctx, p)
+				var rl *RateLimitError
+				if errors.As(err, &rl) && rl.RetryAfter > 0 && rl.RetryAfter <= maxInlineRetryAfter {
+					slog.Warn("Rate-Limit erreicht, neuer Versuch", "platform", poster.Name(), "retry_after", rl.RetryAfter)
+					if !sleepContext(ctx, rl.RetryAfter) {
+						deferRemaining(errs, i)
+						return
+					}
+					remote, err = poster.Post(ctx, p)
+				}
+
+				errs[i] = err
+				if err != nil {
+					slog.Error("Post fehlgeschlagen", "platform", poster.Name(), "link", p.link, "error", err)
+					if errors.As(err, &rl) && rl.RetryAfter > 0 {
+						// Plattform ist gesperrt: restliche Posts bis zum nächsten Durchlauf zurückstellen
+						deferRemaining(errs, i+1)
+						return
+					}
+					continue
+				}
+				if !testMode {
+					slog.Info("Post erstellt", "platform", poster.Name(), "link", p.link, "url", remote.URL)
+					recordReceipt(config, poster.Name(), p.link, poster.ReceiptText(p), remote)
+					created[i] = true
+				}
+			}
+		}(poster)
+	}
+
+	wg.Wait()
+	return outcomes, createdPosts
+}
+
+// sleepContext wartet d ab und gibt false zurück, falls ctx vorher abgebrochen wird
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// deferRemaining stellt alle Posts ab Index from bis zum nächsten Durchlauf zurück
+func deferRemaining(errs []error, from int) {
+	for j := from; j < len(errs); j++ {
+		errs[j] = errPlatformDeferred
+	}
+}
+
+// statusSnapshot hält die vorgerenderten Antworten des Status-Servers. Sie werden am
+// Ende jeder Überprüfung neu erzeugt, damit Anfragen keine Link-Daten laden müssen.
+type statusSnapshot struct {
+	mu         sync.RWMutex
+	statusJSON []byte
+	feedXML    []byte
+	items      map[string]itemPage // Seiten der Permalinks, nach linkHash
+}
+
+// itemPage ist die vorgerenderte Antwort auf einen Permalink /item/<hash>
+type itemPage struct {
+	status int
+	body   []byte
+}
+
+// statusCache ist der vom Status-Server ausgelieferte Snapshot
+var statusCache = &statusSnapshot{statusJSON: []byte("{}")}
+
+// Update erzeugt den Snapshot aus den aktuellen Link-Daten neu
+func (s *statusSnapshot) Update(data LinkData, result CheckResult, checkedAt time.Time) {
+	status := map[string]interface{}{
+		"last_check":   checkedAt,
+		"last_result":  result,
+		"links":        len(data.Links),
+		"failed_links": data.FailedLinks,
+		"post_counts":  data.PostCounts,
+	}
+	statusJSON, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		slog.Warn("Status-Snapshot konnte nicht erzeugt werden", "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	s.statusJSON = statusJSON
+	s.mu.Unlock()
+}
+
+// UpdateItems erzeugt die Seiten der Permalinks aus den gespeicherten Links aller Quellen neu
+func (s *statusSnapshot) UpdateItems(config Config) {
+	items, err := buildItemPages(config)
+	if err != nil {
+		slog.Warn("Permalink-Seiten konnten nicht erzeugt werden", "error", err)
+		return
+	}
+	s.mu.Lock()
+	s.items = items
+	s.mu.Unlock()
+}
+
+// UpdateFeed erzeugt den unter /feed.xml ausgelieferten RSS-Feed neu
+func (s *statusSnapshot) UpdateFeed(config Config, now time.Time) {
+	feedXML, err := renderFeed(config, now)
+	if err != nil {
+		slog.Warn("Feed-Snapshot konnte nicht erzeugt werden", "error", err)
+		return
+	}
+	s.mu.Lock()
+	s.feedXML = feedXML
+	s.mu.Unlock()
+}
+
+// ServeFeed liefert den zuletzt erzeugten RSS-Feed aus
+func (s *statusSnapshot) ServeFeed(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	body := s.feedXML
+	s.mu.RUnlock()
+	if body == nil {
+		http.Error(w, "Feed noch nicht erzeugt", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.Write(body)
+}
+
+// ServeItem liefert die Seite zu einem Permalink /item/<hash> aus
+func (s *statusSnapshot) ServeItem(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	page, ok := s.items[r.PathValue("hash")]
+	s.mu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(page.status)
+	w.Write(page.body)
+}
+
+// itemHTML ist die Seite hinter einem Permalink
+var itemHTML = htmltemplate.Must(htmltemplate.New("item").Parse(`<!DOCTYPE html>
+<html lang="de"><head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body><h1>{{.Title}}</h1>
+{{if .Removed}}<p>Das Listing wurde am {{.Removed}} von der Website entfernt.</p>
+{{else}}{{with .FirstSeen}}<p>Gepostet am {{.}}</p>
+{{end}}<pre>{{.Text}}</pre>
+{{end}}<p><a href="{{.URL}}">{{.URL}}</a></p>
+</body></html>
+`))
+
+// itemPageData sind die Angaben einer Permalink-Seite
+type itemPageData struct {
+	Title     string
+	Text      string
+	URL       string
+	FirstSeen string
+	Removed   string
+}
+
+// buildItemPages rendert die Seiten aller Permalinks: gespeicherte Links mit Titel und Text,
+// von der Website entfernte Links mit dem Zeitpunkt der Entfernung und Status 410
+func buildItemPages(config Config) (map[string]itemPage, error) {
+	pages := make(map[string]itemPage)
+	render := func(link string, status int, data itemPageData) error {
+		var b bytes.Buffer
+		if err := itemHTML.Execute(&b, data); err != nil {
+			return fmt.Errorf("Fehler beim Rendern der Seite für %s: %v", link, err)
+		}
+		pages[linkHash(link)] = itemPage{status: status, body: b.Bytes()}
+		return nil
+	}
+	for _, source := range sourceConfigs(config) {
+		data, err := loadSourceData(source)
+		if err != nil {
+			return nil, err
+		}
+		for link, removedAt := range data.RemovedLinks {
+			err := render(link, http.StatusGone, itemPageData{
+				Title:   strings.Title(strings.Split(link, "/")[0]),
+				URL:     detailPageURL(source, link),
+				Removed: removedAt.Format("02.01.2006"),
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, record := range data.Links {
+			city := record.City
+			if city == "" {
+				city = strings.Title(strings.Split(record.Href, "/")[0])
+			}
+			page := itemPageData{Title: city, Text: record.Text, URL: detailPageURL(source, record.Href)}
+			if record.Title != "" {
+				page.Title += ": " + record.Title
+			}
+			if !record.FirstSeen.IsZero() {
+				page.FirstSeen = record.FirstSeen.Format("02.01.2006")
+			}
+			if err := render(record.Href, http.StatusOK, page); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return pages, nil
+}
+
+// ServeStatus liefert den zuletzt erzeugten Status als JSON aus
+func (s *statusSnapshot) ServeStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	body := s.statusJSON
+	s.mu.RUnlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// healthStatus hält fest, wann die letzte Überprüfung erfolgreich war und woran die
+// letzte fehlgeschlagene gescheitert ist
+type healthStatus struct {
+	mu          sync.RWMutex
+	started     time.Time
+	lastSuccess time.Time
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// healthState wird am Ende jedes Durchlaufs aktualisiert und unter /healthz ausgeliefert
+var healthState = &healthStatus{started: time.Now()}
+
+// Record trägt das Ergebnis eines Durchlaufs ein
+func (h *healthStatus) Record(err error, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		h.lastError = err.Error()
+		h.lastErrorAt = now
+		return
+	}
+	h.lastSuccess = now
+}
+
+// HealthHandler liefert 200, wenn die letzte erfolgreiche Überprüfung höchstens maxAge
+// zurückliegt, sonst 503. Vor der ersten Überprüfung zählt der Startzeitpunkt.
+func (h *healthStatus) HealthHandler(maxAge time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h.mu.RLock()
+		reference := h.lastSuccess
+		if reference.IsZero() {
+			reference = h.started
+		}
+		body := map[string]interface{}{
+			"status": "ok",
+		}
+		if !h.lastSuccess.IsZero() {
+			body["last_seen"] = h.lastSuccess
+		}
+		if h.lastError != "" {
+			body["last_error"] = h.lastError
+			body["last_error_at"] = h.lastErrorAt
+		}
+		h.mu.RUnlock()
+
+		code := http.StatusOK
+		if time.Since(reference) > maxAge {
+			code = http.StatusServiceUnavailable
+			body["status"] = "stale"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+// healthMaxAge ist die Zeit, nach der ohne erfolgreiche Überprüfung /healthz 503 meldet
+func healthMaxAge(config Config) time.Duration {
+	return 2 * config.CheckInterval
+}
+
+// startStatusServer startet den Status-Server und beendet ihn, sobald ctx abgebrochen wird
+func startStatusServer(ctx context.Context, addr string, config Config) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", statusCache.ServeStatus)
+	mux.HandleFunc("/feed.xml", statusCache.ServeFeed)
+	mux.HandleFunc("GET /item/{hash}", statusCache.ServeItem)
+	mux.HandleFunc("/healthz", healthState.HealthHandler(healthMaxAge(config)))
+	if config.AdminToken != "" {
+		registerApprovalHandlers(mux, config)
+	}
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		log.Printf("Status-Server lauscht auf %s", addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("Fehler im Status-Server", "addr", addr, "error", err)
+		}
+	}()
+}
+
+// lemmyAuthenticate liefert ein gültiges Lemmy-Token und die Community-ID für ein Ziel.
+// Ein gespeichertes Token wird wiederverwendet, sonst wird ein neues geholt und in target abgelegt.
+// Bei Fehlern wird ein leeres Token bzw. die Community-ID 0 zurückgegeben.
+func lemmyAuthenticate(target *LemmyTarget) (string, int) {
+	var jwt string
+	var err error
+	if target.Token != "" && time.Now().Before(target.TokenExp) {
+		// Verwende gespeichertes Token
+		jwt = target.Token
+		log.Printf("Verwende gespeichertes Lemmy-Token für %s (gültig bis %v)", target.Server, target.TokenExp)
+	} else {
+		// Hole neues Token
+		jwt, err = lemmyLogin(target.Server, target.Username, target.Password)
+		if err != nil {
+			slog.Error("Fehler beim Lemmy-Login", "platform", target.key(), "error", err)
+			return "", 0
+		}
+		// Token für 1 Stunde speichern
+		target.Token = jwt
+		target.TokenExp = time.Now().Add(1 * time.Hour)
+		log.Printf("Neues Lemmy-Token für %s geholt und gespeichert (gültig bis %v)", target.Server, target.TokenExp)
+		// Die language_id wird mit jedem neuen Token neu ermittelt, damit eine geänderte
+		// Sprache spätestens nach Ablauf des Tokens gilt
+		target.LanguageID = 0
+	}
+	if target.Language == "" {
+		target.LanguageID = 0
+	} else if target.LanguageID == 0 {
+		languageID, err := lemmyGetLanguageID(target.Server, jwt, target.Language)
+		if err != nil {
+			slog.Warn("Lemmy-Sprache konnte nicht ermittelt werden, Posts ohne language_id", "platform", target.key(), "language", target.Language, "error", err)
+		} else {
+			target.LanguageID = languageID
+			slog.Info("language_id ermittelt", "platform", target.key(), "language", target.Language, "language_id", languageID)
+		}
+	}
+
+	// Community-ID abfragen
+	communityID, err := lemmyGetCommunityID(target.Server, jwt, target.Community)
+	if err != nil {
+		slog.Error("Fehler beim Abrufen der Community-ID", "platform", target.key(), "error", err)
+		return jwt, 0
+	}
+	log.Printf("Community-ID für '%s': %d", target.Community, communityID)
+	return jwt, communityID
+}
+
+// mastodonTokenRefreshMargin ist der Abstand vor dem Ablauf, ab dem ein Mastodon-Token erneuert wird
+const mastodonTokenRefreshMargin = 60 * time.Second
+
+// mastodonTokenExpired gibt an, ob das Mastodon-Token abgelaufen ist oder innerhalb von
+// mastodonTokenRefreshMargin abläuft. Tokens ohne Ablaufzeit laufen nie ab.
+func mastodonTokenExpired(config Config, now time.Time) bool {
+	return !config.MastodonTokenExp.IsZero() && !now.Before(config.MastodonTokenExp.Add(-mastodonTokenRefreshMargin))
+}
+
+// mastodonAuthenticate liefert ein gültiges Mastodon-Token. Fehlt das Token oder läuft es bald ab
+// und sind Username/Passwort/ClientID/Secret gesetzt, wird per Passwort ein neues Token geholt
+// und in config abgelegt; gespeichert wird es mit der Konfiguration am Ende des Durchlaufs.
+func mastodonAuthenticate(config *Config) (string, error) {
+	mastodonToken := config.MastodonAccessToken
+	if mastodonToken == "" || mastodonTokenExpired(*config, time.Now()) {
+		if !mastodonFlavorFor(*config).passwordGrant {
+			slog.Warn("Login per Passwort wird nicht unterstützt, verwende nur mastodon_access_token", "platform", "mastodon", "flavor", config.MastodonFlavor)
+		} else if mastodonCanLogin(*config) {
+			log.Printf("    Mastodon: Hole neues Access Token per Passwort...")
+			token, exp, err := mastodonLogin(config.MastodonServer, config.MastodonClientID, config.MastodonClientSecret, config.MastodonUsername, config.MastodonPassword)
+			if err != nil {
+				slog.Error("Fehler beim Mastodon-Login", "platform", "mastodon", "error", err)
+				return "", fmt.Errorf("Login: %v", err)
+			}
+			mastodonToken = token
+			config.MastodonAccessToken = token
+			config.MastodonTokenExp = exp
+			if exp.IsZero() {
+				log.Printf("    Mastodon: Neues Token geholt und gespeichert (ohne Ablaufzeit)")
+			} else {
+				log.Printf("    Mastodon: Neues Token geholt und gespeichert (gültig bis %v)", exp)
+			}
+		}
+	}
+	if mastodonToken == "" {
+		if config.MastodonUsername != "" || config.MastodonPassword != "" || config.MastodonClientID != "" || config.MastodonClientSecret != "" {
+			slog.Error("Kein Access Token verfügbar und Login mit Username/Passwort/ClientID/Secret nicht möglich (z.B. GoToSocial). Bitte ein App-Passwort (mastodon_access_token) verwenden.", "platform", "mastodon")
+		}
+		slog.Error("Kein Mastodon-Token verfügbar, Mastodon-Post übersprungen", "platform", "mastodon")
+		return "", fmt.Errorf("Kein Token")
+	}
+	return mastodonToken, nil
+}
+
+// noticeKey gibt den Schlüssel einer Mitteilung in den Post-Nachweisen zurück, z.B.
+// "removed:koeln/index.htm@2026-03-01T10:00:00Z". version unterscheidet wiederholte
+// Mitteilungen derselben Art zum selben Link (z.B. eine neue Frist).
+func noticeKey(kind, link, version string) string {
+	return kind + ":" + link + "@" + version
+}
+
+// noticePost bereitet eine kurze Mitteilung (z.B. eine Erinnerung) für publishPrepared vor.
+// key ersetzt den Link in den Post-Nachweisen, siehe noticeKey.
+func noticePost(config Config, key, title, text, pageURL string) preparedPost {
+	texts := noticeTexts(title, text)
+	lemmyTitle, lemmyText := formatLemmyPost(config, texts)
+	discordTitle, discordText := formatDiscordPost(config, texts)
+	return preparedPost{
+		link:         key,
+		pageURL:      pageURL,
+		data:         PostData{Title: title, Text: text, URL: pageURL},
+		title:        lemmyTitle,
+		lemmyText:    lemmyText,
+		mastodonText: formatMastodonPost(config, texts, pageURL),
+		blueskyText:  formatBlueskyPost(config, texts, pageURL),
+		telegramText: formatTelegramPost(config, texts, pageURL),
+		discordText:  discordText,
+		discordTitle: discordTitle,
+	}
+}
+
+// postNotices veröffentlicht Mitteilungen wie neue Listings über newPlatformPosters und
+// publishPrepared, sodass Retry-After-Sperren, Pausen, onlyPlatform und vorhandene
+// Post-Nachweise gelten: Nach einem Teilerfolg wird eine Mitteilung nur noch auf den
+// übrigen Plattformen gepostet. Zurückgegeben wird je Mitteilung nil, wenn sie auf allen
+// Plattformen gepostet ist, sonst die Fehler der übrigen Plattformen.
+func postNotices(ctx context.Context, config *Config, savedData *LinkData, notices []preparedPost, testMode bool) []error {
+	errs := make([]error, len(notices))
+	if len(notices) == 0 {
+		return errs
+	}
+	if !anyPlatformConfigured(*config) {
+		for i := range errs {
+			errs[i] = fmt.Errorf("Weder Lemmy noch Mastodon noch Bluesky noch Telegram noch Discord noch ein Webhook sind konfiguriert")
+		}
+		return errs
+	}
+	receipts, err := loadReceiptIndex(*config)
+	if err != nil {
+		slog.Warn("Post-Nachweise konnten nicht gelesen werden", "error", err)
+	}
+
+	posters := newPlatformPosters(config, savedData, testMode)
+	outcomes, _ := publishPrepared(ctx, *config, posters, notices, receipts, testMode)
+	for _, poster := range posters {
+		for _, err := range outcomes[poster.Name()] {
+			savedData.noteRateLimit(poster.Name(), err, time.Now())
+			if err != nil && !errors.Is(err, errPlatformDeferred) {
+				metricPostFailures.WithLabelValues(poster.Name()).Inc()
+			}
+		}
+	}
+	for i := range notices {
+		var postErrs []string
+		for _, poster := range posters {
+			if err := outcomes[poster.Name()][i]; err != nil {
+				postErrs = append(postErrs, poster.label+": "+err.Error())
+			}
+		}
+		if len(postErrs) > 0 {
+			errs[i] = errors.New(strings.Join(postErrs, "; "))
+		}
+	}
+	return errs
+}
+
+// sendRemovalNotices postet für jeden Link in PendingRemovalNotices eine Mitteilung, dass das
+// Listing zurückgezogen wurde. Fehlgeschlagene Mitteilungen bleiben für den nächsten Durchlauf stehen.
+func sendRemovalNotices(ctx context.Context, config *Config, savedData *LinkData, testMode bool) {
+	var records []LinkRecord
+	var notices []preparedPost
+	for _, record := range savedData.PendingRemovalNotices {
+		if savedData.record(record.Href) != nil {
+			// Link ist inzwischen wieder auf der Website, keine Mitteilung mehr nötig
+			continue
+		}
+		city := record.City
+		if city == "" {
+			city = strings.Title(strings.Split(record.Href, "/")[0])
+		}
+		title := "Listing zurückgezogen: " + city
+		text := "Das Listing ist nicht mehr auf der Website veröffentlicht."
+		if record.Title != "" {
+			text = fmt.Sprintf("Das Listing „%s“ ist nicht mehr auf der Website veröffentlicht.", record.Title)
+		}
+		// Ein später erneut entfernter Link erhält eine neue Mitteilung
+		version := ""
+		if removedAt, ok := savedData.RemovedLinks[record.Href]; ok {
+			version = removedAt.UTC().Format(time.RFC3339)
+		}
+		log.Printf("📭 Mitteilung über entfernten Link %s", record.Href)
+		records = append(records, record)
+		notices = append(notices, noticePost(*config, noticeKey("removed", record.Href, version), title, text, detailPageURL(*config, record.Href)))
+	}
+
+	var failed []LinkRecord
+	for i, err := range postNotices(ctx, config, savedData, notices, testMode) {
+		if err != nil {
+			slog.Error("Mitteilung über entfernten Link fehlgeschlagen, wird erneut versucht", "link", records[i].Href, "error", err)
+			failed = append(failed, records[i])
+		}
+	}
+	savedData.PendingRemovalNotices = failed
+}
+
+// postUpdatedLinks ruft die Detailseiten der geposteten Links auf der Übersichtsseite bedingt
+// ab und postet eine Mitteilung für jeden Link, dessen Text nicht mehr zu ContentHashes passt.
+// Neuer Hash und Validatoren werden erst nach erfolgreicher Mitteilung gespeichert, damit eine
+// fehlgeschlagene Mitteilung beim nächsten Durchlauf wiederholt wird. Links ohne gespeicherten
+// Hash erhalten ihn ohne Mitteilung. Zurückgegeben wird die Zahl der geänderten Links.
+func postUpdatedLinks(ctx context.Context, config *Config, savedData *LinkData, currentLinks, newLinks []string, testMode bool) int {
+	onIndex := make(map[string]bool, len(currentLinks))
+	for _, link := range currentLinks {
+		onIndex[link] = true
+	}
+	storeValidators := func(pageURL string, validators CacheValidators) {
+		if validators.ETag != "" || validators.LastModified != "" {
+			savedData.Validators[pageURL] = validators
+		} else {
+			delete(savedData.Validators, pageURL)
+		}
+	}
+	// linkUpdate wird erst nach erfolgreicher Mitteilung übernommen
+	type linkUpdate struct {
+		index      int
+		pageURL    string
+		text       string
+		hash       string
+		deadline   time.Time
+		validators CacheValidators
+	}
+	var updates []linkUpdate
+	var notices []preparedPost
+	for i := range savedData.Links {
+		record := &savedData.Links[i]
+		link := record.Href
+		if ctx.Err() != nil {
+			break
+		}
+		if !onIndex[link] || containsString(newLinks, link) {
+			continue
+		}
+		pageURL := detailPageURL(*config, link)
+		if !robotsAllowedURL(ctx, pageURL) {
+			continue
+		}
+		pageContent, validators, err := fetchSourceURLConditional(ctx, *config, pageURL, savedData.Validators[pageURL])
+		if errors.Is(err, ErrNotModified) {
+			continue
+		}
+		if err != nil {
+			slog.Warn("Detailseite konnte nicht auf Änderungen geprüft werden", "link", link, "error", err)
+			continue
+		}
+		title, text, err := extractListingText(pageContent, *config)
+		if err != nil || text == "" {
+			continue
+		}
+		hash := contentHash(text)
+		oldHash, known := savedData.ContentHashes[link]
+		if known && oldHash != hash {
+			data := newPostData(record.City, normalizeTitle(title, config.TitleNormalization), text, pageURL, link)
+			noticeTitle := "Listing aktualisiert: " + data.City
+			noticeText := "Das Listing wurde auf der Website geändert."
+			if data.Title != "" {
+				noticeText = fmt.Sprintf("Das Listing „%s“ wurde auf der Website geändert.", data.Title)
+			}
+			if data.Deadline != "" {
+				noticeText += fmt.Sprintf(" Frist: %s.", data.Deadline)
+			}
+			log.Printf("✏️  Geänderter Link %s", link)
+			updates = append(updates, linkUpdate{index: i, pageURL: pageURL, text: text, hash: hash, deadline: data.deadline, validators: validators})
+			notices = append(notices, noticePost(*config, noticeKey("updated", link, hash), noticeTitle, noticeText, pageURL))
+			continue
+		}
+		savedData.ContentHashes[link] = hash
+		storeValidators(pageURL, validators)
+	}
+
+	for i, err := range postNotices(ctx, config, savedData, notices, testMode) {
+		u := updates[i]
+		record := &savedData.Links[u.index]
+		if err != nil {
+			slog.Error("Mitteilung über geänderten Link fehlgeschlagen, wird erneut versucht", "link", record.Href, "error", err)
+			continue
+		}
+		record.Text = u.text
+		if !u.deadline.IsZero() && !u.deadline.Equal(savedData.Deadlines[record.Href]) {
+			// Eine neue Frist soll erneut erinnert werden
+			savedData.Deadlines[record.Href] = u.deadline
+			delete(savedData.RemindersSent, record.Href)
+		}
+		savedData.ContentHashes[record.Href] = u.hash
+		storeValidators(u.pageURL, u.validators)
+	}
+	return len(updates)
+}
+
+// sendDeadlineReminders postet einmalig eine Erinnerung für Links, deren Frist
+// innerhalb von DeadlineReminderDays Tagen abläuft
+func sendDeadlineReminders(ctx context.Context, config *Config, testMode bool, now time.Time) error {
+	if config.DeadlineReminderDays <= 0 {
+		return nil
+	}
+	if until, quiet := quietHoursUntil(*config, now); quiet {
+		slog.Info("🌙 Ruhezeit, Erinnerungen werden danach gepostet", "until", until.Format("15:04"))
+		return nil
+	}
+	savedData, err := loadSourceData(*config)
+	if err != nil {
+		return err
+	}
+
+	window := time.Duration(config.DeadlineReminderDays) * 24 * time.Hour
+	var links []string
+	var notices []preparedPost
+	for _, record := range savedData.Links {
+		link := record.Href
+		deadline, ok := savedData.Deadlines[link]
+		if !ok || now.After(deadline) || deadline.Sub(now) > window {
+			continue
+		}
+		if _, sent := savedData.RemindersSent[link]; sent {
+			continue
+		}
+
+		city := record.City
+		if city == "" {
+			city = strings.Title(strings.Split(link, "/")[0])
+		}
+		pageURL := detailPageURL(*config, link)
+		title := "Frist läuft bald ab: " + city
+		text := fmt.Sprintf("Die Frist für die Bekundung des Erwerbsinteresses endet am %s.", deadline.Format("02.01.2006"))
+		log.Printf("⏰ Erinnerung für %s (Frist %s)", link, deadline.Format("02.01.2006"))
+		links = append(links, link)
+		notices = append(notices, noticePost(*config, noticeKey("reminder", link, deadline.Format("2006-01-02")), title, text, pageURL))
+	}
+
+	if len(notices) == 0 {
+		return nil
+	}
+	for i, err := range postNotices(ctx, config, &savedData, notices, testMode) {
+		if err != nil {
+			slog.Error("Erinnerung fehlgeschlagen, wird erneut versucht", "link", links[i], "error", err)
+			continue
+		}
+		savedData.RemindersSent[links[i]] = now
+	}
+	if testMode {
+		return nil
+	}
+	return saveSourceData(*config, savedData)
+}
+
+// Prometheus-Metriken, über -metrics-addr unter /metrics abrufbar
+var (
+	metricChecks = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gvgbot_checks_total",
+		Help: "Number of website checks run.",
+	})
+	metricCurrentLinks = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gvgbot_current_links",
+		Help: "Number of links found on the index pages in the last check.",
+	})
+	metricNewLinks = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gvgbot_new_links",
+		Help: "Number of new links found in the last check.",
+	})
+	metricRemovedLinks = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gvgbot_removed_links",
+		Help: "Number of links removed from the index pages in the last check.",
+	})
+	metricPostFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gvgbot_post_failures_total",
+		Help: "Number of failed posts by platform.",
+	}, []string{"platform"})
+	metricLastSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gvgbot_last_successful_check_timestamp_seconds",
+		Help: "Unix time of the last check in which all sources could be read.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(metricChecks, metricCurrentLinks, metricNewLinks, metricRemovedLinks, metricPostFailures, metricLastSuccess)
+}
+
+// recordCheckMetrics überträgt das Ergebnis eines Durchlaufs in die Prometheus-Metriken
+func recordCheckMetrics(result CheckResult, success bool, now time.Time) {
+	metricCurrentLinks.Set(float64(result.Checked))
+	metricNewLinks.Set(float64(result.New))
+	metricRemovedLinks.Set(float64(result.Removed))
+	if success {
+		metricLastSuccess.Set(float64(now.Unix()))
+	}
+}
+
+// startMetricsServer startet den Prometheus-Endpunkt /metrics und /healthz und beendet
+// beide, sobald ctx abgebrochen wird
+func startMetricsServer(ctx context.Context, addr string, config Config) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", healthState.HealthHandler(healthMaxAge(config)))
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		log.Printf("Metrik-Server lauscht auf %s", addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("Fehler im Metrik-Server", "addr", addr, "error", err)
+		}
+	}()
+}
+
+// statsdPrefix wird allen an StatsD gesendeten Metriken vorangestellt
+const statsdPrefix = "gvgbot."
+
+// sendStatsd sendet die Metriken eines Durchlaufs per UDP an StatsD
+func sendStatsd(addr string, result CheckResult, storedLinks int, postCounts map[string]int) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	metrics := []string{
+		fmt.Sprintf("%schecks:1|c", statsdPrefix),
+		fmt.Sprintf("%snew_links:%d|c", statsdPrefix, result.New),
+		fmt.Sprintf("%sposted_links:%d|c", statsdPrefix, result.Posted),
+		fmt.Sprintf("%sfailed_links:%d|c", statsdPrefix, result.Failed),
+		fmt.Sprintf("%sremoved_links:%d|c", statsdPrefix, result.Removed),
+		fmt.Sprintf("%scurrent_links:%d|g", statsdPrefix, result.Checked),
+		fmt.Sprintf("%sstored_links:%d|g", statsdPrefix, storedLinks),
+		fmt.Sprintf("%scheck_duration:%d|ms", statsdPrefix, result.Duration.Milliseconds()),
+	}
+	for _, platform := range sortedPlatforms(postCounts) {
+		metrics = append(metrics, fmt.Sprintf("%sposts_total.%s:%d|g", statsdPrefix, platform, postCounts[platform]))
+	}
+	_, err = conn.Write([]byte(strings.Join(metrics, "\n")))
+	return err
+}
+
+// sendAllDeadlineReminders sendet die fälligen Frist-Erinnerungen für alle Quellen
+// und speichert dabei erneuerte Tokens
+func sendAllDeadlineReminders(ctx context.Context, config Config, testMode bool) {
+	changed := false
+	for _, source := range sourceConfigs(config) {
+		if err := sendDeadlineReminders(ctx, &source, testMode, time.Now()); err != nil {
+			slog.Error("Fehler beim Senden der Frist-Erinnerungen", "source", source.URL, "error", err)
+		}
+		if mergeTokens(&config, source) {
+			changed = true
+		}
+	}
+	if changed {
+		if err := saveConfig(config, config.configFile); err != nil {
+			slog.Warn("Konfiguration konnte nicht gespeichert werden", "file", config.configFile, "error", err)
+		}
+	}
+}
+
+// reloadTokens übernimmt die zuletzt gespeicherten Plattform-Tokens aus der Konfigurationsdatei,
+// damit ein im vorigen Durchlauf erneuertes Token weiterverwendet wird. Die übrigen, beim
+// Start übernommenen globalen Einstellungen bleiben unverändert.
+func reloadTokens(config *Config) {
+	saved, err := readConfig(config.configFile)
+	if err != nil {
+		slog.Warn("Tokens konnten nicht neu geladen werden", "error", err)
+		return
+	}
+	mergeTokens(config, saved)
+}
+
+// runMonitoring startet die kontinuierliche Überwachung
+func runMonitoring(ctx context.Context, config Config, testMode bool) error {
+	log.Printf("Starte Überwachung der Website: %s", config.URL)
+	log.Printf("Überprüfungsintervall: %v", config.CheckInterval)
+	log.Printf("Datendatei: %s", config.DataFile)
+
+	// Erste Überprüfung sofort bzw. nach einer zufälligen Verzögerung durchführen
+	if config.StartupJitter > 0 {
+		delay := time.Duration(rand.Int63n(int64(config.StartupJitter)))
+		log.Printf("Erste Überprüfung in %v", delay.Round(time.Second))
+		if !sleepContext(ctx, delay) {
+			log.Println("Überwachung beendet")
+			return nil
+		}
+	}
+	cycleStart := time.Now()
+	_, err := checkWebsite(ctx, config, testMode)
+	healthState.Record(err, time.Now())
+	if err != nil {
+		slog.Error("Fehler bei der ersten Überprüfung", "error", err)
+	}
+	sendAllDeadlineReminders(ctx, config, testMode)
+
+	// Der Timer wird für jeden Durchlauf neu berechnet, damit jedes Intervall eigenen Jitter
+	// erhält. Gemessen wird ab Beginn des vorigen Durchlaufs wie bei einem Ticker.
+	timer := time.NewTimer(nextCheckDelay(config, cycleStart, time.Now()))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Überwachung beendet")
+			return nil
+		case <-timer.C:
+			cycleStart = time.Now()
+			reloadTokens(&config)
+			_, err := checkWebsite(ctx, config, testMode)
+			healthState.Record(err, time.Now())
+			if err != nil {
+				slog.Error("Fehler bei der Website-Überprüfung", "error", err)
+			}
+			sendAllDeadlineReminders(ctx, config, testMode)
+			timer.Reset(nextCheckDelay(config, cycleStart, time.Now()))
+		}
+	}
+}
+
+// nextCheckDelay gibt die Wartezeit bis zur nächsten Überprüfung zurück: CheckInterval
+// ab cycleStart, zufällig verschoben um bis zu ±CheckJitter
+func nextCheckDelay(config Config, cycleStart, now time.Time) time.Duration {
+	interval := config.CheckInterval
+	if config.CheckJitter > 0 {
+		interval += time.Duration(rand.Int63n(2*int64(config.CheckJitter)+1)) - config.CheckJitter
+	}
+	return max(interval-now.Sub(cycleStart), 0)
+}
+
+// mastodonFlavor beschreibt die Abweichungen eines Mastodon-kompatiblen Servers
+type mastodonFlavor struct {
+	// passwordGrant gibt an, ob der Server Tokens per OAuth-Password-Grant ausstellt
+	passwordGrant bool
+	// visibilities enthält die unterstützten Sichtbarkeiten; nicht unterstützte werden
+	// über visibilityFallback auf die nächstliegende abgebildet
+	visibilities map[string]bool
+	// mediaPath ist der Endpunkt für das Hochladen von Bildern
+	mediaPath string
+}
+
+// mastodonFlavors enthält die unterstützten Server-Varianten
+var mastodonFlavors = map[string]mastodonFlavor{
+	"mastodon": {
+		passwordGrant: true,
+		visibilities:  map[string]bool{"public": true, "unlisted": true, "private": true, "direct": true},
+		mediaPath:     "/api/v2/media",
+	},
+	// GoToSocial kennt keinen Password-Grant, kein /api/v2/media und zusätzlich "mutuals_only"
+	"gotosocial": {
+		passwordGrant: false,
+		visibilities:  map[string]bool{"public": true, "unlisted": true, "private": true, "mutuals_only": true, "direct": true},
+		mediaPath:     "/api/v1/media",
+	},
+	// Akkoma kennt zusätzlich "local" (nur auf der eigenen Instanz sichtbar)
+	"akkoma": {
+		passwordGrant: true,
+		visibilities:  map[string]bool{"public": true, "unlisted": true, "local": true, "private": true, "direct": true},
+		mediaPath:     "/api/v2/media",
+	},
+}
+
+// visibilityFallback bildet varianten-spezifische Sichtbarkeiten auf Mastodon-Standardwerte ab
+var visibilityFallback = map[string]string{
+	"mutuals_only": "private",
+	"local":        "unlisted",
+}
+
+// mastodonFlavorFor gibt die Server-Variante der Konfiguration zurück (Standard: mastodon)
+func mastodonFlavorFor(config Config) mastodonFlavor {
+	if flavor, ok := mastodonFlavors[config.MastodonFlavor]; ok {
+		return flavor
+	}
+	return mastodonFlavors["mastodon"]
+}
+
+// postVisibility gibt die Sichtbarkeit zurück, die der Server der Konfiguration versteht
+func postVisibility(config Config) string {
+	visibility := config.MastodonVisibility
+	flavor := mastodonFlavorFor(config)
+	if visibility == "" || flavor.visibilities[visibility] {
+		return visibility
+	}
+	if fallback, ok := visibilityFallback[visibility]; ok && flavor.visibilities[fallback] {
+		slog.Warn("Sichtbarkeit wird nicht unterstützt, verwende Ersatz", "platform", "mastodon", "flavor", config.MastodonFlavor, "visibility", visibility, "fallback", fallback)
+		return fallback
+	}
+	return visibility
+}
+
+// RateLimitError wird zurückgegeben, wenn eine Plattform mit HTTP 429 antwortet
+type RateLimitError struct {
+	Platform   string
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s HTTP 429 (Retry-After %v) - Antwort: %s", e.Platform, e.RetryAfter, e.Body)
+}
+
+// parseRetryAfter wertet einen Retry-After-Header aus (Sekunden oder HTTP-Datum)
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(header); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+func lemmyLogin(serverURL, username, password string) (string, error) {
+	loginUrl := serverURL + "/api/v3/user/login"
+	payload := map[string]string{
+		"username_or_email": username,
+		"password":          password,
+	}
+	data, _ := json.Marshal(payload)
+	resp, err := httpPost(context.Background(), loginUrl, "application/json", strings.NewReader(string(data)))
+	if err != nil {
+		return "", fmt.Errorf("Lemmy-Login fehlgeschlagen: %v", err)
+	}
+	defer closeBody(resp)
+
+	// Komplette Antwort lesen
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("Fehler beim Lesen der Login-Antwort: %v", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("Lemmy-Login HTTP %d - Antwort: %s", resp.StatusCode, string(body))
+	}
+
+	var loginResp LemmyLoginResponse
+	if err := json.Unmarshal(body, &loginResp); err != nil {
+		return "", fmt.Errorf("Lemmy-Login JSON-Fehler: %v - Antwort: %s", err, string(body))
+	}
+	return loginResp.Jwt, nil
+}
+
+// Hilfsfunktion, um Community-ID anhand des Namens zu holen
+func lemmyGetCommunityID(serverURL, jwt, communityName string) (int, error) {
+	id, lookupErr := lemmyLookupCommunity(serverURL, jwt, communityName)
+	if lookupErr == nil && id != 0 {
+		return id, nil
+	}
+	// Manche Instanzen liefern beim direkten Abruf keine oder eine andere Antwort, die Suche
+	// findet die Community dort trotzdem
+	id, searchErr := lemmySearchCommunity(serverURL, jwt, communityName)
+	if searchErr == nil && id != 0 {
+		return id, nil
+	}
+	if lookupErr == nil {
+		lookupErr = errors.New("keine Community in der Antwort")
+	}
+	if searchErr == nil {
+		searchErr = errors.New("kein Treffer")
+	}
+	return 0, fmt.Errorf("Community %s nicht gefunden (Abruf: %v; Suche: %v)", communityName, lookupErr, searchErr)
+}
+
+// lemmyLookupCommunity ruft die Community direkt über /api/v3/community ab. Neben
+// community_view.community.id wird auch eine Antwort mit community.id auf oberster Ebene akzeptiert.
+func lemmyLookupCommunity(serverURL, jwt, communityName string) (int, error) {
+	var respData struct {
+		CommunityView struct {
+			Community struct {
+				Id int `json:"id"`
+			} `json:"community"`
+		} `json:"community_view"`
+		Community struct {
+			Id int `json:"id"`
+		} `json:"community"`
+	}
+	if err := lemmyGet(serverURL, "/api/v3/community", url.Values{"name": {communityName}}, jwt, &respData); err != nil {
+		return 0, err
+	}
+	if respData.CommunityView.Community.Id != 0 {
+		return respData.CommunityView.Community.Id, nil
+	}
+	return respData.Community.Id, nil
+}
+
+// lemmySearchCommunity sucht die Community über /api/v3/search. Ein Name der Form
+// name@instanz muss auch in der Instanz übereinstimmen, sonst wird die lokale Community bevorzugt.
+func lemmySearchCommunity(serverURL, jwt, communityName string) (int, error) {
+	name, instance, _ := strings.Cut(communityName, "@")
+	var respData struct {
+		Communities []struct {
+			Community struct {
+				Id      int    `json:"id"`
+				Name    string `json:"name"`
+				ActorID string `json:"actor_id"`
+				Local   bool   `json:"local"`
+			} `json:"community"`
+		} `json:"communities"`
+	}
+	query := url.Values{"type_": {"Communities"}, "q": {name}, "limit": {"50"}}
+	if err := lemmyGet(serverURL, "/api/v3/search", query, jwt, &respData); err != nil {
+		return 0, err
+	}
+	fallback := 0
+	for _, view := range respData.Communities {
+		c := view.Community
+		if !strings.EqualFold(c.Name, name) {
+			continue
+		}
+		actor, err := url.Parse(c.ActorID)
+		actorHost := ""
+		if err == nil {
+			actorHost = actor.Host
+		}
+		switch {
+		case instance != "" && strings.EqualFold(actorHost, instance):
+			return c.Id, nil
+		case instance == "" && c.Local:
+			return c.Id, nil
+		case instance == "" && fallback == 0:
+			fallback = c.Id
+		}
+	}
+	return fallback, nil
+}
+
+// lemmyGet ruft einen Lemmy-API-Endpunkt mit GET ab und dekodiert die Antwort nach v
+func lemmyGet(serverURL, path string, query url.Values, jwt string, v interface{}) error {
+	resp, err := lemmyRequest(context.Background(), "GET", serverURL, path, query, nil, jwt)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// lemmyGetLanguageID ermittelt über /api/v3/site die language_id zum ISO-Code code
+func lemmyGetLanguageID(serverURL, jwt, code string) (int, error) {
+	var site struct {
+		AllLanguages []struct {
+			ID   int    `json:"id"`
+			Code string `json:"code"`
+		} `json:"all_languages"`
+	}
+	if err := lemmyGet(serverURL, "/api/v3/site", nil, jwt, &site); err != nil {
+		return 0, err
+	}
+	for _, language := range site.AllLanguages {
+		if strings.EqualFold(language.Code, code) && language.ID != 0 {
+			return language.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("Sprache %q ist auf dem Server nicht bekannt", code)
+}
+
+// Arten, das JWT an Lemmy zu übergeben (LemmyAuthMode)
+const (
+	lemmyAuthHeader = "header"
+	lemmyAuthBody   = "body"
+)
+
+// lemmyAuthMode ist der konfigurierte LemmyAuthMode, gesetzt von loadConfig
+var lemmyAuthMode = lemmyAuthHeader
+
+// lemmyBodyAuthServers merkt sich Server, die das Bearer-Token abgelehnt, auth im Body aber
+// akzeptiert haben, damit nicht bei jeder Anfrage erneut beide Varianten versucht werden
+var lemmyBodyAuthServers sync.Map
+
+// lemmyRequest sendet eine Anfrage an die Lemmy-API und übergibt das JWT gemäß lemmyAuthMode:
+// als Authorization-Header oder als auth im JSON-Body (POST) bzw. in der Query (GET). Lehnt
+// der Server das Bearer-Token ab, wird die Anfrage einmal mit auth im Body wiederholt.
+func lemmyRequest(ctx context.Context, method, serverURL, path string, query url.Values, payload map[string]interface{}, jwt string) (*http.Response, error) {
+	send := func(bodyAuth bool) (*http.Response, error) {
+		params := url.Values{}
+		for name, values := range query {
+			params[name] = values
+		}
+		var body io.Reader
+		if payload != nil {
+			fields := make(map[string]interface{}, len(payload)+1)
+			for name, value := range payload {
+				fields[name] = value
+			}
+			if bodyAuth {
+				fields["auth"] = jwt
+			}
+			data, _ := json.Marshal(fields)
+			body = strings.NewReader(string(data))
+		} else if bodyAuth {
+			params.Set("auth", jwt)
+		}
+		endpoint := serverURL + path
+		if len(params) > 0 {
+			endpoint += "?" + params.Encode()
+		}
+		req, err := newRequest(ctx, method, endpoint, body)
+		if err != nil {
+			return nil, err
+		}
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if !bodyAuth {
+			req.Header.Set("Authorization", "Bearer "+jwt)
+		}
+		return httpClient.Do(req)
+	}
+
+	_, remembered := lemmyBodyAuthServers.Load(serverURL)
+	if lemmyAuthMode == lemmyAuthBody || remembered {
+		return send(true)
+	}
+	resp, err := send(false)
+	if err != nil || !lemmyAuthRejected(resp) {
+		return resp, err
+	}
+	closeBody(resp)
+	slog.Warn("Lemmy lehnt das Bearer-Token ab, versuche auth im Body", "server", serverURL, "status", resp.StatusCode)
+	resp, err = send(true)
+	if err == nil && !lemmyAuthRejected(resp) {
+		lemmyBodyAuthServers.Store(serverURL, true)
+	}
+	return resp, err
+}
+
+// lemmyAuthRejected meldet, ob Lemmy die Anfrage wegen fehlender Anmeldung abgelehnt hat
+func lemmyAuthRejected(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden
+}
+
+// Passe lemmyCreatePost an, damit sie community_id verwendet
+func lemmyCreatePost(ctx context.Context, serverURL, jwt string, communityID, languageID int, title, body, url string) (RemotePost, error) {
+	payload := map[string]interface{}{
+		"name":         title,
+		"body":         body,
+		"url":          url,
+		"community_id": communityID,
+	}
+	if languageID != 0 {
+		payload["language_id"] = languageID
+	}
+	resp, err := lemmyRequest(ctx, "POST", serverURL, "/api/v3/post", nil, payload, jwt)
+	if err != nil {
+		return RemotePost{}, err
+	}
+	defer closeBody(resp)
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return RemotePost{}, fmt.Errorf("Fehler beim Lesen der Lemmy-Antwort: %v", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return RemotePost{}, &RateLimitError{Platform: "Lemmy", RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), Body: string(respBody)}
+	}
+	if resp.StatusCode != 200 {
+		return RemotePost{}, fmt.Errorf("Post-Erstellung HTTP %d - Antwort: %s", resp.StatusCode, string(respBody))
+	}
+	slog.Debug("Lemmy-Post erstellt", "server", serverURL, "status", resp.StatusCode, "response", string(respBody))
+	var postResp LemmyPostResponse
+	if err := json.Unmarshal(respBody, &postResp); err != nil {
+		slog.Warn("Lemmy-Antwort konnte nicht gelesen werden", "platform", "lemmy", "error", err)
+	}
+	return RemotePost{
+		ID:  strconv.Itoa(postResp.PostView.Post.Id),
+		URL: postResp.PostView.Post.ApId,
+	}, nil
+}
+
+// mastodonLogin holt ein Access Token per OAuth2 Password Grant
+func mastodonLogin(server, clientID, clientSecret, username, password string) (string, time.Time, error) {
+	tokenURL := server + "/oauth/token"
+	payload := url.Values{}
+	payload.Set("grant_type", "password")
+	payload.Set("client_id", clientID)
+	payload.Set("client_secret", clientSecret)
+	payload.Set("username", username)
+	payload.Set("password", password)
+	payload.Set("scope", "read write")
+
+	resp, err := httpPost(context.Background(), tokenURL, "application/x-www-form-urlencoded", strings.NewReader(payload.Encode()))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("Mastodon-Login fehlgeschlagen: %v", err)
+	}
+	defer closeBody(resp)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("Fehler beim Lesen der Mastodon-Login-Antwort: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		return "", time.Time{}, fmt.Errorf("Mastodon-Login HTTP %d - Antwort: %s", resp.StatusCode, string(body))
+	}
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", time.Time{}, fmt.Errorf("Mastodon-Login JSON-Fehler: %v - Antwort: %s", err, string(body))
+	}
+	// Ohne expires_in ist das Token unbegrenzt gültig
+	var exp time.Time
+	if tokenResp.ExpiresIn > 0 {
+		exp = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	}
+	return tokenResp.AccessToken, exp, nil
+}
+
+// mastodonURLLength ist die feste Länge, mit der Mastodon jede URL auf das Zeichenlimit anrechnet
+const mastodonURLLength = 23
+
+var mastodonURLRe = regexp.MustCompile(`https?://[^\s]+`)
+
+// mastodonLength zählt die Zeichen eines Textes nach den Regeln von Mastodon
+func mastodonLength(text string) int {
+	n := utf8.RuneCountInString(text)
+	for _, u := range mastodonURLRe.FindAllString(text, -1) {
+		n += mastodonURLLength - utf8.RuneCountInString(u)
+	}
+	return n
+}
+
+// mastodonPostText hängt Disclaimer und pageURL an den Text an. Überschreitet das Ergebnis
+// das Zeichenlimit, wird nur der Text gekürzt; URLs zählen dabei wie bei Mastodon 23 Zeichen.
+func mastodonPostText(config Config, text, pageURL string) string {
+	suffix := "\n" + pageURL
+	room := platformLimit(config, "mastodon") - mastodonLength(suffix)
+	if room < 1 {
+		return pageURL
+	}
+	// fitToLimit zählt Runen. Lange URLs im Text zählen weniger, das Limit in Runen darf
+	// also größer sein; es wird so lange verkleinert, bis auch die Mastodon-Zählung passt.
+	runeRoom := room
+	if saved := utf8.RuneCountInString(text) - mastodonLength(text); saved > 0 {
+		runeRoom += saved
+	}
+	fitted := fitToLimit(config, "mastodon", text, runeRoom)
+	for runeRoom > 1 {
+		excess := mastodonLength(fitted) - room
+		if excess <= 0 {
+			break
+		}
+		runeRoom -= excess
+		fitted = fitToLimit(config, "mastodon", text, runeRoom)
+	}
+	return fitted + suffix
+}
+
+// mastodonCreatePost erstellt einen neuen Beitrag auf Mastodon mit den zuvor hochgeladenen Medien
+func mastodonCreatePost(ctx context.Context, server, token, text, visibility string, mediaIDs []string) (RemotePost, error) {
+	apiUrl := server + "/api/v1/statuses"
+	payload := map[string]interface{}{
+		"status":     text,
+		"visibility": visibility,
+	}
+	if len(mediaIDs) > 0 {
+		payload["media_ids"] = mediaIDs
+	}
+	data, _ := json.Marshal(payload)
+	req, err := newRequest(ctx, "POST", apiUrl, strings.NewReader(string(data)))
+	if err != nil {
+		return RemotePost{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, body, err := doRequest(req)
+	if err != nil {
+		return RemotePost{}, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return RemotePost{}, &RateLimitError{Platform: "Mastodon", RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), Body: string(body)}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return RemotePost{}, fmt.Errorf("Mastodon-Post HTTP %d - Antwort: %s", resp.StatusCode, string(body))
+	}
+	var status RemotePost
+	if err := json.Unmarshal(body, &status); err != nil {
+		slog.Warn("Mastodon-Antwort konnte nicht gelesen werden", "platform", "mastodon", "error", err)
+	}
+	return status, nil
+}
+
+// mastodonUploadImage lädt das Bild von der Quelle herunter und über den Medien-Endpunkt
+// der Server-Variante hoch (/api/v2/media, bei GoToSocial /api/v1/media).
+// Bilder über MastodonMaxImageBytes und Dateien, die kein Bild sind, ergeben einen Fehler.
+// Verarbeitet der Server das Bild asynchron (HTTP 202), wird kurz auf das Ergebnis gewartet.
+func mastodonUploadImage(ctx context.Context, config Config, token string, image listingImage) (string, error) {
+	if !robotsAllowedURL(ctx, image.URL) {
+		return "", fmt.Errorf("robots.txt verbietet den Abruf des Bildes")
+	}
+	data, err := fetchURL(ctx, image.URL, config.MastodonMaxImageBytes, sourceHeaders(config, image.URL))
+	if errors.Is(err, ErrPageTooLarge) {
+		return "", fmt.Errorf("Bild ist größer als %d Bytes", config.MastodonMaxImageBytes)
+	}
+	if err != nil {
+		return "", err
+	}
+	contentType := http.DetectContentType([]byte(data))
+	if !strings.HasPrefix(contentType, "image/") {
+		return "", fmt.Errorf("kein Bild (%s)", contentType)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, path.Base(image.URL)))
+	header.Set("Content-Type", contentType)
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return "", err
+	}
+	part.Write([]byte(data))
+	if image.Alt != "" {
+		form.WriteField("description", image.Alt)
+	}
+	form.Close()
+
+	req, err := newRequest(ctx, "POST", config.MastodonServer+mastodonFlavorFor(config).mediaPath, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, respBody, err := doRequest(req)
+	if err != nil {
+		return "", fmt.Errorf("Upload fehlgeschlagen: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return "", fmt.Errorf("Upload HTTP %d - Antwort: %s", resp.StatusCode, string(respBody))
+	}
+	var media struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(respBody, &media); err != nil || media.ID == "" {
+		return "", fmt.Errorf("Upload ohne Medien-ID - Antwort: %s", string(respBody))
+	}
+	if resp.StatusCode == http.StatusAccepted {
+		if err := mastodonWaitForMedia(ctx, config.MastodonServer, token, media.ID); err != nil {
+			return "", err
+		}
+	}
+	return media.ID, nil
+}
+
+// mastodonWaitForMedia wartet, bis ein asynchron hochgeladenes Medium verarbeitet ist.
+// Vorher lehnt Mastodon einen Beitrag mit diesem Medium ab.
+func mastodonWaitForMedia(ctx context.Context, server, token, id string) error {
+	for attempt := 0; attempt < 10; attempt++ {
+		if !sleepContext(ctx, time.Second) {
+			return ctx.Err()
+		}
+		req, err := newRequest(ctx, "GET", server+"/api/v1/media/"+url.PathEscape(id), nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("Status des Bildes konnte nicht abgefragt werden: %v", err)
+		}
+		closeBody(resp)
+		if resp.StatusCode == http.StatusOK {
+			return nil
+		}
+		if resp.StatusCode != http.StatusPartialContent {
+			return fmt.Errorf("Status des Bildes HTTP %d", resp.StatusCode)
+		}
+	}
+	return fmt.Errorf("Bild wurde nicht rechtzeitig verarbeitet")
+}
+
+// BlueskySession ist eine mit com.atproto.server.createSession angelegte Sitzung
+type BlueskySession struct {
+	AccessJwt string `json:"accessJwt"`
+	Did       string `json:"did"`
+	Handle    string `json:"handle"`
+}
+
+// blueskyLogin legt eine Sitzung auf dem PDS an
+func blueskyLogin(pds, handle, password string) (BlueskySession, error) {
+	var session BlueskySession
+	payload := map[string]string{
+		"identifier": handle,
+		"password":   password,
+	}
+	data, _ := json.Marshal(payload)
+	resp, err := httpPost(context.Background(), strings.TrimSuffix(pds, "/")+"/xrpc/com.atproto.server.createSession", "application/json", strings.NewReader(string(data)))
+	if err != nil {
+		return session, fmt.Errorf("Bluesky-Login fehlgeschlagen: %v", err)
+	}
+	defer closeBody(resp)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return session, fmt.Errorf("Fehler beim Lesen der Bluesky-Login-Antwort: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		return session, fmt.Errorf("Bluesky-Login HTTP %d - Antwort: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, &session); err != nil {
+		return session, fmt.Errorf("Bluesky-Login JSON-Fehler: %v - Antwort: %s", err, string(body))
+	}
+	if session.AccessJwt == "" || session.Did == "" {
+		return session, fmt.Errorf("Bluesky-Login ohne Sitzung - Antwort: %s", string(body))
+	}
+	return session, nil
+}
+
+// blueskyPostText kürzt text auf das Bluesky-Limit und hängt pageURL an. Bluesky zählt
+// Grapheme; gekürzt wird nach Zeichen (Runes), was nie mehr als das Limit ergibt.
+func blueskyPostText(config Config, text, pageURL string) string {
+	suffix := "\n" + pageURL
+	room := platformLimit(config, "bluesky") - utf8.RuneCountInString(suffix)
+	if room < 1 {
+		return pageURL
+	}
+	return fitToLimit(config, "bluesky", text, room) + suffix
+}
+
+// blueskyCreatePost erstellt einen app.bsky.feed.post-Eintrag. Endet text mit linkURL,
+// wird der Link als Facet ausgezeichnet, damit er in den Apps anklickbar ist.
+func blueskyCreatePost(ctx context.Context, pds string, session BlueskySession, text, linkURL string) (RemotePost, error) {
+	record := map[string]interface{}{
+		"$type":     "app.bsky.feed.post",
+		"text":      text,
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+		"langs":     []string{"de"},
+	}
+	if linkURL != "" && strings.HasSuffix(text, linkURL) {
+		// Facet-Indizes sind Byte-Offsets im UTF-8-kodierten Text
+		record["facets"] = []map[string]interface{}{{
+			"index": map[string]int{
+				"byteStart": len(text) - len(linkURL),
+				"byteEnd":   len(text),
+			},
+			"features": []map[string]string{{
+				"$type": "app.bsky.richtext.facet#link",
+				"uri":   linkURL,
+			}},
+		}}
+	}
+	payload := map[string]interface{}{
+		"repo":       session.Did,
+		"collection": "app.bsky.feed.post",
+		"record":     record,
+	}
+	data, _ := json.Marshal(payload)
+	req, err := newRequest(ctx, "POST", strings.TrimSuffix(pds, "/")+"/xrpc/com.atproto.repo.createRecord", strings.NewReader(string(data)))
+	if err != nil {
+		return RemotePost{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+session.AccessJwt)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return RemotePost{}, err
+	}
+	defer closeBody(resp)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		body, _ := io.ReadAll(resp.Body)
+		return RemotePost{}, &RateLimitError{Platform: "Bluesky", RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), Body: string(body)}
+	}
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return RemotePost{}, fmt.Errorf("Bluesky-Post HTTP %d - Antwort: %s", resp.StatusCode, string(body))
+	}
+	var created struct {
+		URI string `json:"uri"`
+		CID string `json:"cid"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		slog.Warn("Bluesky-Antwort konnte nicht gelesen werden", "platform", "bluesky", "error", err)
+	}
+	remote := RemotePost{ID: created.URI}
+	// at://<did>/app.bsky.feed.post/<rkey> → https://bsky.app/profile/<did>/post/<rkey>
+	if i := strings.LastIndex(created.URI, "/"); i >= 0 && created.URI != "" {
+		remote.URL = "https://bsky.app/profile/" + session.Did + "/post/" + created.URI[i+1:]
+	}
+	return remote, nil
+}
+
+// telegramAPIURL ist die Basis-URL der Telegram Bot API
+var telegramAPIURL = "https://api.telegram.org"
+
+// telegramPostText entfernt die Markdown-Hervorhebungen des extrahierten Textes, kürzt ihn
+// auf das Telegram-Limit und hängt pageURL an. Maskiert wird erst in telegramCreatePost.
+func telegramPostText(config Config, text, pageURL string) string {
+	text = strings.NewReplacer("**", "", "*", "").Replace(text)
+	suffix := "\n\n" + pageURL
+	room := platformLimit(config, "telegram") - utf8.RuneCountInString(suffix)
+	if room < 1 {
+		return pageURL
+	}
+	return fitToLimit(config, "telegram", text, room) + suffix
+}
+
+// telegramMarkdownV2Replacer maskiert alle Zeichen, die in MarkdownV2 eine Bedeutung haben
+var telegramMarkdownV2Replacer = strings.NewReplacer(
+	"\\", "\\\\", "_", "\\_", "*", "\\*", "[", "\\[", "]", "\\]", "(", "\\(", ")", "\\)",
+	"~", "\\~", "`", "\\`", ">", "\\>", "#", "\\#", "+", "\\+", "-", "\\-", "=", "\\=",
+	"|", "\\|", "{", "\\{", "}", "\\}", ".", "\\.", "!", "\\!",
+)
+
+// escapeTelegramMarkdownV2 maskiert text, damit er mit parse_mode=MarkdownV2 unverändert erscheint
+func escapeTelegramMarkdownV2(text string) string {
+	return telegramMarkdownV2Replacer.Replace(text)
+}
+
+// telegramCreatePost sendet text über sendMessage der Bot API an chatID
+func telegramCreatePost(ctx context.Context, token, chatID, text string) (RemotePost, error) {
+	payload := map[string]interface{}{
+		"chat_id":    chatID,
+		"text":       escapeTelegramMarkdownV2(text),
+		"parse_mode": "MarkdownV2",
+	}
+	data, _ := json.Marshal(payload)
+	resp, err := httpPost(ctx, telegramAPIURL+"/bot"+token+"/sendMessage", "application/json", strings.NewReader(string(data)))
+	if err != nil {
+		// Die URL enthält das Bot-Token und darf nicht im Log landen
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return RemotePost{}, fmt.Errorf("Telegram-Nachricht fehlgeschlagen: %v", err)
+	}
+	defer closeBody(resp)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return RemotePost{}, fmt.Errorf("Fehler beim Lesen der Telegram-Antwort: %v", err)
+	}
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+		Parameters  struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+		Result struct {
+			MessageID int `json:"message_id"`
+			Chat      struct {
+				Username string `json:"username"`
+			} `json:"chat"`
+		} `json:"result"`
+	}
+	json.Unmarshal(body, &result)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter := time.Duration(result.Parameters.RetryAfter) * time.Second
+		if retryAfter == 0 {
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+		return RemotePost{}, &RateLimitError{Platform: "Telegram", RetryAfter: retryAfter, Body: string(body)}
+	}
+	if resp.StatusCode != 200 || !result.OK {
+		return RemotePost{}, fmt.Errorf("Telegram-Nachricht HTTP %d - Antwort: %s", resp.StatusCode, string(body))
+	}
+	remote := RemotePost{ID: strconv.Itoa(result.Result.MessageID)}
+	if result.Result.Chat.Username != "" {
+		remote.URL = "https://t.me/" + result.Result.Chat.Username + "/" + remote.ID
+	}
+	return remote, nil
+}
+
+// Limits für Discord-Embeds
+const (
+	discordTitleLimit       = 256
+	discordDescriptionLimit = 4096
+)
+
+// discordCreatePost sendet einen Embed mit Titel, Text und Link an den Webhook. Mit wait=true
+// antwortet Discord mit der erstellten Nachricht, deren ID als Nachweis gespeichert wird.
+func discordCreatePost(ctx context.Context, webhookURL, title, text, pageURL string) (RemotePost, error) {
+	if utf8.RuneCountInString(title) > discordTitleLimit {
+		title = truncateString(title, discordTitleLimit-3)
+	}
+	if utf8.RuneCountInString(text) > discordDescriptionLimit {
+		text = truncateString(text, discordDescriptionLimit-3)
+	}
+	payload := map[string]interface{}{
+		"embeds": []map[string]interface{}{{
+			"title":       title,
+			"description": text,
+			"url":         pageURL,
+		}},
+	}
+	data, _ := json.Marshal(payload)
+	target := webhookURL
+	if strings.Contains(target, "?") {
+		target += "&wait=true"
+	} else {
+		target += "?wait=true"
+	}
+	resp, err := httpPost(ctx, target, "application/json", strings.NewReader(string(data)))
+	if err != nil {
+		// Die Webhook-URL enthält das Token und darf nicht im Log landen
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return RemotePost{}, fmt.Errorf("Discord-Nachricht fehlgeschlagen: %v", err)
+	}
+	defer closeBody(resp)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return RemotePost{}, fmt.Errorf("Fehler beim Lesen der Discord-Antwort: %v", err)
+	}
+	var result struct {
+		ID         string  `json:"id"`
+		RetryAfter float64 `json:"retry_after"`
+	}
+	json.Unmarshal(body, &result)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter := time.Duration(result.RetryAfter * float64(time.Second))
+		if retryAfter == 0 {
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+		return RemotePost{}, &RateLimitError{Platform: "Discord", RetryAfter: retryAfter, Body: string(body)}
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return RemotePost{}, fmt.Errorf("Discord-Nachricht HTTP %d - Antwort: %s", resp.StatusCode, string(body))
+	}
+	return RemotePost{ID: result.ID}, nil
+}
+
+// WebhookTarget ist ein eigener HTTP-Endpunkt, an den jeder Post per POST gesendet wird
+type WebhookTarget struct {
+	Name    string            `json:"name,omitempty"` // für Log und Nachweise, Standard ist der Host der URL
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// BodyTemplate ist eine text/template-Vorlage für den JSON-Body mit den Feldern von
+	// WebhookPayload, z.B. {"text": {{json .Text}}}. Leer wird WebhookPayload direkt gesendet.
+	BodyTemplate string `json:"body_template,omitempty"`
+	// Secret signiert den Body per HMAC-SHA256 im Header X-Signature ("sha256=<hex>")
+	Secret string `json:"secret,omitempty"`
+}
+
+// String gibt den Namen bzw. den Host des Webhooks zurück
+func (t WebhookTarget) String() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	if u, err := url.Parse(t.URL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return t.URL
+}
+
+// key ist der Schlüssel für Nachweise, Sperren und Zähler
+func (t WebhookTarget) key() string {
+	return "webhook@" + t.String()
+}
+
+// WebhookPayload sind die Daten eines Posts für Webhooks
+type WebhookPayload struct {
+	Title     string `json:"title"`
+	Text      string `json:"text"`
+	City      string `json:"city"`
+	URL       string `json:"url"`
+	Timestamp string `json:"timestamp"` // RFC 3339, UTC
+}
+
+func newWebhookPayload(title, text, city, pageURL string) WebhookPayload {
+	return WebhookPayload{
+		Title:     title,
+		Text:      text,
+		City:      city,
+		URL:       pageURL,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// render erzeugt den JSON-Body. Vorlagen können Werte mit {{json .Feld}} als JSON maskieren;
+// das Ergebnis muss gültiges JSON sein.
+func (t WebhookTarget) render(payload WebhookPayload) ([]byte, error) {
+	if t.BodyTemplate == "" {
+		return json.Marshal(payload)
+	}
+	tmpl, err := template.New("webhook").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(t.BodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("Fehler beim Parsen der Webhook-Vorlage: %v", err)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, payload); err != nil {
+		return nil, fmt.Errorf("Fehler beim Rendern der Webhook-Vorlage: %v", err)
+	}
+	if !json.Valid(b.Bytes()) {
+		return nil, fmt.Errorf("Webhook-Vorlage ergibt kein gültiges JSON")
+	}
+	return b.Bytes(), nil
+}
+
+// webhookSignature gibt die HMAC-SHA256-Signatur des Bodys für den Header X-Signature zurück
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookSend sendet den Post an den Webhook. Jede Antwort mit HTTP 2xx gilt als Erfolg;
+// enthält sie ein Feld id, wird es als Nachweis gespeichert.
+func webhookSend(ctx context.Context, target WebhookTarget, payload WebhookPayload) (RemotePost, error) {
+	body, err := target.render(payload)
+	if err != nil {
+		return RemotePost{}, err
+	}
+	req, err := newRequest(ctx, "POST", target.URL, bytes.NewReader(body))
+	if err != nil {
+		return RemotePost{}, fmt.Errorf("Ungültige Webhook-URL: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range target.Headers {
+		req.Header.Set(name, value)
+	}
+	if target.Secret != "" {
+		req.Header.Set("X-Signature", webhookSignature(target.Secret, body))
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// Die URL kann ein Token enthalten und darf nicht im Log landen
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return RemotePost{}, fmt.Errorf("Webhook-Aufruf fehlgeschlagen: %v", err)
+	}
+	defer closeBody(resp)
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return RemotePost{}, fmt.Errorf("Fehler beim Lesen der Webhook-Antwort: %v", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return RemotePost{}, &RateLimitError{Platform: "Webhook " + target.String(), RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), Body: string(respBody)}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return RemotePost{}, fmt.Errorf("Webhook HTTP %d - Antwort: %s", resp.StatusCode, string(respBody))
+	}
+	var result struct {
+		ID interface{} `json:"id"`
+	}
+	json.Unmarshal(respBody, &result)
+	var remote RemotePost
+	if result.ID != nil {
+		remote.ID = fmt.Sprint(result.ID)
+	}
+	return remote, nil
+}
+
+// ApprovalItem ist ein Link, der auf manuelle Freigabe wartet
+type ApprovalItem struct {
+	Href     string    `json:"href"`
+	URL      string    `json:"url"`
+	City     string    `json:"city"`
+	Title    string    `json:"title"`
+	Text     string    `json:"text"`
+	QueuedAt time.Time `json:"queued_at"`
+	Approved bool      `json:"approved"`
+	Rejected bool      `json:"rejected"`
+}
+
+// ApprovalQueue enthält alle Links, die auf Freigabe warten
+type ApprovalQueue struct {
+	Items []ApprovalItem `json:"items"`
+}
+
+// approvalQueueFile gibt den Pfad der Freigabe-Warteschlange neben der Datendatei zurück
+func approvalQueueFile(config Config) string {
+	return filepath.Join(filepath.Dir(config.DataFile), "approval_queue.json")
+}
+
+// loadApprovalQueue lädt die Freigabe-Warteschlange
+func loadApprovalQueue(filename string) (ApprovalQueue, error) {
+	var queue ApprovalQueue
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return queue, nil
+		}
+		return queue, fmt.Errorf("Fehler beim Lesen der Freigabe-Warteschlange: %v", err)
+	}
+	if err := json.Unmarshal(data, &queue); err != nil {
+		return queue, fmt.Errorf("Fehler beim Parsen der Freigabe-Warteschlange: %v", err)
+	}
+	return queue, nil
+}
+
+// saveApprovalQueue speichert die Freigabe-Warteschlange
+func saveApprovalQueue(queue ApprovalQueue, filename string) error {
+	data, err := json.MarshalIndent(queue, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Fehler beim Marshalling der Freigabe-Warteschlange: %v", err)
+	}
+	return writeFileAtomic(filename, data, 0644)
+}
+
+// find gibt den Eintrag zu einem Link zurück oder nil
+func (q *ApprovalQueue) find(href string) *ApprovalItem {
+	for i := range q.Items {
+		if q.Items[i].Href == href {
+			return &q.Items[i]
+		}
+	}
+	return nil
+}
+
+// remove entfernt einen Link aus der Warteschlange und meldet, ob er enthalten war
+func (q *ApprovalQueue) remove(href string) bool {
+	for i := range q.Items {
+		if q.Items[i].Href == href {
+			q.Items = append(q.Items[:i], q.Items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// queuedLinks gibt die Links der Quelle zurück, die in der Freigabe-Warteschlange stehen.
+// Die Warteschlange liegt neben der Datendatei und kann Links mehrerer Quellen enthalten.
+func queuedLinks(config Config) ([]string, error) {
+	approvalMu.Lock()
+	queue, err := loadApprovalQueue(approvalQueueFile(config))
+	approvalMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	var links []string
+	for _, item := range queue.Items {
+		if detailPageURL(config, item.Href) == item.URL {
+			links = append(links, item.Href)
+		}
+	}
+	return links, nil
+}
+
+// approvalMu schützt die Freigabe-Warteschlange vor gleichzeitigen Änderungen
+// durch checkWebsite und die HTTP-Endpunkte
+var approvalMu sync.Mutex
+
+// updateApprovalQueue lädt die Warteschlange, wendet fn an und speichert sie wieder
+func updateApprovalQueue(config Config, fn func(*ApprovalQueue) error) error {
+	approvalMu.Lock()
+	defer approvalMu.Unlock()
+
+	filename := approvalQueueFile(config)
+	queue, err := loadApprovalQueue(filename)
+	if err != nil {
+		return err
+	}
+	if err := fn(&queue); err != nil {
+		return err
+	}
+	return saveApprovalQueue(queue, filename)
+}
+
+// approvalHref wandelt eine vollständige URL oder einen Link in den gespeicherten Link um
+func approvalHref(config Config, ref string) string {
+	if base, err := linkBase(config.URL); err == nil {
+		if href, err := canonicalLink(base, ref); err == nil {
+			return href
+		}
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(ref, strings.TrimSuffix(config.URL, "/")), "/")
+}
+
+// errNotQueued wird zurückgegeben, wenn ein Link nicht in der Freigabe-Warteschlange steht
+var errNotQueued = errors.New("Link ist nicht in der Freigabe-Warteschlange")
+
+// approveLink gibt einen wartenden Link frei, damit er beim nächsten Durchlauf gepostet wird.
+// Ein bereits freigegebener Link bleibt freigegeben.
+func approveLink(config Config, ref string) error {
+	href := approvalHref(config, ref)
+	return updateApprovalQueue(config, func(q *ApprovalQueue) error {
+		item := q.find(href)
+		if item == nil {
+			return fmt.Errorf("%s: %w", ref, errNotQueued)
+		}
+		item.Approved = true
+		item.Rejected = false
+		return nil
+	})
+}
+
+// rejectLink verwirft einen wartenden Link. Beim nächsten Durchlauf wird er als gesehen
+// markiert und aus der Warteschlange entfernt, ohne gepostet zu werden.
+func rejectLink(config Config, ref string) error {
+	href := approvalHref(config, ref)
+	return updateApprovalQueue(config, func(q *ApprovalQueue) error {
+		item := q.find(href)
+		if item == nil {
+			return fmt.Errorf("%s: %w", ref, errNotQueued)
+		}
+		item.Approved = false
+		item.Rejected = true
+		return nil
+	})
+}
+
+// adminAuthorized prüft das Admin-Token aus dem Authorization-Header oder dem Parameter token
+func adminAuthorized(config Config, r *http.Request) bool {
+	if config.AdminToken == "" {
+		return false
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(config.AdminToken)) == 1
+}
+
+// queueHTML ist die Browser-Ansicht der Freigabe-Warteschlange
+var queueHTML = htmltemplate.Must(htmltemplate.New("queue").Parse(`<!DOCTYPE html>
+<html lang="de"><head><meta charset="utf-8"><title>Freigabe-Warteschlange</title></head>
+<body><h1>Freigabe-Warteschlange ({{len .Items}})</h1>
+{{range .Items}}<div>
+<h2>{{.City}}: {{.Title}}{{if .Approved}} (freigegeben){{end}}{{if .Rejected}} (verworfen){{end}}</h2>
+<p><a href="{{.URL}}">{{.URL}}</a></p>
+<pre>{{.Text}}</pre>
+<form method="post" action="approve?url={{.Href}}&amp;token={{$.Token}}"><button>Freigeben</button></form>
+<form method="post" action="reject?url={{.Href}}&amp;token={{$.Token}}"><button>Verwerfen</button></form>
+</div><hr>
+{{end}}</body></html>
+`))
+
+// registerApprovalHandlers hängt /queue, /approve und /reject an den Status-Server
+func registerApprovalHandlers(mux *http.ServeMux, config Config) {
+	mux.HandleFunc("/queue", func(w http.ResponseWriter, r *http.Request) {
+		if !adminAuthorized(config, r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		approvalMu.Lock()
+		queue, err := loadApprovalQueue(approvalQueueFile(config))
+		approvalMu.Unlock()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if strings.Contains(r.Header.Get("Accept"), "text/html") {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			queueHTML.Execute(w, struct {
+				Items []ApprovalItem
+				Token string
+			}{queue.Items, r.URL.Query().Get("token")})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(queue)
+	})
+
+	action := func(fn func(Config, string) error) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			if !adminAuthorized(config, r) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			ref := r.URL.Query().Get("url")
+			if ref == "" {
+				http.Error(w, "Parameter url fehlt", http.StatusBadRequest)
+				return
+			}
+			if err := fn(config, ref); err != nil {
+				status := http.StatusInternalServerError
+				if errors.Is(err, errNotQueued) {
+					status = http.StatusNotFound
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+			if strings.Contains(r.Header.Get("Accept"), "text/html") {
+				http.Redirect(w, r, "queue?token="+url.QueryEscape(r.URL.Query().Get("token")), http.StatusSeeOther)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true}`))
+		}
+	}
+	mux.HandleFunc("/approve", action(approveLink))
+	mux.HandleFunc("/reject", action(rejectLink))
+}
+
+// parseDayDuration parst eine Dauer wie time.ParseDuration, versteht aber zusätzlich
+// Tage als Präfix, z.B. "7d" oder "1d12h".
+func parseDayDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("leere Dauer")
+	}
+	var days time.Duration
+	if i := strings.Index(s, "d"); i >= 0 {
+		n, err := strconv.Atoi(s[:i])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("ungültige Dauer %q", s)
+		}
+		days = time.Duration(n) * 24 * time.Hour
+		s = s[i+1:]
+		if s == "" {
+			return days, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("ungültige Dauer %q: %v", s, err)
+	}
+	return days + d, nil
+}
+
+// filterLinksSince gibt die gespeicherten Links zurück, die innerhalb von since vor now
+// zum ersten Mal gesehen wurden. Bei since == 0 werden alle Links zurückgegeben.
+func filterLinksSince(data LinkData, since time.Duration, now time.Time) []LinkRecord {
+	if since <= 0 {
+		return data.Links
+	}
+	cutoff := now.Add(-since)
+	var links []LinkRecord
+	for _, record := range data.Links {
+		if !record.FirstSeen.IsZero() && !record.FirstSeen.Before(cutoff) {
+			links = append(links, record)
+		}
+	}
+	return links
+}
+
+// listLinks gibt die gespeicherten Links mit Stadt und Titel, die fehlgeschlagenen und
+// zurückgestellten Links sowie den Zeitpunkt des letzten Durchlaufs auf stdout aus
+func listLinks(w io.Writer, data LinkData, since time.Duration, now time.Time) {
+	if data.LastSeen.IsZero() {
+		fmt.Fprintln(w, "Letzter Durchlauf: noch keiner")
+	} else {
+		fmt.Fprintf(w, "Letzter Durchlauf: %s\n", data.LastSeen.Format(time.RFC3339))
+	}
+	links := filterLinksSince(data, since, now)
+	for _, record := range links {
+		first := ""
+		if !record.FirstSeen.IsZero() {
+			first = record.FirstSeen.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", first, formatReportLine(record))
+	}
+	if since <= 0 {
+		for _, link := range data.FailedLinks {
+			fmt.Fprintf(w, "\t%s (fehlgeschlagen, wird erneut versucht)\n", link)
+		}
+		for _, link := range data.PendingLinks {
+			fmt.Fprintf(w, "\t%s (zurückgestellt)\n", link)
+		}
+	}
+	fmt.Fprintf(w, "%d Links\n", len(links))
+}
+
+// exportLinksCSV schreibt die gespeicherten Links aller Quellen als CSV-Datei. Die URL
+// wird gegen die Übersichtsseite der jeweiligen Quelle aufgelöst.
+func exportLinksCSV(config Config, filename string, since time.Duration, now time.Time) error {
+	sources := sourceConfigs(config)
+	datas := make([]LinkData, len(sources))
+	for i, source := range sources {
+		data, err := loadSourceData(source)
+		if err != nil {
+			return fmt.Errorf("Fehler beim Laden der Link-Daten von %s: %v", source.URL, err)
+		}
+		datas[i] = data
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("Fehler beim Erstellen der CSV-Datei: %v", err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"href", "url", "first_seen"})
+	for i, source := range sources {
+		for _, record := range filterLinksSince(datas[i], since, now) {
+			first := ""
+			if !record.FirstSeen.IsZero() {
+				first = record.FirstSeen.Format(time.RFC3339)
+			}
+			w.Write([]string{record.Href, resolveLinkURL(source.URL, record.Href), first})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("Fehler beim Schreiben der CSV-Datei: %v", err)
+	}
+	return nil
+}
+
+// feedMaxItems begrenzt die Anzahl der Einträge im Feed
+const feedMaxItems = 100
+
+// rssFeed ist die Wurzel eines RSS-2.0-Dokuments
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// feedItem ist ein gespeicherter Link einer Quelle mit den Angaben für den Feed
+type feedItem struct {
+	item      rssItem
+	firstSeen time.Time
+}
+
+// buildFeed erzeugt einen RSS-2.0-Feed aus den gespeicherten Links aller Quellen,
+// die neuesten zuerst. Links ohne gespeicherten Titel erhalten den Verzeichnisnamen.
+func buildFeed(config Config, now time.Time) (rssFeed, error) {
+	var items []feedItem
+	for _, source := range sourceConfigs(config) {
+		data, err := loadSourceData(source)
+		if err != nil {
+			return rssFeed{}, err
+		}
+		for _, record := range data.Links {
+			link := record.Href
+			pageURL := detailPageURL(source, link)
+			city := record.City
+			if city == "" {
+				city = strings.Title(strings.Split(link, "/")[0])
+			}
+			title := city
+			if record.Title != "" {
+				title += ": " + record.Title
+			}
+			description := record.Text
+			if source.Disclaimer != "" {
+				// Wie in den Posts steht der Disclaimer unter dem Text
+				description += "\n\n" + source.Disclaimer
+			}
+			item := rssItem{
+				Title:       title,
+				Link:        pageURL,
+				Description: description,
+				GUID:        rssGUID{IsPermaLink: true, Value: pageURL},
+			}
+			if permalink := permalinkFor(source, link); permalink != "" {
+				item.GUID.Value = permalink
+			}
+			firstSeen := record.FirstSeen
+			if !firstSeen.IsZero() {
+				item.PubDate = firstSeen.Format(time.RFC1123Z)
+			}
+			items = append(items, feedItem{item: item, firstSeen: firstSeen})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].firstSeen.After(items[j].firstSeen)
+	})
+	if len(items) > feedMaxItems {
+		items = items[:feedMaxItems]
+	}
+
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         "Grundstücksverkäufe nach dem Grundstückverkehrsgesetz NRW",
+			Link:          config.URL,
+			Description:   "Neue Bekanntmachungen von Grundstücksverkäufen an Nicht-LandwirtInnen",
+			Language:      "de",
+			LastBuildDate: now.Format(time.RFC1123Z),
+		},
+	}
+	for _, item := range items {
+		feed.Channel.Items = append(feed.Channel.Items, item.item)
+	}
+	return feed, nil
+}
+
+// renderFeed erzeugt den RSS-Feed als XML-Dokument
+func renderFeed(config Config, now time.Time) ([]byte, error) {
+	feed, err := buildFeed(config, now)
+	if err != nil {
+		return nil, err
+	}
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("Fehler beim Erzeugen des Feeds: %v", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// writeFeed schreibt den RSS-Feed atomar nach filename
+func writeFeed(config Config, filename string) error {
+	data, err := renderFeed(config, time.Now())
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, data, 0644)
+}
+
+// archiveDir ist das Verzeichnis der archivierten Posts
+const archiveDir = "posts"
+
+// ArchivedPost ist ein im Verzeichnis posts/ archivierter Post
+type ArchivedPost struct {
+	Title     string `json:"title"`
+	Markdown  string `json:"markdown"`
+	URL       string `json:"url"`
+	Community string `json:"community"`
+	Timestamp string `json:"timestamp"`
+
+	// Details enthält die aus dem Text gelesenen Angaben zum Flurstück
+	Details ListingDetails `json:"details"`
+}
+
+// dumpDir gibt das Verzeichnis für die im Testmodus geplanten Posts zurück
+func dumpDir(config Config) string {
+	if config.DumpDir != "" {
+		return config.DumpDir
+	}
+	return archiveDir
+}
+
+// unsafeFilenameChars sind Zeichen, die in Dateinamen von archivierten Posts ersetzt werden
+var unsafeFilenameChars = regexp.MustCompile(`[^\pL\d._-]+`)
+
+// postFilename erzeugt aus einem Titel einen gültigen Dateinamen ohne Endung
+func postFilename(title string, now time.Time) string {
+	name := strings.Trim(unsafeFilenameChars.ReplaceAllString(title, "_"), "._")
+	if utf8.RuneCountInString(name) > 100 {
+		name = string([]rune(name)[:100])
+	}
+	if name == "" {
+		name = "post"
+	}
+	return fmt.Sprintf("%s_%d", name, now.UnixNano())
+}
+
+// savePostAsJSON archiviert einen Post als JSON-Datei in dir, mit CompressArchive als .json.gz.
+// Gemarkung, Flur, Flurstück, Fläche und Preis werden zusätzlich strukturiert abgelegt.
+func savePostAsJSON(config Config, dir, title, markdown, url, community string) error {
+	post := ArchivedPost{
+		Title:     title,
+		Markdown:  markdown,
+		URL:       url,
+		Community: community,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Details:   parseListingDetails(markdown),
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	filename := filepath.Join(dir, postFilename(title, time.Now())+".json")
+	data, err := json.MarshalIndent(post, "", "  ")
+	if err != nil {
+		return err
+	}
+	if config.CompressArchive {
+		return writeGzipFile(filename+".gz", data)
+	}
+	return writeFileAtomic(filename, data, 0644)
+}
+
+// writeGzipFile schreibt data gzip-komprimiert nach filename
+func writeGzipFile(filename string, data []byte) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, buf.Bytes(), 0644)
+}
+
+// loadArchivedPost liest einen archivierten Post, komprimiert (.json.gz) oder unkomprimiert (.json)
+func loadArchivedPost(filename string) (ArchivedPost, error) {
+	var post ArchivedPost
+	file, err := os.Open(filename)
+	if err != nil {
+		return post, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(filename, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return post, fmt.Errorf("Fehler beim Entpacken von %s: %v", filename, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	if err := json.NewDecoder(r).Decode(&post); err != nil {
+		return post, fmt.Errorf("Fehler beim Parsen von %s: %v", filename, err)
+	}
+	return post, nil
+}
+
+// compactArchive komprimiert alle unkomprimierten Posts in dir. Mit tarMonths > 0 werden
+// komprimierte Posts, die älter als so viele Monate sind, zusätzlich in ein Tar-Archiv
+// pro Monat (<dir>/<JJJJ-MM>.tar) gepackt und einzeln gelöscht.
+func compactArchive(dir string, tarMonths int, now time.Time) error {
+	plain, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, filename := range plain {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return fmt.Errorf("Fehler beim Lesen von %s: %v", filename, err)
+		}
+		if err := writeGzipFile(filename+".gz", data); err != nil {
+			return fmt.Errorf("Fehler beim Komprimieren von %s: %v", filename, err)
+		}
+		// Original erst löschen, wenn die komprimierte Datei lesbar ist
+		if _, err := loadArchivedPost(filename + ".gz"); err != nil {
+			return err
+		}
+		if err := os.Remove(filename); err != nil {
+			return err
+		}
+	}
+	log.Printf("🗜️  %d archivierte Posts komprimiert", len(plain))
+
+	if tarMonths <= 0 {
+		return nil
+	}
+
+	cutoff := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -tarMonths, 0)
+	compressed, err := filepath.Glob(filepath.Join(dir, "*.json.gz"))
+	if err != nil {
+		return err
+	}
+	months := map[string][]string{}
+	for _, filename := range compressed {
+		postTime, err := archivedPostTime(filename)
+		if err != nil {
+			slog.Warn("Archivierter Post wird übersprungen", "file", filename, "error", err)
+			continue
+		}
+		if postTime.Before(cutoff) {
+			month := postTime.Format("2006-01")
+			months[month] = append(months[month], filename)
+		}
+	}
+	for month, files := range months {
+		tarFile := filepath.Join(dir, month+".tar")
+		if err := appendToTar(tarFile, files); err != nil {
+			return fmt.Errorf("Fehler beim Schreiben von %s: %v", tarFile, err)
+		}
+		for _, filename := range files {
+			if err := os.Remove(filename); err != nil {
+				return err
+			}
+		}
+		log.Printf("📦 %d Posts aus %s nach %s gepackt", len(files), month, tarFile)
+	}
+	return nil
+}
+
+// archivedPostTime gibt den Zeitstempel eines archivierten Posts zurück, ersatzweise die Änderungszeit der Datei
+func archivedPostTime(filename string) (time.Time, error) {
+	post, err := loadArchivedPost(filename)
+	if err == nil {
+		if t, err := time.Parse(time.RFC3339, post.Timestamp); err == nil {
+			return t, nil
+		}
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// appendToTar schreibt files in das Tar-Archiv tarFile. Ein vorhandenes Archiv wird
+// mitsamt seinem bisherigen Inhalt neu geschrieben.
+func appendToTar(tarFile string, files []string) error {
+	tmpFile := tarFile + ".tmp"
+	out, err := os.Create(tmpFile)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile)
+	tw := tar.NewWriter(out)
+
+	if existing, err := os.Open(tarFile); err == nil {
+		tr := tar.NewReader(existing)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				existing.Close()
+				out.Close()
+				return err
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				existing.Close()
+				out.Close()
+				return err
+			}
+			if _, err := io.Copy(tw, tr); err != nil {
+				existing.Close()
+				out.Close()
+				return err
+			}
+		}
+		existing.Close()
+	}
+
+	for _, filename := range files {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			out.Close()
+			return err
+		}
+		info, err := os.Stat(filename)
+		if err != nil {
+			out.Close()
+			return err
+		}
+		hdr := &tar.Header{
+			Name:    filepath.Base(filename),
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: info.ModTime(),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			out.Close()
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			out.Close()
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, tarFile)
+}
+
+func printMastodonAuthURL(config Config) {
+	if config.MastodonServer == "" || config.MastodonClientID == "" {
+		fmt.Println("mastodon_server und mastodon_client_id müssen in der Konfiguration gesetzt sein.")
+		return
+	}
+	url := fmt.Sprintf("%soauth/authorize?client_id=%s&redirect_uri=urn:ietf:wg:oauth:2.0:oob&response_type=code&scope=write", config.MastodonServer, config.MastodonClientID)
+	fmt.Println("Öffne folgende URL im Browser, um den Authorization Code zu erhalten:")
+	fmt.Println(url)
+}
+
+func obtainMastodonTokenInteractive(config *Config) error {
+	if config.MastodonServer == "" || config.MastodonClientID == "" || config.MastodonClientSecret == "" {
+		return fmt.Errorf("mastodon_server, mastodon_client_id und mastodon_client_secret müssen gesetzt sein")
+	}
+	redirectURI := "urn:ietf:wg:oauth:2.0:oob"
+	scope := "write"
+
+	authURL := fmt.Sprintf("%soauth/authorize?client_id=%s&redirect_uri=%s&response_type=code&scope=%s", config.MastodonServer, config.MastodonClientID, redirectURI, scope)
+	fmt.Println("Bitte öffne folgende URL im Browser, logge dich ein und erlaube den Zugriff:")
+	fmt.Println(authURL)
+	fmt.Print("Gib den angezeigten Code ein: ")
+	reader := bufio.NewReader(os.Stdin)
+	code, _ := reader.ReadString('\n')
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return fmt.Errorf("Kein Code eingegeben")
+	}
+
+	// Tausche Code gegen Access Token
+	payload := map[string]string{
+		"redirect_uri": redirectURI,
+		"client_id": config.MastodonClientID,
+		"client_secret": config.MastodonClientSecret,
+		"grant_type": "authorization_code",
+		"code": code,
+	}
+	data, _ := json.Marshal(payload)
+	resp, err := httpPost(context.Background(), config.MastodonServer+"oauth/token", "application/json", strings.NewReader(string(data)))
+	if err != nil {
+		return fmt.Errorf("Fehler beim Token-Austausch: %v", err)
+	}
+	defer closeBody(resp)
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("Token-Austausch fehlgeschlagen: %s", string(body))
+	}
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return fmt.Errorf("Fehler beim Parsen der Token-Antwort: %v", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return fmt.Errorf("Kein Access Token erhalten")
+	}
+	config.MastodonAccessToken = tokenResp.AccessToken
+	config.MastodonTokenExp = time.Time{}
+	if tokenResp.ExpiresIn > 0 {
+		config.MastodonTokenExp = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	}
+	fmt.Println("Access Token erfolgreich erhalten und gespeichert.")
+	return saveConfig(*config, config.configFile)
+}
+
+// runSnapshot führt einen isolierten Testlauf durch und speichert bzw. vergleicht dessen geplante Posts
+// Unterschiede zu compareFile werden nach w geschrieben und als Fehler zurückgegeben, damit
+// der Aufruf mit Exit-Code 1 endet.
+func runSnapshot(w io.Writer, config Config, snapshotFile, compareFile string) error {
+	isolated, cleanup, err := isolateDataFile(config)
+	if err != nil {
+		return fmt.Errorf("Fehler beim Anlegen der temporären Datendatei: %v", err)
+	}
+	defer cleanup()
+
+	result, err := checkWebsite(context.Background(), isolated, true)
+	if err != nil {
+		return fmt.Errorf("Fehler bei der Website-Überprüfung: %v", err)
+	}
+
+	if snapshotFile != "" {
+		if err := saveSnapshot(result.WouldPost, snapshotFile); err != nil {
+			return err
+		}
+		log.Printf("Snapshot mit %d Posts gespeichert: %s", len(result.WouldPost), snapshotFile)
+	}
+	if compareFile != "" {
+		old, err := loadSnapshot(compareFile)
+		if err != nil {
+			return err
+		}
+		diffs := compareSnapshots(old, result.WouldPost)
+		if len(diffs) == 0 {
+			fmt.Fprintln(w, "Keine Unterschiede zum Snapshot")
+			return nil
+		}
+		fmt.Fprintf(w, "%d Unterschiede zum Snapshot %s:\n", len(diffs), compareFile)
+		for _, d := range diffs {
+			fmt.Fprintln(w, d)
+		}
+		return fmt.Errorf("%d Unterschiede zum Snapshot %s", len(diffs), compareFile)
+	}
+	return nil
+}
+
+// subcommand ist ein Unterbefehl der Kommandozeile
+type subcommand struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+// subcommands enthält alle Unterbefehle in der Reihenfolge der Hilfe
+var subcommands []subcommand
+
+func init() {
+	subcommands = []subcommand{
+		{"run", "Check the website once, or continuously with --loop", cmdRun},
+		{"check", "Check the website once, with dry-run snapshots or --replay-failed", cmdCheck},
+		{"list", "Print all stored links", cmdList},
+		{"forget", "Remove a stored link (href or URL) so the next run posts it again; -all clears all links", cmdForget},
+		{"export", "Export stored links as CSV to the given file", cmdExport},
+		{"feed", "Write an RSS 2.0 feed of the stored links to the given file", cmdFeed},
+		{"approve", "Approve a queued link (href or URL) so it is posted on the next run", cmdApprove},
+		{"reject", "Reject a queued link (href or URL) so it is never posted", cmdReject},
+		{"stats", "Print lifetime post counters per platform", cmdStats},
+		{"prune", "Remove stale entries from the link data and compact the post archive", cmdPrune},
+		{"validate", "Check the configuration and the link data for problems", cmdValidate},
+		{"migrate-to-sqlite", "Copy the JSON link data of all sources into a SQLite database", cmdMigrateToSQLite},
+		{"doctor", "Diagnose the setup (config, data dir, sources, robots.txt, platform auth, clock) without posting", cmdDoctor},
+		{"mastodon-auth", "Obtain a Mastodon access token via the OAuth2 flow", cmdMastodonAuth},
+	}
+}
+
+// findSubcommand sucht einen Unterbefehl anhand seines Namens
+func findSubcommand(name string) (subcommand, bool) {
+	for _, cmd := range subcommands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return subcommand{}, false
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", filepath.Base(os.Args[0]))
+	for _, cmd := range subcommands {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nWithout a command, run is assumed. Use <command> -h for the flags of a command and -version for the build.\n")
+}
+
+// Build-Informationen, beim Bauen gesetzt mit
+// -ldflags "-X main.version=1.2.0 -X main.commit=$(git rev-parse --short HEAD) -X main.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var version, commit, date string
+
+// versionString beschreibt den laufenden Build. Ohne -ldflags werden Commit und Zeitpunkt
+// aus den VCS-Angaben übernommen, die go build im Git-Checkout einbettet.
+func versionString() string {
+	v, c, d := version, commit, date
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			v = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && c == "":
+				c = setting.Value
+				if len(c) > 12 {
+					c = c[:12]
+				}
+			case setting.Key == "vcs.time" && d == "":
+				d = setting.Value
+			}
+		}
+	}
+	if v == "" {
+		v = "dev"
+	}
+	if c == "" {
+		c = "unknown"
+	}
+	if d == "" {
+		d = "unknown"
+	}
+	return fmt.Sprintf("%s (commit %s, built %s)", v, c, d)
+}
+
+// configFile ist der Pfad der Konfigurationsdatei, änderbar mit -config
+var configFile = "config.json"
+
+// databaseFile überschreibt database aus der Konfiguration, gesetzt mit -db
+var databaseFile string
+
+// newFlagSet erstellt das FlagSet eines Unterbefehls mit dem gemeinsamen Flag -config
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&configFile, "config", configFile, "Path to the configuration file; new tokens are saved back to it")
+	fs.StringVar(&databaseFile, "db", databaseFile, "Store the link data in this SQLite database instead of data_file (overrides database); an existing data_file is imported on first use")
+	fs.Func("log-format", "Log output format: text (default) or json", func(v string) error {
+		logFormat = v
+		return configureLogging()
+	})
+	fs.Func("log-level", "Minimum log level: debug, info (default), warn or error", func(v string) error {
+		logLevel = v
+		return configureLogging()
+	})
+	fs.BoolFunc("quiet", "Only log warnings, errors and the final run summary (same as -log-level warn), e.g. for cron", func(string) error {
+		logLevel = "warn"
+		return configureLogging()
+	})
+	return fs
+}
+
+// Log-Einstellungen, gesetzt über -log-format, -log-level und -quiet
+var (
+	logFormat           = "text"
+	logLevel            = "info"
+	logOutput io.Writer = os.Stderr
+)
+
+// logSummary protokolliert die Zusammenfassung eines Durchlaufs als INFO, unabhängig vom
+// eingestellten Log-Level, damit sie auch mit -quiet in der Cron-Ausgabe erscheint
+func logSummary(summary string) {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, summary, 0)
+	slog.Default().Handler().Handle(context.Background(), r)
+}
+
+// configureLogging richtet slog als Standard-Logger ein. Auch log.Printf läuft danach
+// über den Handler und wird als INFO protokolliert.
+func configureLogging() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(logLevel)); err != nil {
+		return fmt.Errorf("ungültiges Log-Level %q (erlaubt: debug, info, warn, error)", logLevel)
+	}
+	var handler slog.Handler
+	switch logFormat {
+	case "json":
+		handler = slog.NewJSONHandler(logOutput, &slog.HandlerOptions{Level: level})
+	case "text", "":
+		handler = &textLogHandler{out: logOutput, level: level, mu: &sync.Mutex{}}
+	default:
+		return fmt.Errorf("ungültiges Log-Format %q (erlaubt: text, json)", logFormat)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// textLogHandler schreibt lesbare Zeilen im Stil des log-Pakets: Zeitstempel, Nachricht und
+// Attribute als key=value. Die Stufe wird nur angegeben, wenn sie nicht INFO ist.
+type textLogHandler struct {
+	out   io.Writer
+	level slog.Level
+	attrs []slog.Attr
+	mu    *sync.Mutex
+}
+
+func (h *textLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *textLogHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Time.Format("2006/01/02 15:04:05 "))
+	if r.Level != slog.LevelInfo {
+		b.WriteString(r.Level.String())
+		b.WriteString(" ")
+	}
+	b.WriteString(r.Message)
+	writeAttr := func(a slog.Attr) bool {
+		if a.Equal(slog.Attr{}) {
+			return true
+		}
+		value := a.Value.Resolve().String()
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, " %s=%s", a.Key, value)
+		return true
+	}
+	for _, a := range h.attrs {
+		writeAttr(a)
+	}
+	r.Attrs(writeAttr)
+	b.WriteString("\n")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.out, b.String())
+	return err
+}
+
+func (h *textLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup wird nicht benötigt, Gruppen werden flach ausgegeben
+func (h *textLogHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// dryRunFlag registriert das gemeinsame Flag -test mit dem Alias -dry-run
+func dryRunFlag(fs *flag.FlagSet) *bool {
+	testMode := fs.Bool("test", false, "Run in test mode - don't post to Lemmy, just show what would be posted")
+	fs.BoolVar(testMode, "dry-run", false, "Alias for -test")
+	return testMode
+}
+
+// sinceFlag registriert das Flag -since für list und export
+func sinceFlag(fs *flag.FlagSet) *string {
+	return fs.String("since", "", "Only list/export links first seen within this duration (e.g. 7d, 12h)")
+}
+
+// parseSince wertet den Wert von -since aus (leer = unbegrenzt)
+func parseSince(since string) (time.Duration, error) {
+	if since == "" {
+		return 0, nil
+	}
+	d, err := parseDayDuration(since)
+	if err != nil {
+		return 0, fmt.Errorf("Ungültiger Wert für -since: %v", err)
+	}
+	return d, nil
+}
+
+// loadCommandConfig lädt die Konfiguration für einen Unterbefehl aus der mit -config gewählten Datei
+func loadCommandConfig() (Config, error) {
+	config, err := loadConfig(configFile)
+	if err != nil {
+		return config, fmt.Errorf("Fehler beim Laden der Konfiguration: %v", err)
+	}
+	if databaseFile != "" {
+		config.Database = databaseFile
+	}
+	return config, nil
+}
+
+// ensureMastodonToken führt den Mastodon-OAuth2-Flow automatisch durch, wenn kein Token
+// vorhanden ist, aber Server und ClientID/Secret gesetzt sind
+func ensureMastodonToken(config *Config) error {
+	if config.MastodonServer != "" && config.MastodonClientID != "" && config.MastodonClientSecret != "" && config.MastodonAccessToken == "" && !mastodonCanLogin(*config) {
+		if err := obtainMastodonTokenInteractive(config); err != nil {
+			return fmt.Errorf("Fehler beim Mastodon-OAuth2-Flow: %v", err)
+		}
+	}
+	return nil
+}
+
+// postTargets gibt die konfigurierten Ziele als Host bzw. Host/Community zurück
+func postTargets(config Config) []string {
+	var targets []string
+	for _, target := range lemmyTargets(config) {
+		targets = append(targets, target.String())
+	}
+	if mastodonConfigured(config) {
+		if u, err := url.Parse(config.MastodonServer); err == nil {
+			targets = append(targets, u.Host)
+		}
+	}
+	if blueskyConfigured(config) {
+		targets = append(targets, config.BlueskyHandle)
+	}
+	if telegramConfigured(config) {
+		targets = append(targets, "t.me/"+strings.TrimPrefix(config.TelegramChatID, "@"))
+	}
+	if discordConfigured(config) {
+		targets = append(targets, "discord")
+	}
+	for _, target := range config.Webhooks {
+		targets = append(targets, target.String())
+	}
+	return targets
+}
+
+// platformKeys gibt die Schlüssel aller konfigurierten Plattformen zurück, wie sie in
+// PostedTo, Nachweisen und Zählern verwendet werden
+func platformKeys(config Config) []string {
+	var keys []string
+	for _, target := range lemmyTargets(config) {
+		keys = append(keys, target.key())
+	}
+	if mastodonConfigured(config) {
+		keys = append(keys, "mastodon")
+	}
+	if blueskyConfigured(config) {
+		keys = append(keys, "bluesky")
+	}
+	if telegramConfigured(config) {
+		keys = append(keys, "telegram")
+	}
+	if discordConfigured(config) {
+		keys = append(keys, "discord")
+	}
+	for _, target := range config.Webhooks {
+		keys = append(keys, target.key())
+	}
+	return keys
+}
+
+// checkProdConfirmation verhindert versehentliches Posten auf Produktivziele
+func checkProdConfirmation(config Config, testMode, confirmed bool) error {
+	if !config.RequireConfirmProd || testMode || confirmed {
+		return nil
+	}
+	for _, target := range postTargets(config) {
+		host, _, _ := strings.Cut(target, "/")
+		for _, prod := range config.ProductionTargets {
+			if strings.EqualFold(prod, target) || strings.EqualFold(prod, host) {
+				return fmt.Errorf("%s ist als Produktivziel eingetragen (production_targets). Zum Posten -yes-post-to-prod angeben oder mit -test prüfen", target)
+			}
+		}
+	}
+	return nil
+}
+
+// feedFlag registriert das Flag -feed für run und check
+func feedFlag(fs *flag.FlagSet) *string {
+	return fs.String("feed", "", "Write an RSS 2.0 feed of the stored links to this file after each check (overrides feed_file)")
+}
+
+// dumpDirFlag registriert das Flag -dump-dir für run und check
+func dumpDirFlag(fs *flag.FlagSet) *string {
+	return fs.String("dump-dir", "", "With -test: write every post that would be made as JSON into this directory (overrides dump_dir, default posts)")
+}
+
+// urlFlag registriert das Flag -url für run und check
+func urlFlag(fs *flag.FlagSet) *string {
+	return fs.String("url", "", "Fetch and post only this detail page, then exit (bypasses the index scan and the list of seen links)")
+}
+
+// runSingleURL postet eine einzelne Detailseite und beendet sich danach
+func runSingleURL(config Config, pageURL string, testMode bool) error {
+	if _, err := postSingleURL(context.Background(), config, pageURL, testMode); err != nil {
+		return fmt.Errorf("Fehler beim Posten von %s: %v", pageURL, err)
+	}
+	return nil
+}
+
+// prodFlag registriert das Flag -yes-post-to-prod
+func prodFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("yes-post-to-prod", false, "Confirm posting to a target listed in production_targets when require_confirm_prod is set")
+}
+
+func cmdRun(args []string) error {
+	fs := newFlagSet("run")
+	loopMode := fs.Bool("loop", false, "Run in continuous monitoring mode")
+	testMode := dryRunFlag(fs)
+	statusAddr := fs.String("status-addr", "", "Serve /status on this address in loop mode (e.g. :8080)")
+	metricsAddr := fs.String("metrics-addr", "", "Serve Prometheus metrics on /metrics at this address in loop mode (e.g. :9090)")
+	confirmProd := prodFlag(fs)
+	singleURL := urlFlag(fs)
+	feedFile := feedFlag(fs)
+	dumpDirPath := dumpDirFlag(fs)
+	reportOnly, verbose := reportOnlyFlags(fs)
+	fs.Parse(args)
+
+	config, err := loadCommandConfig()
+	if err != nil {
+		return err
+	}
+	if *reportOnly {
+		return reportChanges(context.Background(), config, *verbose, os.Stdout)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if *feedFile != "" {
+		config.FeedFile = *feedFile
+	}
+	if *dumpDirPath != "" {
+		config.DumpDir = *dumpDirPath
+	}
+	if err := checkProdConfirmation(config, *testMode, *confirmProd); err != nil {
+		return err
+	}
+	if err := ensureMastodonToken(&config); err != nil {
+		return err
+	}
+
+	if *singleURL != "" {
+		return runSingleURL(config, *singleURL, *testMode)
+	}
+	if !*loopMode {
+		return runCheckOnce(config, *testMode)
+	}
+
+	// Kontinuierliche Überwachung
+	slog.Info("Starte kontinuierliche Überwachung", "version", versionString())
+
+	// Kontext für graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if *statusAddr != "" {
+		if data, err := loadSourceData(config); err == nil {
+			statusCache.Update(data, CheckResult{}, data.LastSeen)
+		}
+		statusCache.UpdateFeed(config, time.Now())
+		if config.StatusBaseURL != "" {
+			statusCache.UpdateItems(config)
+		}
+		startStatusServer(ctx, *statusAddr, config)
+	}
+	if *metricsAddr != "" {
+		startMetricsServer(ctx, *metricsAddr, config)
+	}
+
+	// Signal-Handler für graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go cancelOnSignal(sigChan, cancel)
+
+	// Überwachung starten
+	if err := runMonitoring(ctx, config, *testMode); err != nil {
+		return fmt.Errorf("Fehler in der Überwachung: %v", err)
+	}
+	return nil
+}
+
+// cancelOnSignal bricht den Kontext der Überwachung ab, sobald sigChan ein Signal empfängt
+func cancelOnSignal(sigChan <-chan os.Signal, cancel context.CancelFunc) {
+	<-sigChan
+	log.Println("Shutdown-Signal empfangen...")
+	cancel()
+}
+
+// reportOnlyFlags registriert -report-only und -verbose für run und check
+func reportOnlyFlags(fs *flag.FlagSet) (reportOnly, verbose *bool) {
+	reportOnly = fs.Bool("report-only", false, "Only print new and removed links; no platform login, no posting, no changes to the link data or config")
+	verbose = fs.Bool("verbose", false, "With -report-only: also fetch detail pages to show title and city of new links")
+	return reportOnly, verbose
+}
+
+// reportChanges prüft alle Quellen und gibt neue und entfernte Links aus, ohne sich bei
+// einer Plattform anzumelden, zu posten oder Link-Daten und Konfiguration zu verändern.
+// Detailseiten neuer Links werden nur mit verbose abgerufen.
+func reportChanges(ctx context.Context, config Config, verbose bool, out io.Writer) error {
+	robotsCache.Reset()
+	for _, source := range sourceConfigs(config) {
+		savedData, err := loadSourceData(source)
+		if err != nil {
+			return err
+		}
+		if !robotsAllowedURL(ctx, source.URL) {
+			return fmt.Errorf("robots.txt verbietet den Abruf von %s", source.URL)
+		}
+		htmlContent, err := fetchSourceURL(ctx, source, source.URL)
+		if err != nil {
+			return err
+		}
+		currentLinks, err := extractAllLinks(ctx, source, htmlContent)
+		if err != nil {
+			return err
+		}
+		newLinks := findNewLinks(currentLinks, savedData.Links, append(savedData.FailedLinks, savedData.PendingLinks...))
+		removedLinks := findRemovedLinks(currentLinks, savedData.Links)
+
+		fmt.Fprintf(out, "%s: %d Links, %d neu, %d entfernt\n", source.URL, len(currentLinks), len(newLinks), len(removedLinks))
+		for _, link := range newLinks {
+			record := LinkRecord{Href: link}
+			if verbose {
+				record = reportDetails(ctx, source, link)
+			}
+			fmt.Fprintf(out, "  + %s\n", formatReportLine(record))
+		}
+		for _, link := range removedLinks {
+			record := LinkRecord{Href: link}
+			if saved := savedData.record(link); saved != nil {
+				record = *saved
+			}
+			fmt.Fprintf(out, "  - %s\n", formatReportLine(record))
+		}
+	}
+	return nil
+}
+
+// reportDetails ruft die Detailseite eines neuen Links ab und ermittelt Titel und Stadt.
+// Fehler werden protokolliert, der Link wird dann ohne Details ausgegeben.
+func reportDetails(ctx context.Context, config Config, link string) LinkRecord {
+	record := LinkRecord{Href: link}
+	pageURL := detailPageURL(config, link)
+	if !robotsAllowedURL(ctx, pageURL) {
+		return record
+	}
+	pageContent, err := fetchSourceURL(ctx, config, pageURL)
+	if err != nil {
+		slog.Warn("Fehler beim Abrufen der Detailseite", "link", link, "url", pageURL, "error", err)
+		return record
+	}
+	if title, _, err := extractListingText(pageContent, config); err == nil {
+		record.Title = normalizeTitle(title, config.TitleNormalization)
+	}
+	record.City = extractCityName(pageContent)
+	return record
+}
+
+// formatReportLine gibt einen Link mit Stadt und Titel aus, soweit bekannt
+func formatReportLine(record LinkRecord) string {
+	var details []string
+	for _, s := range []string{record.City, record.Title} {
+		if s != "" {
+			details = append(details, s)
+		}
+	}
+	if len(details) == 0 {
+		return record.Href
+	}
+	return fmt.Sprintf("%s (%s)", record.Href, strings.Join(details, ": "))
+}
+
+// runCheckOnce führt eine einmalige Überprüfung durch
+func runCheckOnce(config Config, testMode bool) error {
+	log.Printf("Führe einmalige Überprüfung durch...")
+	if _, err := checkWebsite(context.Background(), config, testMode); err != nil {
+		return fmt.Errorf("Fehler bei der Website-Überprüfung: %v", err)
+	}
+	log.Printf("Überprüfung abgeschlossen.")
+	return nil
+}
+
+func cmdCheck(args []string) error {
+	fs := newFlagSet("check")
+	testMode := dryRunFlag(fs)
+	snapshot := fs.String("snapshot", "", "With -dry-run: write the would-post links and titles to this file")
+	compareSnapshot := fs.String("compare-snapshot", "", "With -dry-run: compare the would-post list against this snapshot and report differences")
+	replayFailedMode := fs.Bool("replay-failed", false, "Retry posting all failed links immediately without re-scanning the index")
+	backfill := fs.String("backfill", "", "Post stored links whose posted_to lacks this platform (e.g. bluesky, lemmy@host/community) to that platform only")
+	backfillLimit := fs.Int("limit", 0, "With -backfill: post at most this many links, oldest first (0 = all)")
+	backfillDelay := fs.Duration("backfill-delay", 30*time.Second, "With -backfill: pause between two posts")
+	confirmProd := prodFlag(fs)
+	singleURL := urlFlag(fs)
+	feedFile := feedFlag(fs)
+	dumpDirPath := dumpDirFlag(fs)
+	reportOnly, verbose := reportOnlyFlags(fs)
+	fs.Parse(args)
+
+	config, err := loadCommandConfig()
+	if err != nil {
+		return err
+	}
+	if *reportOnly {
+		return reportChanges(context.Background(), config, *verbose, os.Stdout)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if *feedFile != "" {
+		config.FeedFile = *feedFile
+	}
+	if *dumpDirPath != "" {
+		config.DumpDir = *dumpDirPath
+	}
+	if err := checkProdConfirmation(config, *testMode, *confirmProd); err != nil {
+		return err
+	}
+
+	if *snapshot != "" || *compareSnapshot != "" {
+		if !*testMode {
+			return fmt.Errorf("-snapshot und -compare-snapshot erfordern -dry-run")
+		}
+		return runSnapshot(os.Stdout, config, *snapshot, *compareSnapshot)
+	}
+
+	if err := ensureMastodonToken(&config); err != nil {
+		return err
+	}
+	if *singleURL != "" {
+		return runSingleURL(config, *singleURL, *testMode)
+	}
+	if *replayFailedMode {
+		if _, err := replayFailed(context.Background(), config, *testMode); err != nil {
+			return fmt.Errorf("Fehler beim erneuten Versuch fehlgeschlagener Links: %v", err)
+		}
+		return nil
+	}
+	if *backfill != "" {
+		if _, err := backfillPlatform(context.Background(), config, *backfill, *backfillLimit, *backfillDelay, *testMode); err != nil {
+			return fmt.Errorf("Fehler beim Nachholen für %s: %v", *backfill, err)
+		}
+		return nil
+	}
+	return runCheckOnce(config, *testMode)
+}
+
+func cmdList(args []string) error {
+	fs := newFlagSet("list")
+	since := sinceFlag(fs)
+	fs.Parse(args)
+
+	sinceDur, err := parseSince(*since)
+	if err != nil {
+		return err
+	}
+	config, err := loadCommandConfig()
+	if err != nil {
+		return err
+	}
+	return listSources(os.Stdout, config, sinceDur, time.Now())
+}
+
+// listSources gibt die gespeicherten Links aller Quellen aus. Bei mehreren Quellen
+// steht vor den Links jeder Quelle ihre URL.
+func listSources(w io.Writer, config Config, since time.Duration, now time.Time) error {
+	sources := sourceConfigs(config)
+	for i, source := range sources {
+		data, err := loadSourceData(source)
+		if err != nil {
+			return fmt.Errorf("Fehler beim Laden der Link-Daten von %s: %v", source.URL, err)
+		}
+		if len(sources) > 1 {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "Quelle: %s\n", source.URL)
+		}
+		listLinks(w, data, since, now)
+	}
+	return nil
+}
+
+func cmdForget(args []string) error {
+	fs := newFlagSet("forget")
+	all := fs.Bool("all", false, "Clear all stored links so the next run re-scans the index; platforms with an existing post receipt are skipped")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s forget [flags] <href or URL>\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *all != (fs.NArg() == 0) || fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("forget erwartet genau einen Link oder -all")
+	}
+
+	config, err := loadCommandConfig()
+	if err != nil {
+		return err
+	}
+	if *all {
+		return forgetAllLinks(config)
+	}
+	return forgetLink(config, fs.Arg(0))
+}
+
+// forgetLink entfernt einen Link aus den Link-Daten aller Quellen und hebt seine
+// Post-Nachweise auf, damit er beim nächsten Durchlauf erneut gepostet wird
+func forgetLink(config Config, ref string) error {
+	found := false
+	for _, source := range sourceConfigs(config) {
+		href := approvalHref(source, ref)
+		store, err := openLinkStore(source)
+		if err != nil {
+			return err
+		}
+		removed := false
+		err = updateLinkStore(store, func(data *LinkData) { removed = data.forget(href) })
+		store.Close()
+		if err != nil {
+			return fmt.Errorf("Fehler beim Speichern der Link-Daten: %v", err)
+		}
+		if !removed {
+			continue
+		}
+		found = true
+		if err := appendReceipt(source, PostReceipt{Link: href, Forgotten: true, Timestamp: time.Now()}); err != nil {
+			return fmt.Errorf("Post-Nachweise konnten nicht aufgehoben werden: %v", err)
+		}
+		slog.Info("🗑️  Link entfernt, wird beim nächsten Durchlauf erneut gepostet", "link", href, "source", source.URL)
+	}
+	if !found {
+		slog.Warn("Link ist nicht gespeichert", "link", ref)
+	}
+	return nil
+}
+
+// forgetAllLinks leert die gesehenen Links aller Quellen. Die Post-Nachweise bleiben
+// erhalten, damit bei der Neuauswertung nichts doppelt gepostet wird.
+func forgetAllLinks(config Config) error {
+	for _, source := range sourceConfigs(config) {
+		store, err := openLinkStore(source)
+		if err != nil {
+			return err
+		}
+		removed := 0
+		err = updateLinkStore(store, func(data *LinkData) { removed = data.forgetAll() })
+		store.Close()
+		if err != nil {
+			return fmt.Errorf("Fehler beim Speichern der Link-Daten: %v", err)
+		}
+		slog.Info("🗑️  Links entfernt", "links", removed, "source", source.URL)
+	}
+	return nil
+}
+
+func cmdExport(args []string) error {
+	fs := newFlagSet("export")
+	since := sinceFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s export [flags] <file.csv>\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("export erwartet genau eine Zieldatei")
+	}
+
+	sinceDur, err := parseSince(*since)
+	if err != nil {
+		return err
+	}
+	config, err := loadCommandConfig()
+	if err != nil {
+		return err
+	}
+	if err := exportLinksCSV(config, fs.Arg(0), sinceDur, time.Now()); err != nil {
+		return err
+	}
+	log.Printf("CSV exportiert nach %s", fs.Arg(0))
+	return nil
+}
+
+func cmdFeed(args []string) error {
+	fs := newFlagSet("feed")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s feed [flags] <feed.xml>\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("feed erwartet genau eine Zieldatei")
+	}
+
+	config, err := loadCommandConfig()
+	if err != nil {
+		return err
+	}
+	if err := writeFeed(config, fs.Arg(0)); err != nil {
+		return fmt.Errorf("Fehler beim Schreiben des Feeds: %v", err)
+	}
+	log.Printf("Feed geschrieben nach %s", fs.Arg(0))
+	return nil
+}
+
+// queueLinkArg liest den Link-Parameter von approve und reject
+func queueLinkArg(name string, args []string) (string, error) {
+	fs := newFlagSet(name)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s <href-or-url>\n", filepath.Base(os.Args[0]), name)
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return "", fmt.Errorf("%s erwartet genau einen Link", name)
+	}
+	return fs.Arg(0), nil
+}
+
+func cmdApprove(args []string) error {
+	ref, err := queueLinkArg("approve", args)
+	if err != nil {
+		return err
+	}
+	config, err := loadCommandConfig()
+	if err != nil {
+		return err
+	}
+	if err := approveLink(config, ref); err != nil {
+		return fmt.Errorf("Fehler bei der Freigabe: %v", err)
+	}
+	log.Printf("✅ %s freigegeben, wird beim nächsten Durchlauf gepostet", ref)
+	return nil
+}
+
+func cmdReject(args []string) error {
+	ref, err := queueLinkArg("reject", args)
+	if err != nil {
+		return err
+	}
+	config, err := loadCommandConfig()
+	if err != nil {
+		return err
+	}
+	if err := rejectLink(config, ref); err != nil {
+		return fmt.Errorf("Fehler beim Verwerfen: %v", err)
+	}
+	log.Printf("🗑️  %s verworfen", ref)
+	return nil
+}
+
+// pruneLinkData entfernt veraltete Einträge aus den Link-Daten: entfernte Links, die
+// länger als olderThan verschwunden sind, abgelaufene Rate-Limit-Sperren und Angaben
+// zu Links, die nicht mehr gespeichert sind. Zurückgegeben wird die Anzahl der Einträge.
+func pruneLinkData(data *LinkData, olderThan time.Duration, now time.Time) int {
+	pruned := 0
+	for link, removedAt := range data.RemovedLinks {
+		if now.Sub(removedAt) > olderThan {
+			delete(data.RemovedLinks, link)
+			pruned++
+		}
+	}
+	for platform, until := range data.PostNotBefore {
+		if !now.Before(until) {
+			delete(data.PostNotBefore, platform)
+			pruned++
+		}
+	}
+
+	stored := make(map[string]bool, len(data.Links))
+	for _, link := range data.hrefs() {
+		stored[link] = true
+	}
+	for link := range data.Deadlines {
+		if !stored[link] {
+			delete(data.Deadlines, link)
+			pruned++
+		}
+	}
+	for link := range data.RemindersSent {
+		if !stored[link] {
+			delete(data.RemindersSent, link)
+			pruned++
+		}
+	}
+	for link := range data.ContentHashes {
+		if !stored[link] {
+			delete(data.ContentHashes, link)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// pruneSources bereinigt die Link-Daten aller Quellen mit pruneLinkData und gibt die Anzahl
+// der entfernten Einträge zurück. Im Testmodus wird nur gezählt.
+func pruneSources(config Config, olderThan time.Duration, testMode bool, now time.Time) (int, error) {
+	total := 0
+	for _, source := range sourceConfigs(config) {
+		data, err := loadSourceData(source)
+		if err != nil {
+			return total, fmt.Errorf("Fehler beim Laden der Link-Daten von %s: %v", source.URL, err)
+		}
+		pruned := pruneLinkData(&data, olderThan, now)
+		total += pruned
+		if testMode || pruned == 0 {
+			continue
+		}
+		if err := saveSourceData(source, data); err != nil {
+			return total, fmt.Errorf("Fehler beim Speichern der Link-Daten von %s: %v", source.URL, err)
+		}
+	}
+	return total, nil
+}
+
+func cmdPrune(args []string) error {
+	fs := newFlagSet("prune")
+	testMode := dryRunFlag(fs)
+	olderThan := fs.String("older-than", "90d", "Forget removed links that disappeared longer ago than this duration")
+	archive := fs.Bool("archive", false, "Also compress uncompressed archived posts in posts/")
+	tarMonths := fs.Int("tar-months", 0, "With -archive: pack compressed posts older than this many months into one tar per month")
+	fs.Parse(args)
+
+	olderThanDur, err := parseDayDuration(*olderThan)
+	if err != nil {
+		return fmt.Errorf("Ungültiger Wert für -older-than: %v", err)
+	}
+	config, err := loadCommandConfig()
+	if err != nil {
+		return err
+	}
+	pruned, err := pruneSources(config, olderThanDur, *testMode, time.Now())
+	if err != nil {
+		return err
+	}
+	if *testMode {
+		log.Printf("🧪 TEST: %d veraltete Einträge würden entfernt werden", pruned)
+		return nil
+	}
+	log.Printf("🧹 %d veraltete Einträge entfernt", pruned)
+
+	if *archive {
+		if err := compactArchive(archiveDir, *tarMonths, time.Now()); err != nil {
+			return fmt.Errorf("Fehler beim Komprimieren des Archivs: %v", err)
+		}
+	}
+	return nil
+}
+
+// validateConfig prüft die Konfiguration und gibt alle gefundenen Probleme zurück
+func validateConfig(config Config) []string {
+	var problems []string
+	if config.URL == "" {
+		problems = append(problems, "url ist nicht gesetzt")
+	} else if u, err := url.Parse(config.URL); err != nil || u.Scheme == "" || u.Host == "" {
+		problems = append(problems, fmt.Sprintf("url ist keine gültige URL: %s", config.URL))
+	}
+	if config.CheckInterval <= 0 {
+		problems = append(problems, fmt.Sprintf("check_interval muss positiv sein (%v)", config.CheckInterval))
+	} else if config.CheckInterval < time.Minute {
+		problems = append(problems, fmt.Sprintf("check_interval ist kürzer als eine Minute (%v); der Wert wird in Nanosekunden angegeben", config.CheckInterval))
+	}
+	if config.NextPageXPath != "" {
+		if _, err := htmlquery.Query(&html.Node{Type: html.DocumentNode}, config.NextPageXPath); err != nil {
+			problems = append(problems, fmt.Sprintf("next_page_xpath ist ungültig: %v", err))
+		}
+		if config.MaxPages < 1 {
+			problems = append(problems, fmt.Sprintf("max_pages muss mindestens 1 sein (%d)", config.MaxPages))
+		}
+	}
+	if config.CheckInterval > 0 && config.CheckJitter >= config.CheckInterval {
+		problems = append(problems, fmt.Sprintf("check_jitter (%v) muss kleiner als check_interval (%v) sein", config.CheckJitter, config.CheckInterval))
+	}
+	if config.HTTPTimeout > 0 && config.HTTPTimeout < time.Second {
+		problems = append(problems, fmt.Sprintf("http_timeout ist kürzer als eine Sekunde (%v); der Wert wird in Nanosekunden angegeben", config.HTTPTimeout))
+	}
+	if config.DataFile == "" {
+		problems = append(problems, "data_file ist nicht gesetzt")
+	}
+	if !anyPlatformConfigured(config) {
+		problems = append(problems, "Weder Lemmy noch Mastodon noch Bluesky noch Telegram noch Discord noch ein Webhook sind vollständig konfiguriert")
+	}
+	for _, dir := range config.WatchDirs {
+		if containsFold(config.IgnoreDirs, dir) {
+			problems = append(problems, fmt.Sprintf("Verzeichnis %s steht in watch_dirs und ignore_dirs und wird daher nie überwacht", dir))
+		}
+	}
+	if config.StaticMapTemplate != "" {
+		if !strings.Contains(config.StaticMapTemplate, "{lat}") || !strings.Contains(config.StaticMapTemplate, "{lon}") {
+			problems = append(problems, "static_map_template muss die Platzhalter {lat} und {lon} enthalten")
+		}
+		if !strings.Contains(config.GeocodeURL, "{query}") {
+			problems = append(problems, "geocode_url muss den Platzhalter {query} enthalten")
+		}
+	}
+	for i, source := range config.Sources {
+		if source.URL != "" && !validHTTPURL(source.URL) {
+			problems = append(problems, fmt.Sprintf("sources[%d]: url ist keine gültige URL: %s", i, source.URL))
+		}
+	}
+	for _, target := range lemmyTargets(config) {
+		if !validHTTPURL(target.Server) {
+			problems = append(problems, fmt.Sprintf("Lemmy-Server ist keine gültige URL: %s", target.Server))
+		}
+	}
+	if config.MastodonServer != "" && !validHTTPURL(config.MastodonServer) {
+		problems = append(problems, fmt.Sprintf("mastodon_server ist keine gültige URL: %s", config.MastodonServer))
+	}
+	if !knownVisibility(config.MastodonVisibility) {
+		problems = append(problems, fmt.Sprintf("Unbekannte mastodon_visibility %q (erlaubt: public, unlisted, private, direct)", config.MastodonVisibility))
+	}
+	if blueskyConfigured(config) && !validHTTPURL(config.BlueskyPDS) {
+		problems = append(problems, fmt.Sprintf("bluesky_pds ist keine gültige URL: %s", config.BlueskyPDS))
+	}
+	if config.MastodonImages && config.MastodonMaxImageBytes <= 0 {
+		problems = append(problems, fmt.Sprintf("mastodon_max_image_bytes muss positiv sein (%d)", config.MastodonMaxImageBytes))
+	}
+	if config.DiscordWebhookURL != "" && !validHTTPURL(config.DiscordWebhookURL) {
+		problems = append(problems, "discord_webhook_url ist keine gültige URL")
+	}
+	webhookKeys := make(map[string]bool)
+	for i, target := range config.Webhooks {
+		if !validHTTPURL(target.URL) {
+			problems = append(problems, fmt.Sprintf("webhooks[%d]: url ist keine gültige URL", i))
+			continue
+		}
+		if webhookKeys[target.key()] {
+			problems = append(problems, fmt.Sprintf("webhooks[%d]: %s ist doppelt, bitte mit name unterscheiden", i, target))
+		}
+		webhookKeys[target.key()] = true
+		if _, err := target.render(newWebhookPayload("Titel", "Text", "Stadt", "https://example.org/")); err != nil {
+			problems = append(problems, fmt.Sprintf("webhooks[%d]: %v", i, err))
+		}
+	}
+	if config.StatusBaseURL != "" && !validHTTPURL(config.StatusBaseURL) {
+		problems = append(problems, fmt.Sprintf("status_base_url ist keine gültige URL: %s", config.StatusBaseURL))
+	}
+	for _, target := range lemmyTargets(config) {
+		if target.Password == "CHANGEME" {
+			problems = append(problems, fmt.Sprintf("Das Passwort für Lemmy %s ist noch der Platzhalter CHANGEME", target))
+		}
+	}
+	for i, target := range config.LemmyTargets {
+		if !target.configured() {
+			problems = append(problems, fmt.Sprintf("lemmy_targets[%d]: server, community, username und password müssen gesetzt sein", i))
+		}
+	}
+	sample := newPostData("Musterstadt", "Titel", "Text", config.URL, "musterstadt/index.htm")
+	if _, _, err := renderPost(config, sample); err != nil {
+		problems = append(problems, err.Error())
+	}
+	for _, platform := range slices.Sorted(maps.Keys(config.PlatformTemplates)) {
+		if !slices.Contains(templatePlatforms, platform) {
+			problems = append(problems, fmt.Sprintf("platform_templates: unbekannte Plattform %s (erlaubt: %s)", platform, strings.Join(templatePlatforms, ", ")))
+		} else if _, _, err := renderPost(withPlatformTemplates(config, platform), sample); err != nil {
+			problems = append(problems, fmt.Sprintf("platform_templates.%s: %v", platform, err))
+		}
+	}
+	return problems
+}
+
+// Validate prüft die Konfiguration wie validateConfig und fasst alle Probleme zu einem Fehler zusammen
+func (c Config) Validate() error {
+	problems := validateConfig(c)
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("Konfiguration ungültig (%d Probleme):\n  - %s", len(problems), strings.Join(problems, "\n  - "))
+}
+
+// validHTTPURL meldet, ob s eine absolute http(s)-URL ist
+func validHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// knownVisibility meldet, ob eine Mastodon-Sichtbarkeit von mindestens einer Server-Variante
+// unterstützt wird. Leer bedeutet die Standard-Sichtbarkeit des Kontos.
+func knownVisibility(visibility string) bool {
+	if visibility == "" {
+		return true
+	}
+	for _, flavor := range mastodonFlavors {
+		if flavor.visibilities[visibility] {
+			return true
+		}
+	}
+	return false
+}
+
+// validateLinkData prüft, ob die Datendatei gelesen werden kann, ohne sie zu verändern
+func validateLinkData(filename string) error {
+	raw, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Fehler beim Lesen von %s: %v", filename, err)
+	}
+	var data LinkData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("%s ist beschädigt: %v", filename, err)
+	}
+	return nil
+}
+
+func cmdStats(args []string) error {
+	fs := newFlagSet("stats")
+	fs.Parse(args)
+
+	config, err := loadCommandConfig()
+	if err != nil {
+		return err
+	}
+	var combined LinkData
+	for _, source := range sourceConfigs(config) {
+		data, err := loadSourceData(source)
+		if err != nil {
+			return fmt.Errorf("Fehler beim Laden der Link-Daten: %v", err)
+		}
+		combined.Links = append(combined.Links, data.Links...)
+		mergePostCounts(&combined, data)
+	}
+
+	fmt.Printf("Gespeicherte Links: %d\n", len(combined.Links))
+	if len(combined.PostCounts) == 0 {
+		fmt.Println("Noch keine Posts gezählt")
+		return nil
+	}
+	fmt.Printf("Posts seit %s:\n", combined.CountingSince.Format("02.01.2006"))
+	for _, platform := range sortedPlatforms(combined.PostCounts) {
+		fmt.Printf("  %-10s %s\n", platform, formatGermanNumber(float64(combined.PostCounts[platform]), 0))
+	}
+	return nil
+}
+
+func cmdMigrateToSQLite(args []string) error {
+	fs := newFlagSet("migrate-to-sqlite")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s migrate-to-sqlite [flags] [links.db]\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("migrate-to-sqlite erwartet höchstens eine Datenbank")
+	}
+
+	config, err := loadCommandConfig()
+	if err != nil {
+		return err
+	}
+	target := config.Database
+	if fs.NArg() == 1 {
+		target = fs.Arg(0)
+	}
+	if target == "" {
+		fs.Usage()
+		return fmt.Errorf("Keine Datenbank angegeben (Argument, -db oder database)")
+	}
+	db, err := openSQLiteDB(target)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	results, err := migrateToSQLite(db, config)
+	if err != nil {
+		return fmt.Errorf("Fehler bei der Migration nach %s: %v", target, err)
+	}
+	for _, r := range results {
+		fmt.Printf("%s: %d Links, %d fehlgeschlagen, %d zurückgestellt\n", r.DataFile, r.Links, r.Failed, r.Pending)
+	}
+	fmt.Printf("Link-Daten von %d Quelle(n) nach %s übernommen. Die JSON-Dateien bleiben erhalten; mit \"database\": %q in der Konfiguration wird künftig die Datenbank verwendet.\n", len(results), target, target)
+	return nil
+}
+
+func cmdValidate(args []string) error {
+	fs := newFlagSet("validate")
+	fs.Parse(args)
+
+	config, err := loadCommandConfig()
+	if err != nil {
+		return err
+	}
+	problems := validateConfig(config)
+	if err := validateLinkData(config.DataFile); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) == 0 {
+		fmt.Println("✅ Konfiguration ist gültig")
+		return nil
+	}
+	for _, p := range problems {
+		fmt.Printf("❌ %s\n", p)
+	}
+	return fmt.Errorf("Konfiguration ungültig (%d Probleme)", len(problems))
+}
+
+// Ergebnisse einer Diagnose-Prüfung
+const (
+	doctorPass = "pass"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+)
+
+// doctorCheck ist das Ergebnis einer einzelnen Diagnose-Prüfung
+type doctorCheck struct {
+	Name    string
+	Status  string
+	Message string
+	Hint    string // Hinweis zur Behebung bei warn und fail
+}
+
+// maxClockSkew ist die Abweichung zur Serverzeit, ab der doctor warnt
+const maxClockSkew = time.Minute
+
+// doctorTimeout begrenzt die Dauer einzelner Netzwerkprüfungen von doctor
+const doctorTimeout = 15 * time.Second
+
+// runDoctor führt alle Diagnose-Prüfungen durch. Es wird nichts gepostet.
+func runDoctor(config Config, now func() time.Time) []doctorCheck {
+	var checks []doctorCheck
+
+	if problems := validateConfig(config); len(problems) > 0 {
+		checks = append(checks, doctorCheck{"Konfiguration", doctorFail, strings.Join(problems, "; "), "config.json korrigieren und mit validate erneut prüfen"})
+	} else {
+		checks = append(checks, doctorCheck{"Konfiguration", doctorPass, "gültig", ""})
 	}
 
-	if len(newLinks) == 0 && len(removedLinks) == 0 {
-		log.Printf("Keine Änderungen gefunden")
+	for _, source := range sourceConfigs(config) {
+		checks = append(checks, doctorDataDir(source.DataFile))
+		checks = append(checks, doctorSource(source, now)...)
 	}
 
-	// Aktuelle Links speichern (nur erfolgreich gepostete Links bleiben in der Liste)
-	// Entfernte Links werden automatisch entfernt, da sie nicht mehr in currentLinks sind
-	savedData.LastSeen = time.Now()
+	return append(checks, doctorPlatforms(config)...)
+}
 
-	err = saveLinkData(savedData, config.DataFile)
-	if err != nil {
-		return fmt.Errorf("Fehler beim Speichern der Link-Daten: %v", err)
+// doctorPlatforms meldet sich bei allen konfigurierten Plattformen an, ohne die Website
+// abzurufen oder zu posten
+func doctorPlatforms(config Config) []doctorCheck {
+	var checks []doctorCheck
+	if !anyPlatformConfigured(config) {
+		return []doctorCheck{{"Plattformen", doctorFail, "keine Plattform konfiguriert", "Zugangsdaten für mindestens eine Plattform in config.json eintragen"}}
+	}
+	for _, target := range lemmyTargets(config) {
+		checks = append(checks, doctorLemmy(target))
+	}
+	if mastodonConfigured(config) {
+		checks = append(checks, doctorMastodon(config))
+	}
+	if blueskyConfigured(config) {
+		checks = append(checks, doctorBluesky(config))
+	}
+	if telegramConfigured(config) {
+		checks = append(checks, doctorTelegram(config))
+	}
+	if discordConfigured(config) {
+		checks = append(checks, doctorDiscord(config))
 	}
+	return checks
+}
 
-	// Konfiguration mit Token speichern
-	err = saveConfig(config, "config.json")
+// doctorDataDir prüft, ob im Verzeichnis der Datendatei geschrieben werden kann
+func doctorDataDir(dataFile string) doctorCheck {
+	name := "Datenverzeichnis " + filepath.Dir(dataFile)
+	file, err := os.CreateTemp(filepath.Dir(dataFile), ".doctor-")
 	if err != nil {
-		log.Printf("Warnung: Konfiguration konnte nicht gespeichert werden: %v", err)
+		return doctorCheck{name, doctorFail, err.Error(), "Verzeichnis anlegen bzw. Schreibrechte für den Service-Benutzer setzen"}
 	}
-
-	return nil
+	file.Close()
+	os.Remove(file.Name())
+	return doctorCheck{name, doctorPass, "beschreibbar", ""}
 }
 
-// runMonitoring startet die kontinuierliche Überwachung
-func runMonitoring(ctx context.Context, config Config, testMode bool) error {
-	log.Printf("Starte Überwachung der Website: %s", config.URL)
-	log.Printf("Überprüfungsintervall: %v", config.CheckInterval)
-	log.Printf("Datendatei: %s", config.DataFile)
-
-	// Erste Überprüfung sofort durchführen
-	err := checkWebsite(config, testMode)
+// doctorSource prüft Erreichbarkeit, robots.txt und Uhrzeit gegen den Date-Header der Quelle
+func doctorSource(source Config, now func() time.Time) []doctorCheck {
+	sourceURL := source.URL
+	name := "Quelle " + sourceURL
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	req, err := newRequest(ctx, "GET", sourceURL, nil)
+	if err != nil {
+		return []doctorCheck{{name, doctorFail, err.Error(), "url in config.json prüfen"}}
+	}
+	for header, value := range sourceHeaders(source, sourceURL) {
+		req.Header.Set(header, value)
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		log.Printf("Fehler bei der ersten Überprüfung: %v", err)
+		return []doctorCheck{{name, doctorFail, err.Error(), "URL und Netzwerkverbindung (Proxy, DNS, Firewall) prüfen"}}
+	}
+	closeBody(resp)
+	localTime := now()
+
+	var checks []doctorCheck
+	if resp.StatusCode != http.StatusOK {
+		checks = append(checks, doctorCheck{name, doctorFail, fmt.Sprintf("HTTP %d", resp.StatusCode), "url in config.json prüfen"})
+	} else {
+		checks = append(checks, doctorCheck{name, doctorPass, "erreichbar (HTTP 200)", ""})
 	}
 
-	// Timer für regelmäßige Überprüfungen
-	ticker := time.NewTicker(config.CheckInterval)
-	defer ticker.Stop()
+	checks = append(checks, doctorRobots(ctx, sourceURL))
 
-	for {
-		select {
-		case <-ctx.Done():
-			log.Println("Überwachung beendet")
-			return nil
-		case <-ticker.C:
-			err := checkWebsite(config, testMode)
-			if err != nil {
-				log.Printf("Fehler bei der Website-Überprüfung: %v", err)
-			}
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err != nil {
+		checks = append(checks, doctorCheck{"Uhrzeit", doctorWarn, "Server liefert keinen Date-Header", "Systemzeit manuell prüfen (z.B. timedatectl)"})
+	} else {
+		skew := localTime.Sub(date)
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > maxClockSkew {
+			checks = append(checks, doctorCheck{"Uhrzeit", doctorWarn, fmt.Sprintf("weicht um %v von %s ab", skew.Round(time.Second), resp.Request.URL.Host), "Zeitsynchronisation aktivieren (z.B. systemd-timesyncd)"})
+		} else {
+			checks = append(checks, doctorCheck{"Uhrzeit", doctorPass, fmt.Sprintf("Abweichung %v", skew.Round(time.Second)), ""})
 		}
 	}
+	return checks
 }
 
-func lemmyLogin(serverURL, username, password string) (string, error) {
-	loginUrl := serverURL + "/api/v3/user/login"
-	payload := map[string]string{
-		"username_or_email": username,
-		"password":          password,
+// doctorRobots prüft, ob robots.txt der Quelle den Abruf des Pfads für unseren User-Agent verbietet
+func doctorRobots(ctx context.Context, sourceURL string) doctorCheck {
+	u, err := url.Parse(sourceURL)
+	if err != nil {
+		return doctorCheck{"robots.txt", doctorWarn, err.Error(), ""}
 	}
-	data, _ := json.Marshal(payload)
-	resp, err := http.Post(loginUrl, "application/json", strings.NewReader(string(data)))
+	robotsURL := u.Scheme + "://" + u.Host + "/robots.txt"
+	req, err := newRequest(ctx, "GET", robotsURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("Lemmy-Login fehlgeschlagen: %v", err)
+		return doctorCheck{"robots.txt", doctorWarn, err.Error(), ""}
 	}
-	defer resp.Body.Close()
-
-	// Komplette Antwort lesen
-	body, err := io.ReadAll(resp.Body)
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("Fehler beim Lesen der Login-Antwort: %v", err)
+		return doctorCheck{"robots.txt", doctorWarn, err.Error(), "robots.txt konnte nicht geprüft werden"}
+	}
+	defer closeBody(resp)
+	if resp.StatusCode != http.StatusOK {
+		return doctorCheck{"robots.txt", doctorPass, fmt.Sprintf("nicht vorhanden (HTTP %d)", resp.StatusCode), ""}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 512<<10))
+	if err != nil {
+		return doctorCheck{"robots.txt", doctorWarn, err.Error(), ""}
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
 	}
+	if rule, disallowed := robotsDisallows(string(body), path, userAgent); disallowed {
+		return doctorCheck{"robots.txt", doctorWarn, fmt.Sprintf("Disallow: %s betrifft %s", rule, path), "Betreiber der Website um Erlaubnis fragen oder check_interval großzügig wählen"}
+	}
+	return doctorCheck{"robots.txt", doctorPass, "Abruf erlaubt", ""}
+}
 
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("Lemmy-Login HTTP %d - Antwort: %s", resp.StatusCode, string(body))
+// robotsGroup ist eine Gruppe von robots.txt-Regeln für einen oder mehrere User-Agents
+type robotsGroup struct {
+	agents   []string
+	disallow []string
+}
+
+// parseRobots zerlegt robots.txt in Gruppen. Aufeinanderfolgende User-agent-Zeilen bilden
+// eine Gruppe, andere Felder als Disallow werden ignoriert.
+func parseRobots(robots string) []robotsGroup {
+	var groups []robotsGroup
+	inAgents := false
+	for _, line := range strings.Split(robots, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				groups = append(groups, robotsGroup{})
+			}
+			inAgents = true
+			groups[len(groups)-1].agents = append(groups[len(groups)-1].agents, strings.ToLower(value))
+		case "disallow":
+			inAgents = false
+			if len(groups) > 0 && value != "" {
+				groups[len(groups)-1].disallow = append(groups[len(groups)-1].disallow, value)
+			}
+		default:
+			inAgents = false
+		}
 	}
+	return groups
+}
 
-	var loginResp LemmyLoginResponse
-	if err := json.Unmarshal(body, &loginResp); err != nil {
-		return "", fmt.Errorf("Lemmy-Login JSON-Fehler: %v - Antwort: %s", err, string(body))
+// robotsDisallows gibt die Disallow-Regel zurück, die path für userAgent verbietet. Es gelten
+// die Gruppen, die den Produktnamen von userAgent (z.B. "gvgbot") nennen; gibt es keine,
+// gelten die Gruppen für "*".
+func robotsDisallows(robots, path, userAgent string) (string, bool) {
+	product := strings.ToLower(userAgent)
+	if i := strings.IndexAny(product, "/ "); i >= 0 {
+		product = product[:i]
 	}
-	return loginResp.Jwt, nil
+	var specific, wildcard []string
+	for _, group := range parseRobots(robots) {
+		for _, agent := range group.agents {
+			if agent == "*" {
+				wildcard = append(wildcard, group.disallow...)
+			} else if product != "" && strings.HasPrefix(product, agent) {
+				specific = append(specific, group.disallow...)
+			}
+		}
+	}
+	rules := wildcard
+	if specific != nil {
+		rules = specific
+	}
+	for _, rule := range rules {
+		if strings.HasPrefix(path, rule) {
+			return rule, true
+		}
+	}
+	return "", false
 }
 
-// Hilfsfunktion, um Community-ID anhand des Namens zu holen
-func lemmyGetCommunityID(serverURL, jwt, communityName string) (int, error) {
-	url := serverURL + "/api/v3/community?name=" + communityName
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return 0, err
+// robotsRules speichert robots.txt pro Host für die Dauer eines Durchlaufs
+type robotsRules struct {
+	mu    sync.Mutex
+	files map[string]string
+}
+
+// robotsCache wird zu Beginn jedes Durchlaufs von checkWebsite geleert
+var robotsCache = &robotsRules{files: map[string]string{}}
+
+// Reset verwirft alle gespeicherten robots.txt-Dateien
+func (r *robotsRules) Reset() {
+	r.mu.Lock()
+	r.files = map[string]string{}
+	r.mu.Unlock()
+}
+
+// get liefert die Datei robotsURL aus dem Cache oder ruft sie ab. Fehlt die Datei
+// oder kann sie nicht gelesen werden, wird "" gespeichert, also alles erlaubt.
+// Wird ctx abgebrochen, bricht auch der Abruf ab.
+func (r *robotsRules) get(ctx context.Context, robotsURL string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if robots, ok := r.files[robotsURL]; ok {
+		return robots
 	}
-	req.Header.Set("Authorization", "Bearer "+jwt)
-	resp, err := client.Do(req)
+	robots := ""
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	ctx, err := waitForSource(ctx)
 	if err != nil {
-		return 0, err
+		r.files[robotsURL] = robots
+		return robots
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return 0, fmt.Errorf("Community-GET HTTP %d", resp.StatusCode)
+	if req, err := newRequest(ctx, "GET", robotsURL, nil); err == nil {
+		if resp, err := httpClient.Do(req); err != nil {
+			slog.Warn("robots.txt konnte nicht abgerufen werden, Abruf gilt als erlaubt", "url", robotsURL, "error", err)
+		} else {
+			if resp.StatusCode == http.StatusOK {
+				body, err := io.ReadAll(io.LimitReader(resp.Body, 512<<10))
+				if err == nil && utf8.Valid(body) {
+					robots = string(body)
+				}
+			}
+			closeBody(resp)
+		}
 	}
-	var respData struct {
-		CommunityView struct {
-			Community struct {
-				Id int `json:"id"`
-			} `json:"community"`
-		} `json:"community_view"`
+	r.files[robotsURL] = robots
+	return robots
+}
+
+// robotsAllowed prüft anhand der robots.txt von baseURL, ob userAgent path abrufen darf.
+// Fehlt robots.txt oder ist sie nicht lesbar, ist der Abruf erlaubt.
+func robotsAllowed(ctx context.Context, baseURL, path, userAgent string) bool {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return true
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&respData); err != nil {
-		return 0, err
+	if path == "" {
+		path = "/"
 	}
-	return respData.CommunityView.Community.Id, nil
+	robots := robotsCache.get(ctx, u.Scheme+"://"+u.Host+"/robots.txt")
+	_, disallowed := robotsDisallows(robots, path, userAgent)
+	return !disallowed
 }
 
-// Passe lemmyCreatePost an, damit sie community_id verwendet
-func lemmyCreatePost(serverURL, jwt string, communityID int, title, body, url string) error {
-	postUrl := serverURL + "/api/v3/post"
-	payload := map[string]interface{}{
-		"name":         title,
-		"body":         body,
-		"url":          url,
-		"community_id": communityID,
-	}
-	data, _ := json.Marshal(payload)
-	client := &http.Client{}
-	req, err := http.NewRequest("POST", postUrl, strings.NewReader(string(data)))
+// robotsAllowedURL wendet robotsAllowed auf eine vollständige URL an
+func robotsAllowedURL(ctx context.Context, pageURL string) bool {
+	u, err := url.Parse(pageURL)
 	if err != nil {
-		return err
+		return true
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+jwt)
-	resp, err := client.Do(req)
+	return robotsAllowed(ctx, pageURL, u.EscapedPath(), userAgent)
+}
+
+// doctorLemmy prüft Login und Community eines Lemmy-Ziels, ohne zu posten
+func doctorLemmy(target LemmyTarget) doctorCheck {
+	name := "Lemmy " + target.String()
+	jwt, err := lemmyLogin(target.Server, target.Username, target.Password)
 	if err != nil {
-		return err
+		return doctorCheck{name, doctorFail, err.Error(), "Server, Benutzername und Passwort prüfen"}
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Post-Erstellung HTTP %d - Antwort: %s", resp.StatusCode, string(body))
+	communityID, err := lemmyGetCommunityID(target.Server, jwt, target.Community)
+	if err != nil {
+		return doctorCheck{name, doctorFail, err.Error(), "Community prüfen (Name ohne !, z.B. kulturlandschaft)"}
 	}
-	log.Printf("Post-Erstellung %s HTTP %d - Antwort: %s", payload, resp.StatusCode, string(body))
-	return nil
+	return doctorCheck{name, doctorPass, fmt.Sprintf("angemeldet, Community %s (ID %d)", target.Community, communityID), ""}
 }
 
-// mastodonLogin holt ein Access Token per OAuth2 Password Grant
-func mastodonLogin(server, clientID, clientSecret, username, password string) (string, time.Time, error) {
-	tokenURL := server + "/oauth/token"
-	payload := url.Values{}
-	payload.Set("grant_type", "password")
-	payload.Set("client_id", clientID)
-	payload.Set("client_secret", clientSecret)
-	payload.Set("username", username)
-	payload.Set("password", password)
-	payload.Set("scope", "read write")
-
-	resp, err := http.PostForm(tokenURL, payload)
+// doctorMastodon prüft das Mastodon-Token über verify_credentials, ohne zu posten
+func doctorMastodon(config Config) doctorCheck {
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	token, err := mastodonAuthenticate(&config)
 	if err != nil {
-		return "", time.Time{}, fmt.Errorf("Mastodon-Login fehlgeschlagen: %v", err)
+		return doctorCheck{"Mastodon", doctorFail, err.Error(), "mastodon_access_token oder Username/Passwort/ClientID/Secret prüfen"}
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	req, err := newRequest(ctx, "GET", strings.TrimSuffix(config.MastodonServer, "/")+"/api/v1/accounts/verify_credentials", nil)
 	if err != nil {
-		return "", time.Time{}, fmt.Errorf("Fehler beim Lesen der Mastodon-Login-Antwort: %v", err)
+		return doctorCheck{"Mastodon", doctorFail, err.Error(), "mastodon_server prüfen"}
 	}
-	if resp.StatusCode != 200 {
-		return "", time.Time{}, fmt.Errorf("Mastodon-Login HTTP %d - Antwort: %s", resp.StatusCode, string(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return doctorCheck{"Mastodon", doctorFail, err.Error(), "mastodon_server und Netzwerkverbindung prüfen"}
 	}
-	var tokenResp struct {
-		AccessToken string `json:"access_token"`
-		ExpiresIn   int    `json:"expires_in"`
+	defer closeBody(resp)
+	if resp.StatusCode != http.StatusOK {
+		return doctorCheck{"Mastodon", doctorFail, fmt.Sprintf("verify_credentials HTTP %d", resp.StatusCode), "neues Token mit mastodon-auth holen oder mastodon_access_token prüfen"}
 	}
-	if err := json.Unmarshal(body, &tokenResp); err != nil {
-		return "", time.Time{}, fmt.Errorf("Mastodon-Login JSON-Fehler: %v - Antwort: %s", err, string(body))
+	var account struct {
+		Acct string `json:"acct"`
 	}
-	exp := time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
-	return tokenResp.AccessToken, exp, nil
+	json.NewDecoder(resp.Body).Decode(&account)
+	return doctorCheck{"Mastodon", doctorPass, "angemeldet als " + account.Acct, ""}
 }
 
-// mastodonCreatePost erstellt einen neuen Beitrag auf Mastodon
-func mastodonCreatePost(server, token, text, visibility string) error {
-	apiUrl := server + "/api/v1/statuses"
-	payload := map[string]interface{}{
-		"status":     text,
-		"visibility": visibility,
+// doctorBluesky prüft die Anmeldung bei Bluesky
+func doctorBluesky(config Config) doctorCheck {
+	session, err := blueskyLogin(config.BlueskyPDS, config.BlueskyHandle, config.BlueskyPassword)
+	if err != nil {
+		return doctorCheck{"Bluesky", doctorFail, err.Error(), "bluesky_handle und bluesky_password (App-Passwort) prüfen"}
 	}
-	data, _ := json.Marshal(payload)
-	client := &http.Client{}
-	req, err := http.NewRequest("POST", apiUrl, strings.NewReader(string(data)))
+	return doctorCheck{"Bluesky", doctorPass, "angemeldet als " + session.Handle, ""}
+}
+
+// doctorTelegram prüft das Bot-Token über getMe und den Chat über getChat, ohne zu senden
+func doctorTelegram(config Config) doctorCheck {
+	var me struct {
+		OK     bool `json:"ok"`
+		Result struct {
+			Username string `json:"username"`
+		} `json:"result"`
+	}
+	if err := telegramCall(config.TelegramBotToken, "getMe", nil, &me); err != nil || !me.OK {
+		return doctorCheck{"Telegram", doctorFail, fmt.Sprintf("getMe fehlgeschlagen: %v", err), "telegram_bot_token prüfen (von @BotFather)"}
+	}
+	var chat struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := telegramCall(config.TelegramBotToken, "getChat", map[string]string{"chat_id": config.TelegramChatID}, &chat); err != nil || !chat.OK {
+		return doctorCheck{"Telegram", doctorFail, fmt.Sprintf("Chat %s nicht erreichbar: %v %s", config.TelegramChatID, err, chat.Description), "telegram_chat_id prüfen und den Bot zum Kanal hinzufügen"}
+	}
+	return doctorCheck{"Telegram", doctorPass, fmt.Sprintf("Bot @%s, Chat %s", me.Result.Username, config.TelegramChatID), ""}
+}
+
+// doctorDiscord ruft die Angaben zum Webhook ab, ohne eine Nachricht zu senden
+func doctorDiscord(config Config) doctorCheck {
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	req, err := newRequest(ctx, "GET", config.DiscordWebhookURL, nil)
 	if err != nil {
-		return err
+		return doctorCheck{"Discord", doctorFail, "discord_webhook_url ist keine gültige URL", "Webhook-URL aus den Kanaleinstellungen kopieren"}
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return err
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return doctorCheck{"Discord", doctorFail, fmt.Sprintf("Webhook nicht erreichbar: %v", err), "Netzwerkverbindung prüfen"}
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Mastodon-Post HTTP %d - Antwort: %s", resp.StatusCode, string(body))
+	defer closeBody(resp)
+	var webhook struct {
+		Name      string `json:"name"`
+		ChannelID string `json:"channel_id"`
 	}
-	return nil
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&webhook) != nil {
+		return doctorCheck{"Discord", doctorFail, fmt.Sprintf("Webhook antwortet mit HTTP %d", resp.StatusCode), "discord_webhook_url prüfen, der Webhook wurde evtl. gelöscht"}
+	}
+	return doctorCheck{"Discord", doctorPass, fmt.Sprintf("Webhook %q, Kanal %s", webhook.Name, webhook.ChannelID), ""}
 }
 
-func savePostAsJSON(title, markdown, url, community string) error {
-	post := map[string]interface{}{
-		"title":     title,
-		"markdown":  markdown,
-		"url":       url,
-		"community": community,
-		"timestamp": time.Now().Format(time.RFC3339),
+// telegramCall ruft eine Methode der Bot API auf und dekodiert die Antwort nach v
+func telegramCall(token, method string, params map[string]string, v interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	query := url.Values{}
+	for name, value := range params {
+		query.Set(name, value)
 	}
-	if err := os.MkdirAll("posts", 0755); err != nil {
-		return err
+	req, err := newRequest(ctx, "GET", telegramAPIURL+"/bot"+token+"/"+method+"?"+query.Encode(), nil)
+	if err != nil {
+		return errors.New("ungültige Anfrage")
 	}
-	filename := filepath.Join("posts", fmt.Sprintf("%s_%d.json", strings.ReplaceAll(title, " ", "_"), time.Now().UnixNano()))
-	data, err := json.MarshalIndent(post, "", "  ")
+	resp, err := httpClient.Do(req)
 	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
 		return err
 	}
-	return os.WriteFile(filename, data, 0644)
+	defer closeBody(resp)
+	return json.NewDecoder(resp.Body).Decode(v)
 }
 
-func printMastodonAuthURL(config Config) {
-	if config.MastodonServer == "" || config.MastodonClientID == "" {
-		fmt.Println("mastodon_server und mastodon_client_id müssen in der Konfiguration gesetzt sein.")
-		return
+// printDoctorReport gibt den Bericht aus und meldet, ob eine Prüfung fehlgeschlagen ist
+func printDoctorReport(w io.Writer, checks []doctorCheck) bool {
+	icons := map[string]string{doctorPass: "✅", doctorWarn: "⚠️ ", doctorFail: "❌"}
+	failed := false
+	for _, c := range checks {
+		fmt.Fprintf(w, "%s %s: %s\n", icons[c.Status], c.Name, c.Message)
+		if c.Hint != "" {
+			fmt.Fprintf(w, "   → %s\n", c.Hint)
+		}
+		if c.Status == doctorFail {
+			failed = true
+		}
 	}
-	url := fmt.Sprintf("%soauth/authorize?client_id=%s&redirect_uri=urn:ietf:wg:oauth:2.0:oob&response_type=code&scope=write", config.MastodonServer, config.MastodonClientID)
-	fmt.Println("Öffne folgende URL im Browser, um den Authorization Code zu erhalten:")
-	fmt.Println(url)
+	return failed
 }
 
-func obtainMastodonTokenInteractive(config *Config) error {
-	if config.MastodonServer == "" || config.MastodonClientID == "" || config.MastodonClientSecret == "" {
-		return fmt.Errorf("mastodon_server, mastodon_client_id und mastodon_client_secret müssen gesetzt sein")
-	}
-	redirectURI := "urn:ietf:wg:oauth:2.0:oob"
-	scope := "write"
-
-	authURL := fmt.Sprintf("%soauth/authorize?client_id=%s&redirect_uri=%s&response_type=code&scope=%s", config.MastodonServer, config.MastodonClientID, redirectURI, scope)
-	fmt.Println("Bitte öffne folgende URL im Browser, logge dich ein und erlaube den Zugriff:")
-	fmt.Println(authURL)
-	fmt.Print("Gib den angezeigten Code ein: ")
-	reader := bufio.NewReader(os.Stdin)
-	code, _ := reader.ReadString('\n')
-	code = strings.TrimSpace(code)
-	if code == "" {
-		return fmt.Errorf("Kein Code eingegeben")
-	}
+func cmdDoctor(args []string) error {
+	fs := newFlagSet("doctor")
+	checkAuth := fs.Bool("check-auth", false, "Only log in to each configured platform and report the result (no website access, no posting)")
+	fs.Parse(args)
 
-	// Tausche Code gegen Access Token
-	payload := map[string]string{
-		"redirect_uri": redirectURI,
-		"client_id": config.MastodonClientID,
-		"client_secret": config.MastodonClientSecret,
-		"grant_type": "authorization_code",
-		"code": code,
-	}
-	data, _ := json.Marshal(payload)
-	resp, err := http.Post(config.MastodonServer+"oauth/token", "application/json", strings.NewReader(string(data)))
+	config, err := loadCommandConfig()
 	if err != nil {
-		return fmt.Errorf("Fehler beim Token-Austausch: %v", err)
-	}
-	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("Token-Austausch fehlgeschlagen: %s", string(body))
-	}
-	var tokenResp struct {
-		AccessToken string `json:"access_token"`
-		ExpiresIn   int    `json:"expires_in"`
-	}
-	if err := json.Unmarshal(body, &tokenResp); err != nil {
-		return fmt.Errorf("Fehler beim Parsen der Token-Antwort: %v", err)
+		return err
 	}
-	if tokenResp.AccessToken == "" {
-		return fmt.Errorf("Kein Access Token erhalten")
+	var checks []doctorCheck
+	if *checkAuth {
+		checks = doctorPlatforms(config)
+	} else {
+		checks = runDoctor(config, time.Now)
 	}
-	config.MastodonAccessToken = tokenResp.AccessToken
-	config.MastodonToken = tokenResp.AccessToken
-	if tokenResp.ExpiresIn > 0 {
-		config.MastodonTokenExp = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	if printDoctorReport(os.Stdout, checks) {
+		return fmt.Errorf("Mindestens eine Prüfung ist fehlgeschlagen")
 	}
-	fmt.Println("Access Token erfolgreich erhalten und gespeichert.")
-	return saveConfig(*config, "config.json")
+	return nil
 }
 
-func main() {
-	// Command line flags
-	var loopMode = flag.Bool("loop", false, "Run in continuous monitoring mode")
-	var testMode = flag.Bool("test", false, "Run in test mode - don't post to Lemmy, just show what would be posted")
-	flag.Parse()
+func cmdMastodonAuth(args []string) error {
+	fs := newFlagSet("mastodon-auth")
+	printURL := fs.Bool("print-url", false, "Only print the authorization URL")
+	fs.Parse(args)
 
-	// Konfiguration laden
-	config, err := loadConfig("config.json")
+	config, err := loadCommandConfig()
 	if err != nil {
-		log.Fatalf("Fehler beim Laden der Konfiguration: %v", err)
+		return err
 	}
-
-	// Mastodon OAuth2-Flow automatisch durchführen, wenn kein Token vorhanden ist, aber Server und ClientID/Secret gesetzt sind
-	if config.MastodonServer != "" && config.MastodonClientID != "" && config.MastodonClientSecret != "" && config.MastodonAccessToken == "" && config.MastodonToken == "" {
-		err := obtainMastodonTokenInteractive(&config)
-		if err != nil {
-			log.Fatalf("Fehler beim Mastodon-OAuth2-Flow: %v", err)
-		}
-		// Nach erfolgreichem Token-Erhalt: Programm normal fortsetzen
+	if *printURL {
+		printMastodonAuthURL(config)
+		return nil
 	}
+	if err := obtainMastodonTokenInteractive(&config); err != nil {
+		return fmt.Errorf("Fehler beim Mastodon-OAuth2-Flow: %v", err)
+	}
+	return nil
+}
 
-	if *loopMode {
-		// Kontinuierliche Überwachung
-		log.Printf("Starte kontinuierliche Überwachung...")
+// parseCommandLine trennt den Unterbefehl von seinen Argumenten. Ohne Unterbefehl wird run
+// angenommen, damit z.B. "--loop" weiterhin funktioniert; explicit ist dann false.
+func parseCommandLine(args []string) (name string, rest []string, explicit bool) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return args[0], args[1:], true
+	}
+	return "run", args, false
+}
 
-		// Kontext für graceful shutdown
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
+// isGlobalHelp meldet, ob die allgemeine Hilfe gewünscht ist. Mit Unterbefehl gehört -h
+// dessen FlagSet, sodass z.B. "list -h" die Flags von list zeigt.
+func isGlobalHelp(name string, args []string, explicit bool) bool {
+	if name == "help" {
+		return true
+	}
+	return !explicit && len(args) == 1 && (args[0] == "-h" || args[0] == "-help" || args[0] == "--help")
+}
 
-		// Signal-Handler für graceful shutdown
-		go func() {
-			sigChan := make(chan os.Signal, 1)
-			// signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-			<-sigChan
-			log.Println("Shutdown-Signal empfangen...")
-			cancel()
-		}()
+func main() {
+	if err := configureLogging(); err != nil {
+		log.Fatalf("%v", err)
+	}
 
-		// Überwachung starten
-		err = runMonitoring(ctx, config, *testMode)
-		if err != nil {
-			log.Fatalf("Fehler in der Überwachung: %v", err)
-		}
-	} else {
-		// Einmalige Überprüfung
-		log.Printf("Führe einmalige Überprüfung durch...")
-		err = checkWebsite(config, *testMode)
-		if err != nil {
-			log.Fatalf("Fehler bei der Website-Überprüfung: %v", err)
-		}
-		log.Printf("Überprüfung abgeschlossen.")
+	name, args, explicit := parseCommandLine(os.Args[1:])
+	if isGlobalHelp(name, args, explicit) {
+		printUsage(os.Stdout)
+		return
+	}
+	if len(args) == 1 && (args[0] == "-version" || args[0] == "--version") {
+		fmt.Printf("%s %s\n", filepath.Base(os.Args[0]), versionString())
+		return
+	}
+	cmd, ok := findSubcommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unbekannter Befehl: %s\n\n", name)
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+	if err := cmd.run(args); err != nil {
+		log.Fatalf("%v", err)
 	}
 }
@@ -0,0 +1,3468 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"grundstueckverkehrsgesetz/internal/fakeserver"
+)
+
+// testConfig liefert eine Konfiguration, deren Dateien im temporären Verzeichnis des Tests
+// liegen und die keinen echten Server anspricht
+func testConfig(t *testing.T) Config {
+	t.Helper()
+	dir := t.TempDir()
+	config := DefaultConfig()
+	config.DataFile = filepath.Join(dir, "links.json")
+	config.configFile = filepath.Join(dir, "config.json")
+	config.DumpDir = filepath.Join(dir, "posts")
+	config.LemmyServer = ""
+	config.LemmyPassword = ""
+	config.IgnoreDirs = nil
+	return config
+}
+
+func TestStatusSnapshotConcurrent(t *testing.T) {
+	config := testConfig(t)
+	config.Disclaimer = "Ohne Gewähr"
+	data := newLinkData()
+	data.addLink(LinkRecord{Href: "bielefeld/acker.html", City: "Bielefeld", Title: "Acker", Text: "Ackerfläche"})
+	if err := saveLinkData(data, config.DataFile); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := &statusSnapshot{statusJSON: []byte("{}")}
+	cache.Update(data, CheckResult{}, time.Now())
+	cache.UpdateFeed(config, time.Now())
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", cache.ServeStatus)
+	mux.HandleFunc("/feed.xml", cache.ServeFeed)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			cache.Update(data, CheckResult{}, time.Now())
+			cache.UpdateFeed(config, time.Now())
+		}()
+		go func() {
+			defer wg.Done()
+			for _, path := range []string{"/status", "/feed.xml"} {
+				resp, err := http.Get(server.URL + path)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("%s: HTTP %d", path, resp.StatusCode)
+				}
+				if path == "/status" && !strings.Contains(string(body), `"links": 1`) {
+					t.Errorf("/status liefert nicht den Snapshot: %s", body)
+				}
+				if path == "/feed.xml" && !strings.Contains(string(body), "Ackerfläche&#xA;&#xA;Ohne Gewähr") {
+					t.Errorf("/feed.xml liefert nicht den Snapshot: %s", body)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestServeFeedBeforeFirstCheck(t *testing.T) {
+	cache := &statusSnapshot{}
+	rec := httptest.NewRecorder()
+	cache.ServeFeed(rec, httptest.NewRequest("GET", "/feed.xml", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("HTTP %d, erwartet 503", rec.Code)
+	}
+}
+
+// fakeSource ist eine nachgebaute Übersichtsseite mit Detailseiten
+type fakeSource struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	pages   map[string]string
+	hits    map[string]int         // Abrufe je Pfad
+	headers map[string]http.Header // Header der letzten Anfrage je Pfad
+
+	// onIndex wird vor der Auslieferung der Übersichtsseite aufgerufen, falls gesetzt
+	onIndex func()
+}
+
+// newFakeSource startet eine Website, deren Übersichtsseite auf die Detailseiten verlinkt
+func newFakeSource(t *testing.T) *fakeSource {
+	t.Helper()
+	s := &fakeSource{pages: map[string]string{}, hits: map[string]int{}, headers: map[string]http.Header{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" && s.onIndex != nil {
+			s.onIndex()
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.hits[r.URL.Path]++
+		s.headers[r.URL.Path] = r.Header.Clone()
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, "<html><body>")
+			for path := range s.pages {
+				fmt.Fprintf(w, `<a href="%s">%s</a>`, path, path)
+			}
+			fmt.Fprint(w, "</body></html>")
+			return
+		}
+		page, ok := s.pages[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, page)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// setListing legt eine Detailseite unter <stadt>/index.htm an
+func (s *fakeSource) setListing(city, title, text string) string {
+	href := strings.ToLower(city) + "/index.htm"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pages[href] = fmt.Sprintf("<html><body><h1>%s</h1><hr><h3>%s</h3><p>%s</p><hr></body></html>", city, title, text)
+	return href
+}
+
+// setPage legt eine beliebige Seite unter href an, die auf der Übersichtsseite verlinkt wird
+func (s *fakeSource) setPage(href, page string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pages[href] = page
+}
+
+// fetches gibt zurück, wie oft die Seite href abgerufen wurde
+func (s *fakeSource) fetches(href string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits["/"+href]
+}
+
+// requestHeader gibt die Header der letzten Anfrage nach href zurück ("" für die Übersichtsseite)
+func (s *fakeSource) requestHeader(href string) http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.headers["/"+href]
+}
+
+// removeListing entfernt eine Detailseite samt Link auf der Übersichtsseite
+func (s *fakeSource) removeListing(href string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pages, href)
+}
+
+// sourceConfig liefert eine Testkonfiguration, die source überwacht und nur an discord postet
+func sourceConfig(t *testing.T, source *fakeSource, discord *fakeserver.Discord) Config {
+	t.Helper()
+	config := testConfig(t)
+	config.URL = source.URL
+	config.DiscordWebhookURL = discord.WebhookURL()
+	t.Cleanup(discord.Close)
+	return config
+}
+
+func TestApprovalQueue(t *testing.T) {
+	source := newFakeSource(t)
+	discord := fakeserver.NewDiscord()
+	config := sourceConfig(t, source, discord)
+	config.RequireApproval = true
+	approved := source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	rejected := source.setListing("Minden", "Grünland", "Verkauf von Grünland an eine GmbH.")
+	ctx := context.Background()
+
+	// Neue Links werden nicht gepostet, sondern in die Warteschlange gestellt
+	if _, err := checkWebsite(ctx, config, false); err != nil {
+		t.Fatal(err)
+	}
+	if embeds := discord.Embeds(); len(embeds) != 0 {
+		t.Fatalf("vor der Freigabe gepostet: %v", embeds)
+	}
+	queue, err := loadApprovalQueue(approvalQueueFile(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue.Items) != 2 {
+		t.Fatalf("Warteschlange enthält %d Einträge, erwartet 2", len(queue.Items))
+	}
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(config.DataFile), ".approval_queue.json.tmp-*"))
+	if len(matches) != 0 {
+		t.Errorf("temporäre Dateien nicht aufgeräumt: %v", matches)
+	}
+
+	if err := approveLink(config, source.URL+"/"+approved); err != nil {
+		t.Fatal(err)
+	}
+	if err := rejectLink(config, rejected); err != nil {
+		t.Fatal(err)
+	}
+	if err := approveLink(config, "unbekannt/index.htm"); err == nil {
+		t.Error("Freigabe eines unbekannten Links ohne Fehler")
+	}
+
+	// Der freigegebene Link wird gepostet, der verworfene ohne Post als gesehen markiert
+	if _, err := checkWebsite(ctx, config, false); err != nil {
+		t.Fatal(err)
+	}
+	embeds := discord.Embeds()
+	if len(embeds) != 1 || embeds[0].URL != source.URL+"/"+approved {
+		t.Fatalf("Posts nach Freigabe: %+v", embeds)
+	}
+	data, err := loadSourceData(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record := data.record(rejected); record == nil || record.Skipped != skipRejected {
+		t.Errorf("verworfener Link: %+v", record)
+	}
+	if record := data.record(approved); record == nil || record.Skipped != "" {
+		t.Errorf("freigegebener Link: %+v", record)
+	}
+	queue, err = loadApprovalQueue(approvalQueueFile(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue.Items) != 0 {
+		t.Errorf("Warteschlange nach dem Posten: %+v", queue.Items)
+	}
+}
+
+func TestDebounceLinks(t *testing.T) {
+	data := newLinkData()
+	start := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	debounce := 30 * time.Minute
+	index := []string{"bielefeld/index.htm", "test/index.htm"}
+
+	if ready := debounceLinks(&data, index, index, debounce, start); len(ready) != 0 {
+		t.Fatalf("sofort bereit: %v", ready)
+	}
+
+	// Der Testeintrag verschwindet vor Ablauf der Frist und wird verworfen
+	index = index[:1]
+	if ready := debounceLinks(&data, index, index, debounce, start.Add(10*time.Minute)); len(ready) != 0 {
+		t.Fatalf("vor Ablauf bereit: %v", ready)
+	}
+	if _, ok := data.FirstObserved["test/index.htm"]; ok {
+		t.Error("verschwundener Link bleibt vorgemerkt")
+	}
+
+	ready := debounceLinks(&data, index, index, debounce, start.Add(debounce))
+	if len(ready) != 1 || ready[0] != "bielefeld/index.htm" {
+		t.Fatalf("nach Ablauf bereit: %v, erwartet bielefeld/index.htm", ready)
+	}
+}
+
+func TestDebounceKeepsFailedLinksOffIndex(t *testing.T) {
+	data := newLinkData()
+	start := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	debounce := 30 * time.Minute
+	link := "bielefeld/index.htm"
+
+	debounceLinks(&data, []string{link}, []string{link}, debounce, start)
+	if ready := debounceLinks(&data, []string{link}, []string{link}, debounce, start.Add(debounce)); len(ready) != 1 {
+		t.Fatalf("nach Ablauf bereit: %v", ready)
+	}
+	data.markFailed(link)
+
+	// Der fehlgeschlagene Link steht nicht mehr auf der Übersichtsseite, wird aber weiter versucht
+	later := start.Add(2 * debounce)
+	if ready := debounceLinks(&data, []string{link}, nil, debounce, later); len(ready) != 1 {
+		t.Fatalf("fehlgeschlagener Link zurückgehalten: %v", ready)
+	}
+	if observed := data.FirstObserved[link]; !observed.Equal(start) {
+		t.Errorf("FirstObserved = %v, erwartet %v", observed, start)
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		max  int
+		want string
+	}{
+		{"kurz", "Grünland", 20, "Grünland"},
+		{"Wortgrenze", "Größe 12.000 m² Grünland", 17, "Größe 12.000 m²..."},
+		{"Umlaute", "Flurstücksübertragung", 10, "Flurstücks..."},
+		{"Emoji", "🌾🌾🌾🌾🌾🌾", 4, "🌾🌾🌾🌾..."},
+		{"Emoji vor Leerzeichen", "🌾 Ackerfläche", 5, "🌾..."},
+		{"langes Wort", "Kurz " + strings.Repeat("ä", 40), 30, "Kurz " + strings.Repeat("ä", 25) + "..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateString(tt.in, tt.max); got != tt.want {
+				t.Errorf("truncateString(%q, %d) = %q, erwartet %q", tt.in, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlatformFormatters(t *testing.T) {
+	const pageURL = "https://www.example.org/grundstueckverkehr/soest/index.htm"
+	short := postTexts{
+		title:       "Soest: Ackerfläche",
+		body:        "**Ackerfläche**\n\nVerkauf von 2 ha an einen Nicht-Landwirt.",
+		status:      "Soest: Ackerfläche\nVerkauf von **2 ha** an einen Nicht-Landwirt.",
+		attachments: "\n\nFormular: https://www.example.org/formular.pdf",
+		hashtag:     "\n\n#Ackerland",
+	}
+	filler := strings.Repeat("Verkauf von Ackerland an einen Nicht-Landwirt. ", 400)
+	long := postTexts{title: short.title, body: filler, status: short.title + "\n" + filler, hashtag: short.hashtag}
+	runes := utf8.RuneCountInString
+
+	tests := []struct {
+		platform string
+		format   func(config Config, texts postTexts) (string, string)
+		count    func(string) int
+		want     string // Text für short ohne Disclaimer und mit Standardlimit
+		suffix   string // steht immer am Ende, auch wenn gekürzt wird
+	}{
+		{
+			platform: "lemmy",
+			format:   func(c Config, p postTexts) (string, string) { return formatLemmyPost(c, p) },
+			count:    runes,
+			want:     short.body,
+		},
+		{
+			platform: "mastodon",
+			format:   func(c Config, p postTexts) (string, string) { return "", formatMastodonPost(c, p, pageURL) },
+			count:    mastodonLength,
+			want:     short.status + short.attachments + short.hashtag + "\n" + pageURL,
+			suffix:   "\n" + pageURL,
+		},
+		{
+			platform: "bluesky",
+			format:   func(c Config, p postTexts) (string, string) { return "", formatBlueskyPost(c, p, pageURL) },
+			count:    runes,
+			want:     short.status + short.hashtag + "\n" + pageURL,
+			suffix:   "\n" + pageURL,
+		},
+		{
+			platform: "telegram",
+			format:   func(c Config, p postTexts) (string, string) { return "", formatTelegramPost(c, p, pageURL) },
+			count:    runes,
+			want:     "Soest: Ackerfläche\nVerkauf von 2 ha an einen Nicht-Landwirt." + short.attachments + short.hashtag + "\n\n" + pageURL,
+			suffix:   "\n\n" + pageURL,
+		},
+		{
+			platform: "discord",
+			format:   func(c Config, p postTexts) (string, string) { return formatDiscordPost(c, p) },
+			count:    runes,
+			want:     short.body + short.hashtag,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.platform, func(t *testing.T) {
+			// Kurzer Text bleibt vollständig, die Quelle steht am Ende
+			title, text := tt.format(Config{}, short)
+			if text != tt.want {
+				t.Errorf("Text %q, erwartet %q", text, tt.want)
+			}
+			if tt.suffix == "" && title != short.title {
+				t.Errorf("Titel %q, erwartet %q", title, short.title)
+			}
+			if tt.suffix == "" && strings.Contains(text, pageURL) {
+				t.Errorf("Quelle im Text, obwohl sie im URL-Feld steht: %q", text)
+			}
+
+			limits := []struct {
+				name   string
+				config Config
+				limit  int
+			}{
+				{"Standardlimit", Config{}, defaultPlatformLimits[tt.platform]},
+				{"platform_limits", Config{PlatformLimits: map[string]int{tt.platform: 200}}, 200},
+				{"Disclaimer", Config{PlatformLimits: map[string]int{tt.platform: 200}, Disclaimer: "Automatisch erstellt."}, 200},
+			}
+			for _, l := range limits {
+				_, text := tt.format(l.config, long)
+				if n := tt.count(text); n > l.limit || n < l.limit-10 {
+					t.Errorf("%s: %d Zeichen, erwartet höchstens %d und das Limit ausgeschöpft", l.name, n, l.limit)
+				}
+				if !strings.HasSuffix(text, l.config.Disclaimer+tt.suffix) {
+					t.Errorf("%s: Ende %q, erwartet Disclaimer und Quelle", l.name, text[max(0, len(text)-120):])
+				}
+				if !strings.Contains(text, "…") {
+					t.Errorf("%s: gekürzter Text ohne Auslassungszeichen", l.name)
+				}
+				if !strings.HasPrefix(text, short.title) && !strings.HasPrefix(text, "Verkauf von") {
+					t.Errorf("%s: Anfang des Textes fehlt: %q", l.name, text[:40])
+				}
+			}
+		})
+	}
+}
+
+func TestReplayFailed(t *testing.T) {
+	source := newFakeSource(t)
+	discord := fakeserver.NewDiscord()
+	config := sourceConfig(t, source, discord)
+	posted := source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	missing := "minden/index.htm"
+
+	data := newLinkData()
+	data.markFailed(posted)
+	data.markFailed(missing)
+	if err := saveSourceData(config, data); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := replayFailed(context.Background(), config, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Posted != 1 || result.Failed != 1 {
+		t.Errorf("Posted = %d, Failed = %d, erwartet 1 und 1", result.Posted, result.Failed)
+	}
+	if embeds := discord.Embeds(); len(embeds) != 1 || embeds[0].URL != source.URL+"/"+posted {
+		t.Fatalf("Posts: %+v", embeds)
+	}
+
+	data, err = loadSourceData(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.record(posted) == nil {
+		t.Errorf("%s nicht als gesehen gespeichert", posted)
+	}
+	if len(data.FailedLinks) != 1 || data.FailedLinks[0] != missing {
+		t.Errorf("FailedLinks = %v, erwartet [%s]", data.FailedLinks, missing)
+	}
+	if attempts := data.FailedAttempts[missing].Count; attempts != 2 {
+		t.Errorf("%d Fehlversuche gezählt, erwartet 2", attempts)
+	}
+}
+
+func TestLoadLinkDataRecoversFromBackup(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "links.json")
+
+	data := newLinkData()
+	data.addLink(LinkRecord{Href: "bielefeld/index.htm"})
+	if err := saveLinkData(data, filename); err != nil {
+		t.Fatal(err)
+	}
+	// Das zweite Speichern legt die gültige erste Fassung als Backup ab
+	data.addLink(LinkRecord{Href: "minden/index.htm"})
+	if err := saveLinkData(data, filename); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, []byte(`{"links": [`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered, err := loadLinkData(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recovered.hrefs(); len(got) != 1 || got[0] != "bielefeld/index.htm" {
+		t.Errorf("wiederhergestellte Links = %v, erwartet das Backup", got)
+	}
+	corrupt, _ := filepath.Glob(filename + ".corrupt-*")
+	if len(corrupt) != 1 {
+		t.Errorf("beschädigte Datei nicht beiseitegelegt: %v", corrupt)
+	}
+}
+
+func TestLoadLinkDataWithoutBackup(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "links.json")
+	if err := os.WriteFile(filename, []byte("kein JSON"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := loadLinkData(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Links) != 0 {
+		t.Errorf("Links = %v, erwartet leere Daten", data.hrefs())
+	}
+}
+
+func TestFilterLinksSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	data := newLinkData()
+	data.addLink(LinkRecord{Href: "neu/index.htm", FirstSeen: now.Add(-2 * time.Hour)})
+	data.addLink(LinkRecord{Href: "grenze/index.htm", FirstSeen: now.Add(-7 * 24 * time.Hour)})
+	data.addLink(LinkRecord{Href: "alt/index.htm", FirstSeen: now.Add(-8 * 24 * time.Hour)})
+	data.addLink(LinkRecord{Href: "ohne-datum/index.htm"})
+
+	since, err := parseSince("7d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, record := range filterLinksSince(data, since, now) {
+		got = append(got, record.Href)
+	}
+	if strings.Join(got, ",") != "neu/index.htm,grenze/index.htm" {
+		t.Errorf("seit 7d: %v", got)
+	}
+
+	if all := filterLinksSince(data, 0, now); len(all) != 4 {
+		t.Errorf("ohne -since: %d Links, erwartet 4", len(all))
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":      0,
+		"12h":   12 * time.Hour,
+		"7d":    7 * 24 * time.Hour,
+		"1d12h": 36 * time.Hour,
+	}
+	for in, want := range tests {
+		got, err := parseSince(in)
+		if err != nil || got != want {
+			t.Errorf("parseSince(%q) = %v, %v, erwartet %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"x", "-1d", "7 Tage"} {
+		if _, err := parseSince(in); err == nil {
+			t.Errorf("parseSince(%q) ohne Fehler", in)
+		}
+	}
+}
+
+func TestPlatformLimits(t *testing.T) {
+	config := testConfig(t)
+	config.PlatformLimits = map[string]int{"mastodon": 2000}
+
+	short := strings.Repeat("a", 1500)
+	if got := fitForPlatform(config, "mastodon", short); got != short {
+		t.Errorf("1500 Zeichen gekürzt auf %d", len([]rune(got)))
+	}
+	long := strings.Repeat("ä", 5000)
+	if got := fitForPlatform(config, "mastodon", long); len([]rune(got)) != 2000 {
+		t.Errorf("5000 Zeichen gekürzt auf %d, erwartet 2000", len([]rune(got)))
+	}
+	if limit := platformLimit(config, "bluesky"); limit != 300 {
+		t.Errorf("Standardlimit bluesky = %d, erwartet 300", limit)
+	}
+}
+
+func TestCheckResultSummary(t *testing.T) {
+	source := newFakeSource(t)
+	discord := fakeserver.NewDiscord()
+	config := sourceConfig(t, source, discord)
+	source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	minden := source.setListing("Minden", "Grünland", "Verkauf von Grünland an eine GmbH.")
+	source.setPage("leer/index.htm", "<html><body></body></html>")
+	ctx := context.Background()
+
+	result, err := checkWebsite(ctx, config, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Checked != 3 || result.New != 3 || result.Posted != 2 || result.Failed != 1 || result.Removed != 0 {
+		t.Errorf("erster Durchlauf: %+v", result)
+	}
+	if !strings.HasPrefix(result.Summary(), "run complete: checked=3 new=3 posted=2 failed=1 removed=0 duration=") {
+		t.Errorf("Summary() = %q", result.Summary())
+	}
+
+	source.removeListing(minden)
+	result, err = checkWebsite(ctx, config, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(result.Summary(), "run complete: checked=2 new=1 posted=0 failed=1 removed=1 duration=") {
+		t.Errorf("zweiter Durchlauf: Summary() = %q", result.Summary())
+	}
+}
+
+func TestRenderPostTemplate(t *testing.T) {
+	config := testConfig(t)
+	config.TitleTemplate = "{{.City}}: {{.Title}}"
+	config.BodyTemplate = "{{.Text}}{{if .Gemarkung}}\nGemarkung {{.Gemarkung}}, Flurstück {{.Flurstueck}}, {{.Area}}{{end}}"
+
+	withParcel := newPostData("Bielefeld", "Ackerfläche", "Gemarkung Brake, Flur 3, Flurstück 12/4, Größe 23.456 m², Kaufpreis 45.000 €",
+		"https://example.org/bielefeld/index.htm", "bielefeld/index.htm")
+	title, body, err := renderPost(config, withParcel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "Bielefeld: Ackerfläche" {
+		t.Errorf("Titel = %q", title)
+	}
+	if !strings.HasSuffix(body, "\nGemarkung Brake, Flurstück 12/4, 2,35 ha") {
+		t.Errorf("Text mit Flurstück = %q", body)
+	}
+
+	without := newPostData("", "Grünland", "Verkauf von Grünland.", "https://example.org/minden/index.htm", "minden/index.htm")
+	title, body, err = renderPost(config, without)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "Minden: Grünland" || body != "Verkauf von Grünland." {
+		t.Errorf("ohne Flurstück: %q / %q", title, body)
+	}
+
+	config.BodyTemplate = "{{.Unbekannt}}"
+	if _, _, err := renderPost(config, without); err == nil {
+		t.Error("unbekannte Variable ohne Fehler")
+	}
+}
+
+// platformFakes sind nachgebaute Server für alle Plattformen
+type platformFakes struct {
+	lemmy    *fakeserver.Lemmy
+	mastodon *fakeserver.Mastodon
+	bluesky  *fakeserver.Bluesky
+	telegram *fakeserver.Telegram
+	discord  *fakeserver.Discord
+}
+
+// withPlatformFakes startet Fakes aller Plattformen und trägt sie in config ein
+func withPlatformFakes(t *testing.T, config *Config) platformFakes {
+	t.Helper()
+	fakes := platformFakes{
+		lemmy:    fakeserver.NewLemmy("kulturlandschaft", 7),
+		mastodon: fakeserver.NewMastodon("mastodon-token"),
+		bluesky:  fakeserver.NewBluesky("gvgbot.example.org", "bluesky-pw"),
+		telegram: fakeserver.NewTelegram("bot-token", "@gvgbot"),
+		discord:  fakeserver.NewDiscord(),
+	}
+	for _, s := range []*httptest.Server{fakes.lemmy.Server, fakes.mastodon.Server, fakes.bluesky.Server, fakes.telegram.Server, fakes.discord.Server} {
+		t.Cleanup(s.Close)
+	}
+	config.LemmyServer = fakes.lemmy.URL
+	config.LemmyCommunity = "kulturlandschaft"
+	config.LemmyUsername = "gvgbot"
+	config.LemmyPassword = "lemmy-pw"
+	config.LemmyLanguage = "de"
+	config.MastodonServer = fakes.mastodon.URL
+	config.MastodonAccessToken = "mastodon-token"
+	config.BlueskyPDS = fakes.bluesky.URL
+	config.BlueskyHandle = "gvgbot.example.org"
+	config.BlueskyPassword = "bluesky-pw"
+	config.TelegramBotToken = "bot-token"
+	config.TelegramChatID = "@gvgbot"
+	config.DiscordWebhookURL = fakes.discord.WebhookURL()
+
+	apiURL := telegramAPIURL
+	telegramAPIURL = fakes.telegram.URL
+	t.Cleanup(func() { telegramAPIURL = apiURL })
+	return fakes
+}
+
+func TestCheckWebsitePostsToAllPlatforms(t *testing.T) {
+	source := newFakeSource(t)
+	config := testConfig(t)
+	config.URL = source.URL
+	fakes := withPlatformFakes(t, &config)
+	href := source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	pageURL := source.URL + "/" + href
+	ctx := context.Background()
+
+	result, err := checkWebsite(ctx, config, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Posted != 1 || result.Posts.Full != 1 {
+		t.Fatalf("Ergebnis: %+v", result)
+	}
+
+	if posts := fakes.lemmy.Posts(); len(posts) != 1 || posts[0].URL != pageURL || posts[0].CommunityID != 7 || posts[0].LanguageID != 32 {
+		t.Errorf("Lemmy: %+v", posts)
+	} else if !strings.HasPrefix(posts[0].Name, "Bielefeld: Grundstücksverkauf an Nicht-LandwirtIn") {
+		t.Errorf("Lemmy-Titel: %q", posts[0].Name)
+	}
+	if statuses := fakes.mastodon.Statuses(); len(statuses) != 1 || !strings.HasSuffix(statuses[0].Status, "\n"+pageURL) {
+		t.Errorf("Mastodon: %+v", statuses)
+	}
+	if records := fakes.bluesky.Records(); len(records) != 1 || !strings.Contains(records[0].Text, "Ackerfläche") {
+		t.Errorf("Bluesky: %+v", records)
+	}
+	if messages := fakes.telegram.Messages(); len(messages) != 1 || messages[0].ChatID != "@gvgbot" {
+		t.Errorf("Telegram: %+v", messages)
+	}
+	if embeds := fakes.discord.Embeds(); len(embeds) != 1 || embeds[0].URL != pageURL {
+		t.Errorf("Discord: %+v", embeds)
+	}
+
+	data, err := loadSourceData(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := data.record(href)
+	if record == nil || len(record.PostedTo) != 5 {
+		t.Fatalf("gespeicherter Link: %+v", record)
+	}
+	receipts, err := loadReceiptIndex(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, platform := range record.PostedTo {
+		if !receipts.has(platform, href) {
+			t.Errorf("kein Post-Nachweis für %s", platform)
+		}
+	}
+
+	// Ein zweiter Durchlauf postet nichts erneut
+	result, err = checkWebsite(ctx, config, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.New != 0 || len(fakes.lemmy.Posts()) != 1 || len(fakes.discord.Embeds()) != 1 {
+		t.Errorf("zweiter Durchlauf: %+v", result)
+	}
+}
+
+func TestCheckWebsiteRetriesFailedPlatformOnly(t *testing.T) {
+	source := newFakeSource(t)
+	config := testConfig(t)
+	config.URL = source.URL
+	config.MaxRetries = 0
+	fakes := withPlatformFakes(t, &config)
+	href := source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	fakes.mastodon.FailNext("/api/v1/statuses", http.StatusInternalServerError, 1)
+	ctx := context.Background()
+
+	result, err := checkWebsite(ctx, config, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Posted != 0 || result.Failed != 1 || result.Posts.Partial != 1 {
+		t.Errorf("erster Durchlauf: %+v", result)
+	}
+	data, err := loadSourceData(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsString(data.FailedLinks, href) {
+		t.Fatalf("FailedLinks = %v", data.FailedLinks)
+	}
+
+	result, err = checkWebsite(ctx, config, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Posted != 1 {
+		t.Errorf("zweiter Durchlauf: %+v", result)
+	}
+	if len(fakes.mastodon.Statuses()) != 1 || len(fakes.lemmy.Posts()) != 1 || len(fakes.discord.Embeds()) != 1 {
+		t.Errorf("Posts: Mastodon %d, Lemmy %d, Discord %d, erwartet je 1",
+			len(fakes.mastodon.Statuses()), len(fakes.lemmy.Posts()), len(fakes.discord.Embeds()))
+	}
+}
+
+func TestRetryAfterSkipsPlatformOnNextRun(t *testing.T) {
+	source := newFakeSource(t)
+	var mu sync.Mutex
+	requests := 0
+	discord := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"message":"You are being rate limited."}`)
+	}))
+	defer discord.Close()
+	config := testConfig(t)
+	config.URL = source.URL
+	config.DiscordWebhookURL = discord.URL + "/api/webhooks/1/token"
+	href := source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	ctx := context.Background()
+
+	start := time.Now()
+	if _, err := checkWebsite(ctx, config, false); err != nil {
+		t.Fatal(err)
+	}
+	data, err := loadSourceData(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	until, ok := data.PostNotBefore["discord"]
+	if !ok || until.Before(start.Add(59*time.Minute)) {
+		t.Fatalf("PostNotBefore = %v, erwartet etwa eine Stunde", data.PostNotBefore)
+	}
+
+	if _, err := checkWebsite(ctx, config, false); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 1 {
+		t.Errorf("%d Anfragen an Discord, erwartet 1 (zweiter Durchlauf übersprungen)", requests)
+	}
+	data, err = loadSourceData(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.record(href) != nil {
+		t.Errorf("%s trotz Sperre als gepostet gespeichert", href)
+	}
+	// Der Link wartet zurückgestellt auf das Ende der Sperre und zählt nicht als Fehlschlag
+	if !containsString(data.PendingLinks, href) {
+		t.Errorf("%s nicht zurückgestellt: %v", href, data.PendingLinks)
+	}
+	if containsString(data.FailedLinks, href) || data.FailedAttempts[href].Count != 0 {
+		t.Errorf("%s als fehlgeschlagen gezählt: %v, %+v", href, data.FailedLinks, data.FailedAttempts[href])
+	}
+}
+
+func TestPermalink(t *testing.T) {
+	source := newFakeSource(t)
+	discord := fakeserver.NewDiscord()
+	config := sourceConfig(t, source, discord)
+	config.StatusBaseURL = "https://gvg.example.org/"
+	href := source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	permalink := "https://gvg.example.org/item/" + linkHash(href)
+
+	if _, err := checkWebsite(context.Background(), config, false); err != nil {
+		t.Fatal(err)
+	}
+	if embeds := discord.Embeds(); len(embeds) != 1 || !strings.Contains(embeds[0].Description, "Permalink: "+permalink) {
+		t.Fatalf("Post ohne Permalink: %+v", embeds)
+	}
+
+	feed, err := buildFeed(config, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items := feed.Channel.Items; len(items) != 1 || items[0].GUID.Value != permalink || items[0].Link != source.URL+"/"+href {
+		t.Errorf("Feed-Einträge: %+v", items)
+	}
+
+	cache := &statusSnapshot{}
+	cache.UpdateItems(config)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /item/{hash}", cache.ServeItem)
+	for path, want := range map[string]int{
+		"/item/" + linkHash(href): http.StatusOK,
+		"/item/0000000000000000":  http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: HTTP %d, erwartet %d", path, rec.Code, want)
+		}
+	}
+}
+
+func TestRedactContacts(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Rückfragen an max.mustermann@kreis-guetersloh.de.", "Rückfragen an [Kontakt siehe Original]."},
+		{"E-Mail: info@landwirtschaftskammer.nrw", "E-Mail: [Kontakt siehe Original]"},
+		{"Tel. 05241 85-1234", "Tel. [Kontakt siehe Original]"},
+		{"Telefon: (0521) 51 2345", "Telefon: [Kontakt siehe Original]"},
+		{"Tel.: +49 521 512345", "Tel.: [Kontakt siehe Original]"},
+		{"Tel.: 0049-5241/851234", "Tel.: [Kontakt siehe Original]"},
+		// Flächen, Preise, Daten und Flurstücke bleiben erhalten
+		{"Größe 12.345 m², Kaufpreis 45.000 €, Frist 15.03.2026, Flurstück 123/4",
+			"Größe 12.345 m², Kaufpreis 45.000 €, Frist 15.03.2026, Flurstück 123/4"},
+	}
+	for _, tt := range tests {
+		if got := redactContacts(tt.in); got != tt.want {
+			t.Errorf("redactContacts(%q) = %q, erwartet %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSQLiteImportsJSON(t *testing.T) {
+	dir := t.TempDir()
+	dataFile := filepath.Join(dir, "links.json")
+	data := newLinkData()
+	for _, href := range []string{"bielefeld/index.htm", "minden/index.htm", "soest/index.htm"} {
+		data.addLink(LinkRecord{Href: href, City: "Stadt", FirstSeen: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)})
+	}
+	data.markFailed("warendorf/index.htm")
+	if err := saveLinkData(data, dataFile); err != nil {
+		t.Fatal(err)
+	}
+
+	database := filepath.Join(dir, "links.db")
+	store, err := openSQLiteLinkStore(database, dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var links, failed int
+	if err := store.db.QueryRow(`SELECT COUNT(*) FROM links WHERE source = ?`, dataFile).Scan(&links); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.db.QueryRow(`SELECT COUNT(*) FROM failed_links WHERE source = ?`, dataFile).Scan(&failed); err != nil {
+		t.Fatal(err)
+	}
+	if links != 3 || failed != 1 {
+		t.Errorf("%d Links und %d fehlgeschlagene Links übernommen, erwartet 3 und 1", links, failed)
+	}
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Links) != 3 || !loaded.Links[0].FirstSeen.Equal(data.Links[0].FirstSeen) || len(loaded.FailedLinks) != 1 {
+		t.Errorf("geladene Daten: %+v", loaded)
+	}
+	store.Close()
+
+	// Beim erneuten Öffnen wird die JSON-Datei nicht noch einmal übernommen
+	data.addLink(LinkRecord{Href: "unna/index.htm"})
+	if err := saveLinkData(data, dataFile); err != nil {
+		t.Fatal(err)
+	}
+	store, err = openSQLiteLinkStore(database, dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if loaded, err := store.Load(); err != nil || len(loaded.Links) != 3 {
+		t.Errorf("erneut geöffnet: %d Links, %v", len(loaded.Links), err)
+	}
+}
+
+func TestMigrateToSQLite(t *testing.T) {
+	config := testConfig(t)
+	data := newLinkData()
+	for _, href := range []string{"bielefeld/index.htm", "minden/index.htm"} {
+		data.addLink(LinkRecord{Href: href, City: "Stadt", FirstSeen: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)})
+	}
+	data.markFailed("warendorf/index.htm")
+	data.PendingLinks = append(data.PendingLinks, "unna/index.htm", "soest/index.htm")
+	if err := saveLinkData(data, config.DataFile); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(config.DataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := openSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	results, err := migrateToSQLite(db, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := migrationResult{DataFile: config.DataFile, Links: 2, Failed: 1, Pending: 2}
+	if len(results) != 1 || results[0] != want {
+		t.Errorf("Ergebnis %+v, erwartet %+v", results, want)
+	}
+	var rows int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM links`).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 2 {
+		t.Errorf("%d Zeilen in links, erwartet 2", rows)
+	}
+	after, err := os.ReadFile(config.DataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Error("JSON-Datei wurde bei der Migration verändert")
+	}
+
+	// Eine zweite Migration überschreibt die vorhandenen Daten nicht
+	if _, err := migrateToSQLite(db, config); err == nil {
+		t.Error("erneute Migration ohne Fehler")
+	}
+}
+
+func TestCategoryFilter(t *testing.T) {
+	page, err := os.ReadFile("testdata/listing_category.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, text, err := extractListingText(string(page), testConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := newPostData("Soest", "Bekanntmachung", text, "", "soest/index.htm")
+	if data.Category != "Wald" || data.Hashtag != "#Wald" {
+		t.Fatalf("Kategorie %q, Hashtag %q", data.Category, data.Hashtag)
+	}
+	if got := parseCategory("Verkauf von zwei Parzellen grünland"); got != "Grünland" {
+		t.Errorf("Kategorie aus Stichwort: %q", got)
+	}
+
+	tests := []struct {
+		include, exclude []string
+		category         string
+		want             bool
+	}{
+		{nil, nil, "Wald", true},
+		{[]string{"Ackerland", "Grünland"}, nil, "Wald", false},
+		{[]string{"ackerland"}, nil, "Ackerland", true},
+		{nil, []string{"Wald"}, "Wald", false},
+		{nil, []string{"Wald"}, "Ackerland", true},
+		{[]string{"Wald"}, []string{"Wald"}, "Wald", false},
+		// Ohne erkannte Kategorie wird immer gepostet
+		{[]string{"Ackerland"}, []string{"Wald"}, "", true},
+	}
+	for _, tt := range tests {
+		config := Config{IncludeCategories: tt.include, ExcludeCategories: tt.exclude}
+		if got := categoryAllowed(config, tt.category); got != tt.want {
+			t.Errorf("categoryAllowed(%v, %v, %q) = %v, erwartet %v", tt.include, tt.exclude, tt.category, got, tt.want)
+		}
+	}
+}
+
+func TestCheckWebsiteSkipsExcludedCategory(t *testing.T) {
+	source := newFakeSource(t)
+	discord := fakeserver.NewDiscord()
+	config := sourceConfig(t, source, discord)
+	config.ExcludeCategories = []string{"Wald"}
+	page, err := os.ReadFile("testdata/listing_category.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source.setPage("soest/index.htm", string(page))
+	posted := source.setListing("Minden", "Ackerfläche", "Verkauf von 2 ha Ackerland.")
+
+	if _, err := checkWebsite(context.Background(), config, false); err != nil {
+		t.Fatal(err)
+	}
+	if embeds := discord.Embeds(); len(embeds) != 1 || !strings.HasSuffix(embeds[0].URL, posted) {
+		t.Errorf("Discord: %+v", embeds)
+	}
+	data, err := loadSourceData(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record := data.record("soest/index.htm"); record == nil || record.Skipped != skipCategory {
+		t.Errorf("herausgefilterter Link: %+v", record)
+	}
+}
+
+func TestParseDeadline(t *testing.T) {
+	page, err := os.ReadFile("testdata/listing_deadline.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, text, err := extractListingText(string(page), testConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := newPostData("", "Bekanntmachung", text, "", "warendorf/index.htm")
+	if data.Deadline != "06.03.2026" {
+		t.Errorf("Frist aus Detailseite: %q", data.Deadline)
+	}
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Interesse bitte bis zum 15.03.2025 bekunden.", "2025-03-15 23:59:59"},
+		{"bis spätestens 1.4.2026", "2026-04-01 23:59:59"},
+		{"bis einschließlich Montag, 02.11.2026", "2026-11-02 23:59:59"},
+		{"BIS ZUM 31.12.2025", "2025-12-31 23:59:59"},
+		{"bis zum 31.02.2026", ""},
+		{"bis zum 15.13.2026", ""},
+		{"Die Bekanntmachung vom 15.03.2025", ""},
+	}
+	for _, tt := range tests {
+		deadline, ok := parseDeadline(tt.text)
+		got := ""
+		if ok {
+			got = deadline.Format("2006-01-02 15:04:05")
+		}
+		if got != tt.want {
+			t.Errorf("parseDeadline(%q) = %q, erwartet %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDeadlineReminderSentOnce(t *testing.T) {
+	discord := fakeserver.NewDiscord()
+	config := testConfig(t)
+	config.DiscordWebhookURL = discord.WebhookURL()
+	config.DeadlineReminderDays = 3
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+
+	data := newLinkData()
+	for _, href := range []string{"soon/index.htm", "later/index.htm", "expired/index.htm"} {
+		data.addLink(LinkRecord{Href: href})
+	}
+	data.Deadlines["soon/index.htm"] = time.Date(2026, 3, 4, 23, 59, 59, 0, time.Local)
+	data.Deadlines["later/index.htm"] = time.Date(2026, 3, 20, 23, 59, 59, 0, time.Local)
+	data.Deadlines["expired/index.htm"] = time.Date(2026, 3, 1, 23, 59, 59, 0, time.Local)
+	if err := saveSourceData(config, data); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, at := range []time.Time{now, now.Add(time.Hour), now.Add(24 * time.Hour)} {
+		if err := sendDeadlineReminders(ctx, &config, false, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	embeds := discord.Embeds()
+	if len(embeds) != 1 {
+		t.Fatalf("%d Erinnerungen gepostet, erwartet 1: %+v", len(embeds), embeds)
+	}
+	if !strings.Contains(embeds[0].Title, "Frist läuft bald ab: Soon") || !strings.Contains(embeds[0].Description, "04.03.2026") {
+		t.Errorf("Erinnerung: %+v", embeds[0])
+	}
+	saved, err := loadSourceData(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent := saved.RemindersSent["soon/index.htm"]; !sent.Equal(now) || len(saved.RemindersSent) != 1 {
+		t.Errorf("gesendete Erinnerungen: %v", saved.RemindersSent)
+	}
+}
+
+func TestNormalizeTitle(t *testing.T) {
+	all := TitleNormalization{CollapseWhitespace: true, StripTrailingColons: true, TitleCase: true}
+	tests := []struct {
+		title string
+		opts  TitleNormalization
+		want  string
+	}{
+		{"  Bekanntmachung  ", TitleNormalization{}, "Bekanntmachung"},
+		{"Verkauf\n  einer   Ackerfläche", TitleNormalization{}, "Verkauf\n  einer   Ackerfläche"},
+		{"Verkauf\n  einer   Ackerfläche", TitleNormalization{CollapseWhitespace: true}, "Verkauf einer Ackerfläche"},
+		{"Bekanntmachung::", TitleNormalization{StripTrailingColons: true}, "Bekanntmachung"},
+		{"Frist: 15.03.2025", TitleNormalization{StripTrailingColons: true}, "Frist: 15.03.2025"},
+		{"verkauf von ackerland in der gemarkung lohne:", all, "Verkauf von Ackerland in der Gemarkung Lohne"},
+		{"BEKANNTMACHUNG GEMARKUNG LOHNE", all, "Bekanntmachung Gemarkung Lohne"},
+		// Kurze Wörter in Großbuchstaben gelten als Abkürzung, gemischt geschriebene
+		// Eigennamen bleiben unverändert
+		{"BEKANNTMACHUNG DER LWK", all, "Bekanntmachung DER LWK"},
+		{"Flächen der LWK NRW in McAllister-Hof", all, "Flächen der LWK NRW in McAllister-Hof"},
+		{"der Hof am See", all, "Der Hof am See"},
+	}
+	for _, tt := range tests {
+		if got := normalizeTitle(tt.title, tt.opts); got != tt.want {
+			t.Errorf("normalizeTitle(%q, %+v) = %q, erwartet %q", tt.title, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestApprovalHandlers(t *testing.T) {
+	source := newFakeSource(t)
+	discord := fakeserver.NewDiscord()
+	config := sourceConfig(t, source, discord)
+	config.RequireApproval = true
+	config.AdminToken = "geheim"
+	approved := source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	rejected := source.setListing("Minden", "Grünland", "Verkauf von Grünland an eine GmbH.")
+	if _, err := checkWebsite(context.Background(), config, false); err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	registerApprovalHandlers(mux, config)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	do := func(method, path, token, accept string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	for _, token := range []string{"", "falsch"} {
+		if resp := do(http.MethodGet, "/queue", token, ""); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("GET /queue mit Token %q: %s", token, resp.Status)
+		}
+		if resp := do(http.MethodPost, "/approve?url="+approved, token, ""); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("POST /approve mit Token %q: %s", token, resp.Status)
+		}
+	}
+
+	resp := do(http.MethodGet, "/queue", "geheim", "")
+	var queue ApprovalQueue
+	if err := json.NewDecoder(resp.Body).Decode(&queue); err != nil {
+		t.Fatal(err)
+	}
+	if len(queue.Items) != 2 {
+		t.Fatalf("Warteschlange: %+v", queue.Items)
+	}
+	resp = do(http.MethodGet, "/queue?token=geheim", "", "text/html")
+	if page, _ := io.ReadAll(resp.Body); !strings.Contains(string(page), "Freigabe-Warteschlange (2)") {
+		t.Errorf("HTML-Ansicht: %s", page)
+	}
+
+	if resp := do(http.MethodGet, "/approve?url="+approved, "geheim", ""); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /approve: %s", resp.Status)
+	}
+	// Wiederholte Aktionen sind unschädlich
+	for range 2 {
+		if resp := do(http.MethodPost, "/approve?url="+approved, "geheim", ""); resp.StatusCode != http.StatusOK {
+			t.Errorf("POST /approve: %s", resp.Status)
+		}
+	}
+	resp = do(http.MethodPost, "/reject?url="+rejected+"&token=geheim", "", "text/html")
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/queue?token=geheim" {
+		t.Errorf("POST /reject aus dem Browser: %s, Location %q", resp.Status, resp.Header.Get("Location"))
+	}
+	if resp := do(http.MethodPost, "/reject?url=unbekannt/index.htm", "geheim", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("POST /reject für unbekannten Link: %s", resp.Status)
+	}
+	if resp := do(http.MethodPost, "/reject", "geheim", ""); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("POST /reject ohne url: %s", resp.Status)
+	}
+
+	queue, err := loadApprovalQueue(approvalQueueFile(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item := queue.find(approved); item == nil || !item.Approved || item.Rejected {
+		t.Errorf("freigegebener Eintrag: %+v", item)
+	}
+	if item := queue.find(rejected); item == nil || item.Approved || !item.Rejected {
+		t.Errorf("verworfener Eintrag: %+v", item)
+	}
+}
+
+func TestDetailFetchBudget(t *testing.T) {
+	source := newFakeSource(t)
+	discord := fakeserver.NewDiscord()
+	config := sourceConfig(t, source, discord)
+	config.MaxDetailFetchesPerRun = 2
+	var hrefs []string
+	for _, city := range []string{"Bielefeld", "Minden", "Soest", "Unna", "Hamm"} {
+		hrefs = append(hrefs, source.setListing(city, "Ackerfläche", "Verkauf einer Ackerfläche in "+city+"."))
+	}
+	ctx := context.Background()
+
+	for run, want := range []int{2, 4, 5} {
+		if _, err := checkWebsite(ctx, config, false); err != nil {
+			t.Fatal(err)
+		}
+		fetched := 0
+		for _, href := range hrefs {
+			fetched += source.fetches(href)
+		}
+		if fetched != want || len(discord.Embeds()) != want {
+			t.Errorf("Durchlauf %d: %d Detailseiten abgerufen, %d gepostet, erwartet je %d", run+1, fetched, len(discord.Embeds()), want)
+		}
+	}
+	data, err := loadSourceData(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.PendingLinks) != 0 || len(data.Links) != len(hrefs) {
+		t.Errorf("%d Links gespeichert, noch wartend: %v", len(data.Links), data.PendingLinks)
+	}
+}
+
+func TestStatsdPackets(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	source := newFakeSource(t)
+	discord := fakeserver.NewDiscord()
+	config := sourceConfig(t, source, discord)
+	config.StatsdAddr = conn.LocalAddr().String()
+	source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	source.setListing("Minden", "Grünland", "Verkauf von Grünland an eine GmbH.")
+
+	if _, err := checkWebsite(context.Background(), config, false); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(buf[:n]), "\n")
+	for _, want := range []string{
+		"gvgbot.checks:1|c",
+		"gvgbot.new_links:2|c",
+		"gvgbot.posted_links:2|c",
+		"gvgbot.failed_links:0|c",
+		"gvgbot.removed_links:0|c",
+		"gvgbot.current_links:2|g",
+		"gvgbot.stored_links:2|g",
+		"gvgbot.posts_total.discord:2|g",
+	} {
+		found := false
+		for _, line := range lines {
+			found = found || line == want
+		}
+		if !found {
+			t.Errorf("Metrik %q fehlt in %q", want, lines)
+		}
+	}
+}
+
+func TestRepostReturning(t *testing.T) {
+	for _, repost := range []bool{true, false} {
+		t.Run(fmt.Sprintf("repost=%v", repost), func(t *testing.T) {
+			source := newFakeSource(t)
+			discord := fakeserver.NewDiscord()
+			config := sourceConfig(t, source, discord)
+			config.RepostReturning = repost
+			href := source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+			source.setListing("Minden", "Grünland", "Verkauf von Grünland an eine GmbH.")
+			ctx := context.Background()
+
+			if _, err := checkWebsite(ctx, config, false); err != nil {
+				t.Fatal(err)
+			}
+			source.removeListing(href)
+			result, err := checkWebsite(ctx, config, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := loadSourceData(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, removed := data.RemovedLinks[href]; result.Removed != 1 || !removed {
+				t.Fatalf("nach dem Entfernen: %+v, entfernt: %v", result, data.RemovedLinks)
+			}
+
+			source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+			if _, err := checkWebsite(ctx, config, false); err != nil {
+				t.Fatal(err)
+			}
+			want := 2
+			if repost {
+				want = 3
+			}
+			if embeds := discord.Embeds(); len(embeds) != want {
+				t.Errorf("%d Posts, erwartet %d", len(embeds), want)
+			}
+			data, err = loadSourceData(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			record := data.record(href)
+			if record == nil {
+				t.Fatal("zurückgekehrter Link nicht gespeichert")
+			}
+			if !repost && record.Skipped != skipReturned {
+				t.Errorf("zurückgekehrter Link: %+v", record)
+			}
+			if _, removed := data.RemovedLinks[href]; removed {
+				t.Error("zurückgekehrter Link noch als entfernt vermerkt")
+			}
+		})
+	}
+}
+
+func TestFetchRejectsOversizedPage(t *testing.T) {
+	body := strings.Repeat("x", 1000)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+	config := testConfig(t)
+	ctx := context.Background()
+
+	config.MaxPageBytes = 999
+	if _, err := fetchSourceURL(ctx, config, server.URL); !errors.Is(err, ErrPageTooLarge) {
+		t.Errorf("Seite über dem Limit: %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("zu große Seite %d-mal abgerufen", n)
+	}
+	for _, limit := range []int64{1000, 0} {
+		config.MaxPageBytes = limit
+		if got, err := fetchSourceURL(ctx, config, server.URL); err != nil || got != body {
+			t.Errorf("Limit %d: %d Bytes, %v", limit, len(got), err)
+		}
+	}
+}
+
+func TestReceiptAppendedAfterPost(t *testing.T) {
+	source := newFakeSource(t)
+	mastodon := fakeserver.NewMastodon("mastodon-token")
+	defer mastodon.Close()
+	config := testConfig(t)
+	config.URL = source.URL
+	config.MastodonServer = mastodon.URL
+	config.MastodonAccessToken = "mastodon-token"
+	href := source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	before := time.Now()
+
+	if _, err := checkWebsite(context.Background(), config, false); err != nil {
+		t.Fatal(err)
+	}
+	statuses := mastodon.Statuses()
+	if len(statuses) != 1 {
+		t.Fatalf("Mastodon: %+v", statuses)
+	}
+	content, err := os.ReadFile(receiptsFile(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("%d Nachweise, erwartet 1: %q", len(lines), lines)
+	}
+	var receipt PostReceipt
+	if err := json.Unmarshal([]byte(lines[0]), &receipt); err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Platform != "mastodon" || receipt.Link != href || receipt.Text != statuses[0].Status {
+		t.Errorf("Nachweis: %+v", receipt)
+	}
+	if receipt.RemoteID == "" || !strings.HasPrefix(receipt.RemoteURL, mastodon.URL+"/@bot/") || !strings.HasSuffix(receipt.RemoteURL, "/"+receipt.RemoteID) {
+		t.Errorf("Remote-ID %q, URL %q", receipt.RemoteID, receipt.RemoteURL)
+	}
+	if receipt.Timestamp.Before(before) || receipt.Timestamp.After(time.Now()) {
+		t.Errorf("Zeitstempel %v", receipt.Timestamp)
+	}
+}
+
+func TestDryRunSnapshotCompare(t *testing.T) {
+	source := newFakeSource(t)
+	discord := fakeserver.NewDiscord()
+	config := sourceConfig(t, source, discord)
+	bielefeld := source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	minden := source.setListing("Minden", "Grünland", "Verkauf von Grünland an eine GmbH.")
+	snapshotFile := filepath.Join(t.TempDir(), "snapshot.json")
+
+	dryRun := func() []PlannedPost {
+		t.Helper()
+		isolated, cleanup, err := isolateDataFile(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cleanup()
+		result, err := checkWebsite(context.Background(), isolated, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.WouldPost
+	}
+
+	if err := saveSnapshot(dryRun(), snapshotFile); err != nil {
+		t.Fatal(err)
+	}
+	old, err := loadSnapshot(snapshotFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(old) != 2 {
+		t.Fatalf("Snapshot: %+v", old)
+	}
+	if diffs := compareSnapshots(old, dryRun()); len(diffs) != 0 {
+		t.Errorf("unveränderter Lauf: %q", diffs)
+	}
+	if len(discord.Embeds()) != 0 {
+		t.Error("Testlauf hat gepostet")
+	}
+	if _, err := os.Stat(config.DataFile); !os.IsNotExist(err) {
+		t.Errorf("Testlauf hat die Datendatei angelegt: %v", err)
+	}
+
+	// Neue Überschrift, ein entfernter und ein neuer Link
+	source.setPage(bielefeld, "<html><body><h1>Bielefeld</h1><hr><h3>Wald</h3><p>Verkauf von Wald.</p><hr></body></html>")
+	source.removeListing(minden)
+	soest := source.setListing("Soest", "Ackerland", "Verkauf von Ackerland.")
+	diffs := compareSnapshots(old, dryRun())
+	if len(diffs) != 3 {
+		t.Fatalf("Unterschiede: %q", diffs)
+	}
+	for _, prefix := range []string{"~ " + bielefeld + ": ", "+ " + soest + ": ", "- " + minden + ": "} {
+		found := false
+		for _, d := range diffs {
+			found = found || strings.HasPrefix(d, prefix)
+		}
+		if !found {
+			t.Errorf("kein Unterschied %q in %q", prefix, diffs)
+		}
+	}
+
+	// runSnapshot meldet Unterschiede als Fehler, statt den Prozess zu beenden
+	var out strings.Builder
+	err = runSnapshot(&out, config, "", snapshotFile)
+	if err == nil || !strings.Contains(out.String(), "3 Unterschiede zum Snapshot") {
+		t.Errorf("runSnapshot mit Unterschieden: %v, Ausgabe %q", err, out.String())
+	}
+	current := filepath.Join(t.TempDir(), "current.json")
+	out.Reset()
+	if err := runSnapshot(&out, config, current, current); err != nil || !strings.Contains(out.String(), "Keine Unterschiede") {
+		t.Errorf("runSnapshot ohne Unterschiede: %v, Ausgabe %q", err, out.String())
+	}
+	if err := runSnapshot(&out, config, "", filepath.Join(t.TempDir(), "fehlt.json")); err == nil {
+		t.Error("fehlender Snapshot ohne Fehler")
+	}
+}
+
+func TestDedupeByContentAcrossURLs(t *testing.T) {
+	for _, dedupe := range []bool{true, false} {
+		t.Run(fmt.Sprintf("dedupe=%v", dedupe), func(t *testing.T) {
+			source := newFakeSource(t)
+			discord := fakeserver.NewDiscord()
+			config := sourceConfig(t, source, discord)
+			config.DedupeByContentAcrossURLs = dedupe
+			page := "<html><body><h1>Soest</h1><hr><h3>Ackerfläche</h3><p>Gemarkung Lohne, Flur 3, Flurstück 112.</p><hr></body></html>"
+			source.setPage("lohne-soest/index.htm", page)
+			ctx := context.Background()
+			if _, err := checkWebsite(ctx, config, false); err != nil {
+				t.Fatal(err)
+			}
+			// Dieselbe Bekanntmachung erscheint später unter einem zweiten Kreis-Pfad
+			source.setPage("lohne-unna/index.htm", page)
+			if _, err := checkWebsite(ctx, config, false); err != nil {
+				t.Fatal(err)
+			}
+
+			want := 1
+			if !dedupe {
+				want = 2
+			}
+			embeds := discord.Embeds()
+			if len(embeds) != want || !strings.HasSuffix(embeds[0].URL, "lohne-soest/index.htm") {
+				t.Fatalf("Posts: %+v", embeds)
+			}
+			data, err := loadSourceData(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			record := data.record("lohne-unna/index.htm")
+			if record == nil || (dedupe && record.Skipped != skipDuplicate) || (!dedupe && record.Skipped != "") {
+				t.Errorf("zweiter Link: %+v", record)
+			}
+		})
+	}
+}
+
+func TestSlowPlatformDoesNotBlockOthers(t *testing.T) {
+	source := newFakeSource(t)
+	discord := fakeserver.NewDiscord()
+	config := sourceConfig(t, source, discord)
+	mastodon := fakeserver.NewMastodon("mastodon-token")
+	defer mastodon.Close()
+	config.MastodonServer = mastodon.URL
+	config.MastodonAccessToken = "mastodon-token"
+	for _, city := range []string{"Bielefeld", "Minden", "Soest"} {
+		source.setListing(city, "Ackerfläche", "Verkauf einer Ackerfläche in "+city+".")
+	}
+
+	// Discord antwortet erst, wenn release geschlossen wird
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		discord.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer slow.Close()
+	defer func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	}()
+	config.DiscordWebhookURL = slow.URL + strings.TrimPrefix(discord.WebhookURL(), discord.URL)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := checkWebsite(context.Background(), config, false)
+		done <- err
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(mastodon.Statuses()) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Mastodon: %d von 3 Posts, während Discord hängt", len(mastodon.Statuses()))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(discord.Embeds()); n != 0 {
+		t.Errorf("Discord hat vor der Freigabe %d Posts erhalten", n)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n := len(discord.Embeds()); n != 3 {
+		t.Errorf("Discord: %d Posts, erwartet 3", n)
+	}
+}
+
+func TestGzipArchiveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	config := testConfig(t)
+	markdown := "**Gemarkung Lohne**, Flur 3, Flurstück 112, 1,8 ha, Kaufpreis 27.000 €"
+	for _, compress := range []bool{false, true} {
+		config.CompressArchive = compress
+		if err := savePostAsJSON(config, dir, "Soest: Verkauf", markdown, "https://example.org/soest/index.htm", "kulturlandschaft"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plain, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	compressed, _ := filepath.Glob(filepath.Join(dir, "*.json.gz"))
+	if len(plain) != 1 || len(compressed) != 1 {
+		t.Fatalf("unkomprimiert %v, komprimiert %v", plain, compressed)
+	}
+
+	want, err := loadArchivedPost(plain[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := compactArchive(dir, 0, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if remaining, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(remaining) != 0 {
+		t.Errorf("nach dem Komprimieren unkomprimiert: %v", remaining)
+	}
+	compressed, _ = filepath.Glob(filepath.Join(dir, "*.json.gz"))
+	if len(compressed) != 2 {
+		t.Fatalf("komprimierte Posts: %v", compressed)
+	}
+	for _, filename := range compressed {
+		post, err := loadArchivedPost(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if post.Title != want.Title || post.Markdown != markdown || post.URL != want.URL || post.Details != want.Details {
+			t.Errorf("%s: %+v, erwartet %+v", filename, post, want)
+		}
+	}
+	if want.Details.Gemarkung != "Lohne" || want.Details.AreaSquareMeters != 18000 {
+		t.Errorf("Angaben: %+v", want.Details)
+	}
+}
+
+func TestCommandHelp(t *testing.T) {
+	if args, ok := os.LookupEnv("GVG_TEST_MAIN_ARGS"); ok {
+		// Kindprozess: main mit den übergebenen Argumenten ausführen
+		os.Args = append([]string{"gvg"}, strings.Fields(args)...)
+		main()
+		os.Exit(0)
+	}
+
+	tests := []struct {
+		args   []string
+		name   string
+		global bool
+	}{
+		{[]string{"-h"}, "run", true},
+		{[]string{"--help"}, "run", true},
+		{[]string{"help"}, "help", true},
+		{[]string{"list", "-h"}, "list", false},
+		{[]string{"run", "-help"}, "run", false},
+		{[]string{"--loop"}, "run", false},
+	}
+	for _, tt := range tests {
+		name, rest, explicit := parseCommandLine(tt.args)
+		if name != tt.name || isGlobalHelp(name, rest, explicit) != tt.global {
+			t.Errorf("%v: Befehl %s, allgemeine Hilfe %v; erwartet %s, %v", tt.args, name, isGlobalHelp(name, rest, explicit), tt.name, tt.global)
+		}
+	}
+
+	// "list -h" zeigt die Flags von list statt der allgemeinen Hilfe
+	cmd := exec.Command(os.Args[0], "-test.run=^TestCommandHelp$")
+	cmd.Env = append(os.Environ(), "GVG_TEST_MAIN_ARGS=list -h")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("list -h: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "-since") || strings.Contains(string(out), "Commands:") {
+		t.Errorf("list -h gibt nicht die Flags von list aus:\n%s", out)
+	}
+}
+
+func TestSubcommandDispatch(t *testing.T) {
+	config := testConfig(t)
+	if err := saveConfig(config, config.configFile); err != nil {
+		t.Fatal(err)
+	}
+	previous := configFile
+	configFile = config.configFile
+	t.Cleanup(func() {
+		configFile = previous
+		applyConfig(DefaultConfig())
+	})
+	data := newLinkData()
+	data.addLink(LinkRecord{Href: "bielefeld/index.htm"})
+	if err := saveSourceData(config, data); err != nil {
+		t.Fatal(err)
+	}
+	err := updateApprovalQueue(config, func(q *ApprovalQueue) error {
+		q.Items = append(q.Items, ApprovalItem{Href: "minden/index.htm"})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(name string, args ...string) error {
+		t.Helper()
+		cmd, ok := findSubcommand(name)
+		if !ok {
+			t.Fatalf("Unterbefehl %s nicht gefunden", name)
+		}
+		return cmd.run(args)
+	}
+
+	csvFile := filepath.Join(t.TempDir(), "links.csv")
+	if err := run("export", csvFile); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := os.ReadFile(csvFile); err != nil || !strings.Contains(string(content), "bielefeld/index.htm,"+config.URL+"/bielefeld/index.htm") {
+		t.Errorf("export: %q, %v", content, err)
+	}
+	if err := run("export"); err == nil {
+		t.Error("export ohne Zieldatei ohne Fehler")
+	}
+
+	if err := run("approve", "minden/index.htm"); err != nil {
+		t.Fatal(err)
+	}
+	queue, err := loadApprovalQueue(approvalQueueFile(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item := queue.find("minden/index.htm"); item == nil || !item.Approved {
+		t.Errorf("approve: %+v", item)
+	}
+	if err := run("reject", "soest/index.htm"); err == nil {
+		t.Error("reject eines nicht wartenden Links ohne Fehler")
+	}
+
+	if _, ok := findSubcommand("unbekannt"); ok {
+		t.Error("unbekannter Unterbefehl gefunden")
+	}
+}
+
+func TestExtractHRSections(t *testing.T) {
+	tests := []struct {
+		fixture    string
+		start, end int
+		title      string
+		text       string
+	}{
+		{"listing_three_hr.html", 2, 3, "Verkauf einer Ackerfläche", "Flurstück 17"},
+		{"listing_four_hr.html", 3, 4, "Verkauf von Grünland", "Flurstück 210/3"},
+	}
+	for _, tt := range tests {
+		page, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		config := testConfig(t)
+
+		// Standard: erster bis zweiter <hr>
+		if _, text, err := extractListingText(string(page), config); err != nil || !strings.Contains(text, "Zurück zur Übersicht") {
+			t.Errorf("%s, Standardabschnitt: %q, %v", tt.fixture, text, err)
+		}
+
+		config.HRSectionStart, config.HRSectionEnd = tt.start, tt.end
+		title, text, err := extractListingText(string(page), config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if title != tt.title || !strings.Contains(text, tt.text) || strings.Contains(text, "Übersicht") || strings.Contains(text, "Impressum") {
+			t.Errorf("%s, Abschnitt %d bis %d: Titel %q, Text %q", tt.fixture, tt.start, tt.end, title, text)
+		}
+	}
+}
+
+func TestExtractWithoutHR(t *testing.T) {
+	page, err := os.ReadFile("testdata/listing_no_hr.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, xpath := range []string{"", `//div[@class="content"]`} {
+		config := testConfig(t)
+		config.ContentXPath = xpath
+		title, text, err := extractListingText(string(page), config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if title != "Verkauf einer Waldfläche" || !strings.Contains(text, "Flurstück 33") || !strings.Contains(text, "bis zum 30.04.2026") {
+			t.Errorf("content_xpath %q: Titel %q, Text %q", xpath, title, text)
+		}
+		for _, noise := range []string{"Startseite", "Impressum", "Weitere Bekanntmachungen"} {
+			if strings.Contains(text, noise) {
+				t.Errorf("content_xpath %q: Text enthält %q: %q", xpath, noise, text)
+			}
+		}
+	}
+
+	config := testConfig(t)
+	config.ContentXPath = "//div["
+	if _, _, err := extractListingText(string(page), config); err == nil {
+		t.Error("ungültiger content_xpath ohne Fehler")
+	}
+}
+
+func TestDisclaimerSurvivesTruncation(t *testing.T) {
+	const disclaimer = "Automatischer Post. Quelle und Kontakt: https://github.com/mdornseif/grundstueckverkehrsgesetz-nrw"
+	config := testConfig(t)
+	config.Disclaimer = disclaimer
+	config.PlatformLimits = map[string]int{"bluesky": 160}
+	long := strings.Repeat("Verkauf einer Ackerfläche an einen Nicht-Landwirt. ", 20)
+
+	got := fitForPlatform(config, "bluesky", long)
+	if !strings.HasSuffix(got, "\n\n"+disclaimer) || utf8.RuneCountInString(got) > 160 {
+		t.Errorf("gekürzt auf %d Zeichen: %q", utf8.RuneCountInString(got), got)
+	}
+	// Passt der Disclaimer allein nicht, wird er selbst gekürzt statt ganz zu fehlen
+	if got := fitToLimit(config, "bluesky", long, 40); utf8.RuneCountInString(got) > 40 || !strings.HasPrefix(got, "Automatischer Post.") {
+		t.Errorf("Limit kleiner als der Disclaimer: %q", got)
+	}
+
+	source := newFakeSource(t)
+	bluesky := fakeserver.NewBluesky("gvgbot.example.org", "bluesky-pw")
+	defer bluesky.Close()
+	config.URL = source.URL
+	config.BlueskyPDS = bluesky.URL
+	config.BlueskyHandle = "gvgbot.example.org"
+	config.BlueskyPassword = "bluesky-pw"
+	source.setListing("Bielefeld", "Ackerfläche", long)
+	if _, err := checkWebsite(context.Background(), config, false); err != nil {
+		t.Fatal(err)
+	}
+	records := bluesky.Records()
+	if len(records) != 1 {
+		t.Fatalf("Bluesky: %+v", records)
+	}
+	if text := records[0].Text; !strings.Contains(text, "\n\n"+disclaimer+"\n") || utf8.RuneCountInString(text) > 160 {
+		t.Errorf("Bluesky-Post mit %d Zeichen: %q", utf8.RuneCountInString(text), text)
+	}
+}
+
+func TestCheckSourcesConcurrently(t *testing.T) {
+	first, second := newFakeSource(t), newFakeSource(t)
+	discord := fakeserver.NewDiscord()
+	config := sourceConfig(t, first, discord)
+	config.SourceConcurrency = 2
+	config.Sources = []SourceConfig{{URL: second.URL, DataFile: filepath.Join(t.TempDir(), "second.json")}}
+	bielefeld := first.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	minden := second.setListing("Minden", "Grünland", "Verkauf von Grünland an eine GmbH.")
+
+	// Beide Übersichtsseiten warten aufeinander; nacheinander überprüft liefe die erste in den Timeout
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	var sequential atomic.Bool
+	barrier := func() {
+		arrived.Done()
+		done := make(chan struct{})
+		go func() { arrived.Wait(); close(done) }()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			sequential.Store(true)
+		}
+	}
+	first.onIndex, second.onIndex = barrier, barrier
+
+	result, err := checkWebsite(context.Background(), config, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sequential.Load() {
+		t.Error("Quellen wurden nicht gleichzeitig überprüft")
+	}
+	if result.Checked != 2 || result.New != 2 || result.Posted != 2 {
+		t.Errorf("Ergebnis: %+v", result)
+	}
+	if embeds := discord.Embeds(); len(embeds) != 2 {
+		t.Errorf("Discord: %+v", embeds)
+	}
+
+	for _, source := range sourceConfigs(config) {
+		data, err := loadSourceData(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, other := bielefeld, minden
+		if source.URL == second.URL {
+			want, other = minden, bielefeld
+		}
+		if len(data.Links) != 1 || data.record(want) == nil || data.record(other) != nil {
+			t.Errorf("%s: %+v", source.URL, data.Links)
+		}
+	}
+}
+
+func TestGoToSocialQuirks(t *testing.T) {
+	tests := []struct {
+		flavor, visibility, want string
+	}{
+		{"mastodon", "mutuals_only", "private"},
+		{"mastodon", "local", "unlisted"},
+		{"gotosocial", "mutuals_only", "mutuals_only"},
+		{"gotosocial", "local", "unlisted"},
+		{"akkoma", "local", "local"},
+		{"gotosocial", "", ""},
+	}
+	for _, tt := range tests {
+		config := Config{MastodonFlavor: tt.flavor, MastodonVisibility: tt.visibility}
+		if got := postVisibility(config); got != tt.want {
+			t.Errorf("postVisibility(%s, %q) = %q, erwartet %q", tt.flavor, tt.visibility, got, tt.want)
+		}
+	}
+
+	source := newFakeSource(t)
+	gts := fakeserver.NewGoToSocial("gts-token")
+	defer gts.Close()
+	config := testConfig(t)
+	config.URL = source.URL
+	config.MastodonServer = gts.URL
+	config.MastodonAccessToken = "gts-token"
+	config.MastodonFlavor = "gotosocial"
+	config.MastodonVisibility = "local"
+	config.MastodonImages = true
+	source.setPage("flurkarte.png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	source.setPage("soest/index.htm", `<html><body><h1>Soest</h1><hr><h3>Ackerfläche</h3><p>Verkauf von 2 ha Ackerland.</p><img src="../flurkarte.png" alt="Flurkarte"><hr></body></html>`)
+
+	if _, err := checkWebsite(context.Background(), config, false); err != nil {
+		t.Fatal(err)
+	}
+	statuses := gts.Statuses()
+	if len(statuses) != 1 {
+		t.Fatalf("GoToSocial: %+v", statuses)
+	}
+	if statuses[0].Visibility != "unlisted" {
+		t.Errorf("Sichtbarkeit %q, erwartet unlisted", statuses[0].Visibility)
+	}
+	if gts.MediaUploads() != 1 || len(statuses[0].MediaIDs) != 1 {
+		t.Errorf("%d Bilder hochgeladen, Medien am Beitrag: %v", gts.MediaUploads(), statuses[0].MediaIDs)
+	}
+}
+
+func TestStaticMapAttachment(t *testing.T) {
+	var mu sync.Mutex
+	var geocodeQuery string
+	var mapRequests []string
+	mapFails := false
+	services := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/search":
+			geocodeQuery = r.URL.Query().Get("q")
+			fmt.Fprint(w, `[{"lat": "51.5634", "lon": "8.1234567"}]`)
+		case "/staticmap":
+			mapRequests = append(mapRequests, r.URL.RequestURI())
+			if mapFails {
+				http.Error(w, "kaputt", http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprint(w, "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer services.Close()
+
+	source := newFakeSource(t)
+	mastodon := fakeserver.NewMastodon("mastodon-token")
+	defer mastodon.Close()
+	config := testConfig(t)
+	config.URL = source.URL
+	config.MastodonServer = mastodon.URL
+	config.MastodonAccessToken = "mastodon-token"
+	config.GeocodeURL = services.URL + "/search?format=jsonv2&q={query}"
+	config.StaticMapTemplate = services.URL + "/staticmap?center={lat},{lon}&zoom={zoom}&size=600x400"
+	config.StaticMapZoom = 15
+	source.setListing("Soest", "Ackerfläche", "Verkauf von 2 ha Ackerland, Gemarkung Ostönnen, Flur 3, Flurstück 12.")
+
+	if _, err := checkWebsite(context.Background(), config, false); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	if geocodeQuery != "Ostönnen, Soest, Nordrhein-Westfalen" {
+		t.Errorf("Geocoding-Anfrage %q", geocodeQuery)
+	}
+	if want := "/staticmap?center=51.563400,8.123457&zoom=15&size=600x400"; len(mapRequests) != 1 || mapRequests[0] != want {
+		t.Errorf("Kartenabrufe %v, erwartet %s", mapRequests, want)
+	}
+	mapFails = true
+	mu.Unlock()
+	statuses := mastodon.Statuses()
+	if len(statuses) != 1 || len(statuses[0].MediaIDs) != 1 {
+		t.Fatalf("Mastodon: %+v", statuses)
+	}
+	if image, err := staticMapImage(context.Background(), config, PostData{City: "Soest", Gemarkung: "Ostönnen"}); err != nil || image.Alt != "Karte: ungefähre Lage des Grundstücks (Gemarkung Ostönnen, Soest)" {
+		t.Errorf("Bildbeschreibung %q, %v", image.Alt, err)
+	}
+
+	// Eine nicht abrufbare Karte verhindert den Post nicht
+	source.setListing("Unna", "Grünland", "Verkauf von Grünland, Gemarkung Massen.")
+	if _, err := checkWebsite(context.Background(), config, false); err != nil {
+		t.Fatal(err)
+	}
+	statuses = mastodon.Statuses()
+	if len(statuses) != 2 || len(statuses[1].MediaIDs) != 0 {
+		t.Errorf("Post ohne Karte: %+v", statuses)
+	}
+}
+
+func TestDoctorReport(t *testing.T) {
+	source := newFakeSource(t)
+	source.setPage("robots.txt", "User-agent: *\nDisallow: /intern/\n")
+	config := testConfig(t)
+	config.URL = source.URL
+	fakes := withPlatformFakes(t, &config)
+	config.MastodonAccessToken = "abgelaufen"
+	skewed := func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	checks := runDoctor(config, skewed)
+	status := map[string]string{}
+	for _, c := range checks {
+		name, _, _ := strings.Cut(c.Name, " ")
+		status[name] = c.Status
+		if c.Status != doctorPass && c.Hint == "" {
+			t.Errorf("%s (%s) ohne Hinweis zur Behebung", c.Name, c.Status)
+		}
+	}
+	want := map[string]string{
+		"Konfiguration":    doctorPass,
+		"Datenverzeichnis": doctorPass,
+		"Quelle":           doctorPass,
+		"robots.txt":       doctorPass,
+		"Uhrzeit":          doctorWarn,
+		"Lemmy":            doctorPass,
+		"Mastodon":         doctorFail,
+		"Bluesky":          doctorPass,
+		"Telegram":         doctorPass,
+		"Discord":          doctorPass,
+	}
+	for name, w := range want {
+		if status[name] != w {
+			t.Errorf("%s: %q, erwartet %q", name, status[name], w)
+		}
+	}
+	if len(checks) != len(want) {
+		t.Errorf("%d Prüfungen, erwartet %d: %+v", len(checks), len(want), checks)
+	}
+
+	var report strings.Builder
+	if !printDoctorReport(&report, checks) {
+		t.Error("Bericht ohne Fehlschlag trotz fehlgeschlagener Mastodon-Anmeldung")
+	}
+	for _, line := range []string{"✅ Konfiguration: gültig\n", "⚠️  Uhrzeit: weicht um 2h0m", "❌ Mastodon", "   → "} {
+		if !strings.Contains(report.String(), line) {
+			t.Errorf("Bericht enthält %q nicht:\n%s", line, report.String())
+		}
+	}
+
+	// doctor postet nichts
+	if len(fakes.lemmy.Posts())+len(fakes.mastodon.Statuses())+len(fakes.bluesky.Records())+len(fakes.telegram.Messages())+len(fakes.discord.Embeds()) != 0 {
+		t.Error("doctor hat gepostet")
+	}
+	if source.fetches("robots.txt") == 0 {
+		t.Error("robots.txt nicht abgerufen")
+	}
+}
+
+// headerRecorder leitet Anfragen an einen Fake weiter und merkt sich deren Header
+type headerRecorder struct {
+	*httptest.Server
+	mu      sync.Mutex
+	headers []http.Header
+}
+
+func newHeaderRecorder(t *testing.T, target *httptest.Server) *headerRecorder {
+	t.Helper()
+	h := &headerRecorder{}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.mu.Lock()
+		h.headers = append(h.headers, r.Header.Clone())
+		h.mu.Unlock()
+		target.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(h.Close)
+	return h
+}
+
+// seen gibt alle Werte des Headers name aus den bisherigen Anfragen zurück
+func (h *headerRecorder) seen(name string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var values []string
+	for _, header := range h.headers {
+		values = append(values, header.Values(name)...)
+	}
+	return values
+}
+
+func TestSourceHeadersOnlySentToSource(t *testing.T) {
+	source := newFakeSource(t)
+	config := testConfig(t)
+	config.URL = source.URL
+	fakes := withPlatformFakes(t, &config)
+	lemmy := newHeaderRecorder(t, fakes.lemmy.Server)
+	mastodon := newHeaderRecorder(t, fakes.mastodon.Server)
+	config.LemmyServer = lemmy.URL
+	config.MastodonServer = mastodon.URL
+	config.SourceHeaders = map[string]string{
+		"Referer": "https://www.landwirtschaftskammer.de/",
+		"cookie":  "session=mirror",
+	}
+	href := source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+
+	if _, err := checkWebsite(context.Background(), config, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(fakes.lemmy.Posts()) != 1 || len(fakes.mastodon.Statuses()) != 1 {
+		t.Fatalf("Lemmy %d, Mastodon %d Posts", len(fakes.lemmy.Posts()), len(fakes.mastodon.Statuses()))
+	}
+	for _, path := range []string{"", href} {
+		header := source.requestHeader(path)
+		if header.Get("Referer") != "https://www.landwirtschaftskammer.de/" || header.Get("Cookie") != "session=mirror" {
+			t.Errorf("Header an die Quelle (/%s): %v", path, header)
+		}
+	}
+	for name, recorder := range map[string]*headerRecorder{"Lemmy": lemmy, "Mastodon": mastodon} {
+		if len(recorder.seen("User-Agent")) == 0 {
+			t.Fatalf("keine Anfragen an %s", name)
+		}
+		for _, header := range []string{"Referer", "Cookie", "Accept-Language"} {
+			if values := recorder.seen(header); len(values) != 0 {
+				t.Errorf("%s erhielt %s: %v", name, header, values)
+			}
+		}
+	}
+}
+
+func TestSourceAcceptLanguage(t *testing.T) {
+	source := newFakeSource(t)
+	config := testConfig(t)
+	config.URL = source.URL
+	ctx := context.Background()
+
+	if _, err := fetchSourceURL(ctx, config, source.URL+"/"); err != nil {
+		t.Fatal(err)
+	}
+	if got := source.requestHeader("").Get("Accept-Language"); got != "de-DE,de;q=0.9" {
+		t.Errorf("Standard-Accept-Language %q", got)
+	}
+
+	config.SourceHeaders = map[string]string{"accept-language": "de-AT"}
+	if _, err := fetchSourceURL(ctx, config, source.URL+"/"); err != nil {
+		t.Fatal(err)
+	}
+	if got := source.requestHeader("").Values("Accept-Language"); len(got) != 1 || got[0] != "de-AT" {
+		t.Errorf("überschriebene Accept-Language %q", got)
+	}
+}
+
+func TestPostCountersAccumulate(t *testing.T) {
+	source := newFakeSource(t)
+	discord := fakeserver.NewDiscord()
+	config := sourceConfig(t, source, discord)
+	mastodon := fakeserver.NewMastodon("mastodon-token")
+	defer mastodon.Close()
+	config.MastodonServer = mastodon.URL
+	config.MastodonAccessToken = "mastodon-token"
+	ctx := context.Background()
+	run := func() {
+		t.Helper()
+		if _, err := checkWebsite(ctx, config, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	source.setListing("Minden", "Grünland", "Verkauf von Grünland an eine GmbH.")
+	run()
+	// Der nächste Link scheitert zunächst auf Mastodon und wird dort im folgenden Lauf nachgeholt
+	source.setListing("Soest", "Wald", "Verkauf von Wald.")
+	mastodon.FailNext("/api/v1/statuses", http.StatusInternalServerError, 1)
+	run()
+	run()
+
+	data, err := loadSourceData(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.PostCounts["mastodon"] != 3 || data.PostCounts["discord"] != 3 {
+		t.Fatalf("Zähler: %v", data.PostCounts)
+	}
+
+	rec := httptest.NewRecorder()
+	statusCache.ServeStatus(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	var status struct {
+		PostCounts map[string]int `json:"post_counts"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.PostCounts["mastodon"] != 3 || status.PostCounts["discord"] != 3 {
+		t.Errorf("/status: %s", rec.Body.String())
+	}
+
+	// Aufräumen und Neustart setzen die Zähler nicht zurück
+	source.removeListing("bielefeld/index.htm")
+	run()
+	data, err = loadSourceData(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pruneLinkData(&data, 0, time.Now().Add(time.Hour))
+	if err := saveSourceData(config, data); err != nil {
+		t.Fatal(err)
+	}
+	data, err = loadSourceData(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.PostCounts["mastodon"] != 3 || data.PostCounts["discord"] != 3 {
+		t.Errorf("Zähler nach dem Aufräumen: %v", data.PostCounts)
+	}
+}
+
+func TestRequireConfirmProd(t *testing.T) {
+	source := newFakeSource(t)
+	mastodon := fakeserver.NewMastodon("mastodon-token")
+	defer mastodon.Close()
+	config := testConfig(t)
+	config.URL = source.URL
+	config.MastodonServer = mastodon.URL
+	config.MastodonAccessToken = "mastodon-token"
+	config.RequireConfirmProd = true
+	config.ProductionTargets = []string{strings.TrimPrefix(mastodon.URL, "http://")}
+	source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	if err := saveConfig(config, config.configFile); err != nil {
+		t.Fatal(err)
+	}
+	previous := configFile
+	configFile = config.configFile
+	t.Cleanup(func() {
+		configFile = previous
+		applyConfig(DefaultConfig())
+	})
+	check, _ := findSubcommand("check")
+
+	if err := check.run(nil); err == nil || !strings.Contains(err.Error(), "-yes-post-to-prod") {
+		t.Errorf("ohne Bestätigung: %v", err)
+	}
+	if n := len(mastodon.Statuses()); n != 0 {
+		t.Fatalf("ohne Bestätigung %d Posts", n)
+	}
+	if err := checkProdConfirmation(config, true, false); err != nil {
+		t.Errorf("Testmodus: %v", err)
+	}
+	other := config
+	other.ProductionTargets = []string{"social.example.org"}
+	if err := checkProdConfirmation(other, false, false); err != nil {
+		t.Errorf("kein Produktivziel: %v", err)
+	}
+
+	if err := check.run([]string{"-yes-post-to-prod"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(mastodon.Statuses()); n != 1 {
+		t.Errorf("mit Bestätigung %d Posts, erwartet 1", n)
+	}
+}
+
+func TestExcerptText(t *testing.T) {
+	const more = " … (mehr: https://example.org/soest/index.htm)"
+	tests := []struct {
+		text   string
+		length int
+		want   string
+	}{
+		{"Verkauf von Ackerland.", 0, "Verkauf von Ackerland."},
+		{"Verkauf von Ackerland.", 22, "Verkauf von Ackerland."},
+		// Schnitt am letzten Satzende im Limit, Datumsangaben gelten nicht als Satzende
+		{"Flurstück 112 in Lohne. Größe 1,8 ha. Frist bis zum 15.03.2026 beachten.", 50, "Flurstück 112 in Lohne. Größe 1,8 ha." + more},
+		{"Gemarkung Lohne! Flurstück 112? Größe 1,8 ha.", 33, "Gemarkung Lohne! Flurstück 112?" + more},
+		// Ohne Satzende am letzten Leerzeichen
+		{"Verkauf einer Ackerfläche an einen Nicht-Landwirt", 30, "Verkauf einer Ackerfläche an" + more},
+		// Umlaute zählen als ein Zeichen
+		{"Größe äußerst übersichtlich öffentlich", 14, "Größe äußerst" + more},
+		{"Grundstückverkehrsgesetz", 10, "Grundstück" + more},
+	}
+	for _, tt := range tests {
+		if got := excerptText(tt.text, tt.length, "https://example.org/soest/index.htm"); got != tt.want {
+			t.Errorf("excerptText(%q, %d) = %q, erwartet %q", tt.text, tt.length, got, tt.want)
+		}
+	}
+}
+
+func TestRunMonitoringStopsOnSignal(t *testing.T) {
+	// Die Übersichtsseite antwortet erst, wenn der Client die Verbindung abbricht
+	arrived := make(chan struct{}, 1)
+	hang := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case arrived <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	defer hang.Close()
+	config := testConfig(t)
+	config.URL = hang.URL
+	config.CheckInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigChan := make(chan os.Signal, 1)
+	go cancelOnSignal(sigChan, cancel)
+	done := make(chan error, 1)
+	go func() { done <- runMonitoring(ctx, config, true) }()
+
+	select {
+	case <-arrived:
+	case <-time.After(5 * time.Second):
+		t.Fatal("keine Anfrage an die Quelle")
+	}
+	sigChan <- syscall.SIGTERM
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("runMonitoring: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("runMonitoring hat sich nach dem Signal nicht beendet")
+	}
+}
+
+func TestUserAgentOnAllRequests(t *testing.T) {
+	source := newFakeSource(t)
+	config := testConfig(t)
+	config.URL = source.URL
+	fakes := withPlatformFakes(t, &config)
+	lemmy := newHeaderRecorder(t, fakes.lemmy.Server)
+	mastodon := newHeaderRecorder(t, fakes.mastodon.Server)
+	config.LemmyServer = lemmy.URL
+	config.MastodonServer = mastodon.URL
+	config.UserAgent = "testbot/2.0"
+	if err := applyConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { applyConfig(DefaultConfig()) })
+	href := source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+
+	if _, err := checkWebsite(context.Background(), config, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"", href} {
+		if got := source.requestHeader(path).Get("User-Agent"); got != "testbot/2.0" {
+			t.Errorf("User-Agent an die Quelle (/%s): %q", path, got)
+		}
+	}
+	for name, recorder := range map[string]*headerRecorder{"Lemmy": lemmy, "Mastodon": mastodon} {
+		values := recorder.seen("User-Agent")
+		if len(values) == 0 {
+			t.Fatalf("keine Anfragen an %s", name)
+		}
+		for _, value := range values {
+			if value != "testbot/2.0" {
+				t.Errorf("User-Agent an %s: %q", name, value)
+			}
+		}
+	}
+
+	// Ohne Angabe in der Konfiguration gilt der Standard
+	if err := applyConfig(DefaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fetchURL(context.Background(), source.URL+"/", 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := source.requestHeader("").Get("User-Agent"); got != defaultUserAgent {
+		t.Errorf("Standard-User-Agent: %q", got)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	config := testConfig(t)
+	dir := filepath.Dir(config.DataFile)
+	leftovers := func() []string {
+		t.Helper()
+		matches, err := filepath.Glob(filepath.Join(dir, ".*.tmp-*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return matches
+	}
+
+	data := newLinkData()
+	data.addLink(LinkRecord{Href: "bielefeld/index.htm", City: "Bielefeld"})
+	if err := saveLinkData(data, config.DataFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(config.DataFile, 0600); err != nil {
+		t.Fatal(err)
+	}
+	data.addLink(LinkRecord{Href: "soest/index.htm", City: "Soest"})
+	if err := saveLinkData(data, config.DataFile); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadLinkData(config.DataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.record("soest/index.htm") == nil {
+		t.Error("zweiter Link fehlt nach dem Speichern")
+	}
+	if info, err := os.Stat(config.DataFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Rechte der Link-Datei nicht erhalten: %v %v", info.Mode(), err)
+	}
+
+	if err := saveConfig(config, config.configFile); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(config.configFile); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("Rechte der neuen Konfiguration: %v %v", info.Mode(), err)
+	}
+	if files := leftovers(); len(files) != 0 {
+		t.Errorf("temporäre Dateien übrig: %v", files)
+	}
+
+	// Scheitert das Umbenennen, bleibt keine halb geschriebene Datei zurück
+	target := filepath.Join(dir, "ziel")
+	if err := os.Mkdir(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(target, "inhalt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(target, []byte("{}"), 0644); err == nil {
+		t.Error("Umbenennen über ein Verzeichnis ohne Fehler")
+	}
+	if files := leftovers(); len(files) != 0 {
+		t.Errorf("temporäre Dateien nach Fehler übrig: %v", files)
+	}
+}
+
+func TestMastodonTokenRefresh(t *testing.T) {
+	source := newFakeSource(t)
+	mastodon := fakeserver.NewMastodon("token-1")
+	defer mastodon.Close()
+	// Kürzer als mastodonTokenRefreshMargin: beim nächsten Durchlauf gilt das Token als abgelaufen
+	mastodon.ExpiresIn = 30
+	config := testConfig(t)
+	config.URL = source.URL
+	config.MastodonServer = mastodon.URL
+	config.MastodonAccessToken = "abgelaufen"
+	config.MastodonTokenExp = time.Now().Add(-time.Minute)
+	config.MastodonUsername = "bot"
+	config.MastodonPassword = "geheim"
+	config.MastodonClientID = "client"
+	config.MastodonClientSecret = "secret"
+	if err := saveConfig(config, config.configFile); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	if _, err := checkWebsite(ctx, config, false); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(mastodon.Statuses()); n != 1 {
+		t.Fatalf("%d Posts nach dem ersten Durchlauf, erwartet 1", n)
+	}
+	saved, err := readConfig(config.configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.MastodonAccessToken != "token-1" {
+		t.Errorf("gespeichertes Token %q, erwartet token-1", saved.MastodonAccessToken)
+	}
+	if remaining := time.Until(saved.MastodonTokenExp); remaining <= 0 || remaining > 30*time.Second {
+		t.Errorf("Ablaufzeit %v, erwartet in höchstens 30s", saved.MastodonTokenExp)
+	}
+
+	// Der Server akzeptiert nur noch das neue Token; der zweite Durchlauf muss sich neu anmelden
+	mastodon.Token = "token-2"
+	source.setListing("Soest", "Grünland", "Verkauf von Grünland an einen Nicht-Landwirt.")
+	if _, err := checkWebsite(ctx, saved, false); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(mastodon.Statuses()); n != 2 {
+		t.Fatalf("%d Posts nach dem zweiten Durchlauf, erwartet 2", n)
+	}
+	saved, err = readConfig(config.configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.MastodonAccessToken != "token-2" {
+		t.Errorf("gespeichertes Token %q, erwartet token-2", saved.MastodonAccessToken)
+	}
+
+	// Ein noch lange gültiges Token wird weiterverwendet
+	saved.MastodonTokenExp = time.Now().Add(time.Hour)
+	if mastodonTokenExpired(saved, time.Now()) {
+		t.Error("Token mit einer Stunde Restlaufzeit gilt als abgelaufen")
+	}
+	saved.MastodonTokenExp = time.Time{}
+	if mastodonTokenExpired(saved, time.Now()) {
+		t.Error("Token ohne Ablaufzeit gilt als abgelaufen")
+	}
+}
+
+func TestRateLimitedRequests(t *testing.T) {
+	source := newFakeSource(t)
+	platform := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer platform.Close()
+	config := testConfig(t)
+	config.RequestsPerSecond = 20
+	config.PlatformRequestsPerSecond = 10
+	if err := applyConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { applyConfig(DefaultConfig()) })
+	ctx := context.Background()
+
+	// 5 Anfragen bei 20/s: die erste sofort, danach je 50ms Abstand
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := fetchURL(ctx, source.URL+"/", 0, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("5 Anfragen an die Quelle in %v, erwartet mindestens 200ms", elapsed)
+	}
+
+	// Plattform-Anfragen haben ein eigenes Limit: 4 Anfragen bei 10/s
+	start = time.Now()
+	for i := 0; i < 4; i++ {
+		req, err := newRequest(ctx, http.MethodGet, platform.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := doRequest(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("4 Plattform-Anfragen in %v, erwartet mindestens 300ms", elapsed)
+	}
+
+	// Das Warten bricht mit dem Kontext ab
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := fetchURL(cancelled, source.URL+"/", 0, nil); err == nil {
+		t.Error("fetchURL mit abgebrochenem Kontext ohne Fehler")
+	}
+}
+
+func TestEnvSecretsOverrideFile(t *testing.T) {
+	config := testConfig(t)
+	config.LemmyPassword = "aus-der-datei"
+	config.MastodonClientSecret = "datei-secret"
+	config.TelegramBotToken = "datei-telegram"
+	if err := saveConfig(config, config.configFile); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GVG_LEMMY_PASSWORD", "aus-der-umgebung")
+	t.Setenv("GVG_MASTODON_CLIENT_SECRET", "umgebung-secret")
+	t.Setenv("GVG_MASTODON_ACCESS_TOKEN", "umgebung-token")
+	// Leere Variablen überschreiben nichts
+	t.Setenv("GVG_TELEGRAM_BOT_TOKEN", "")
+
+	loaded, err := readConfig(config.configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.LemmyPassword != "aus-der-umgebung" || loaded.MastodonClientSecret != "umgebung-secret" || loaded.MastodonAccessToken != "umgebung-token" {
+		t.Errorf("Umgebung nicht übernommen: %q %q %q", loaded.LemmyPassword, loaded.MastodonClientSecret, loaded.MastodonAccessToken)
+	}
+	if loaded.TelegramBotToken != "datei-telegram" {
+		t.Errorf("TelegramBotToken = %q, erwartet den Dateiwert", loaded.TelegramBotToken)
+	}
+
+	// Geheimnisse aus der Umgebung landen beim Speichern nicht in der Datei,
+	// zur Laufzeit neu geholte Tokens schon
+	loaded.MastodonAccessToken = "neu-geholt"
+	if err := saveConfig(loaded, config.configFile); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(config.configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"aus-der-umgebung", "umgebung-secret"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("%q in die Datei geschrieben", secret)
+		}
+	}
+	for _, value := range []string{"aus-der-datei", "datei-secret", "neu-geholt"} {
+		if !strings.Contains(string(raw), value) {
+			t.Errorf("%q fehlt in der Datei", value)
+		}
+	}
+}
+
+func TestRelativeLinks(t *testing.T) {
+	config := testConfig(t)
+	config.URL = "https://www.example.org/gvg"
+	page := `<html><body>
+<a href="koeln/index.htm">relativ</a>
+<a href="./soest/index.htm">punkt</a>
+<a href="/gvg/unna/index.htm">wurzelrelativ</a>
+<a href="https://www.example.org/gvg/hamm/index.htm#oben">absolut</a>
+<a href="./koeln/index.htm">doppelt</a>
+</body></html>`
+	links, err := extractLinks(page, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"koeln/index.htm", "soest/index.htm", "unna/index.htm", "hamm/index.htm", "koeln/index.htm"}
+	if strings.Join(links, " ") != strings.Join(want, " ") {
+		t.Errorf("extractLinks = %v, erwartet %v", links, want)
+	}
+
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"koeln/index.htm", "https://www.example.org/gvg/koeln/index.htm"},
+		{"./koeln/index.htm", "https://www.example.org/gvg/koeln/index.htm"},
+		{"/andere/index.htm", "https://www.example.org/andere/index.htm"},
+		{"../index.htm", "https://www.example.org/index.htm"},
+		{"https://mirror.example.net/koeln/index.htm", "https://mirror.example.net/koeln/index.htm"},
+	}
+	for _, tt := range tests {
+		if got := resolveLinkURL(config.URL, tt.link); got != tt.want {
+			t.Errorf("resolveLinkURL(%q) = %q, erwartet %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := testConfig(t)
+	valid.URL = "https://www.example.org/gvg/"
+	valid.MastodonServer = "https://mastodon.example.org"
+	valid.MastodonAccessToken = "token"
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("gültige Konfiguration abgelehnt: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"leere URL", func(c *Config) { c.URL = "" }, "url ist nicht gesetzt"},
+		{"URL ohne Host", func(c *Config) { c.URL = "gvg/index.htm" }, "url ist keine gültige URL"},
+		{"Intervall null", func(c *Config) { c.CheckInterval = 0 }, "check_interval muss positiv sein"},
+		{"Intervall in Sekunden", func(c *Config) { c.CheckInterval = 3600 }, "check_interval ist kürzer als eine Minute"},
+		{"Jitter zu groß", func(c *Config) { c.CheckJitter = c.CheckInterval }, "check_jitter"},
+		{"Timeout in Sekunden", func(c *Config) { c.HTTPTimeout = 30 }, "http_timeout ist kürzer als eine Sekunde"},
+		{"keine Datendatei", func(c *Config) { c.DataFile = "" }, "data_file ist nicht gesetzt"},
+		{"keine Plattform", func(c *Config) { c.MastodonServer = ""; c.MastodonAccessToken = "" }, "vollständig konfiguriert"},
+		{"Mastodon-Server", func(c *Config) { c.MastodonServer = "mastodon.example.org" }, "mastodon_server ist keine gültige URL"},
+		{"Sichtbarkeit", func(c *Config) { c.MastodonVisibility = "publik" }, `Unbekannte mastodon_visibility "publik"`},
+		{"Lemmy-Server", func(c *Config) {
+			c.LemmyServer = "lemmy.example.org"
+			c.LemmyCommunity = "gvg"
+			c.LemmyUsername = "bot"
+			c.LemmyPassword = "geheim"
+		}, "Lemmy-Server ist keine gültige URL"},
+		{"Lemmy-Platzhalter", func(c *Config) {
+			c.LemmyServer = "https://lemmy.example.org"
+			c.LemmyCommunity = "gvg"
+			c.LemmyUsername = "bot"
+			c.LemmyPassword = "CHANGEME"
+		}, "Platzhalter CHANGEME"},
+		{"Discord-Webhook", func(c *Config) { c.DiscordWebhookURL = "discord" }, "discord_webhook_url ist keine gültige URL"},
+		{"Verzeichnis doppelt", func(c *Config) {
+			c.WatchDirs = []string{"soest"}
+			c.IgnoreDirs = []string{"Soest"}
+		}, "watch_dirs und ignore_dirs"},
+		{"Status-URL", func(c *Config) { c.StatusBaseURL = "status" }, "status_base_url ist keine gültige URL"},
+		{"Quelle", func(c *Config) { c.Sources = []SourceConfig{{URL: "ftp://example.org/"}} }, "sources[0]: url ist keine gültige URL"},
+		{"Vorlage", func(c *Config) { c.PlatformTemplates = map[string]PostTemplate{"myspace": {}} }, "unbekannte Plattform myspace"},
+	}
+	for _, tt := range tests {
+		config := valid
+		tt.modify(&config)
+		err := config.Validate()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Fehler %v, erwartet %q", tt.name, err, tt.want)
+		}
+	}
+
+	// Alle Probleme werden gemeinsam gemeldet
+	config := valid
+	config.URL = ""
+	config.MastodonVisibility = "publik"
+	err := config.Validate()
+	if err == nil || !strings.Contains(err.Error(), "2 Probleme") {
+		t.Errorf("zwei Probleme: %v", err)
+	}
+}
+
+func TestLemmyCommunityResponseShapes(t *testing.T) {
+	// lemmyFixtureServer beantwortet den direkten Abruf mit community und die Suche mit search
+	lemmyFixtureServer := func(community, search string) *httptest.Server {
+		serve := func(fixture string) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				body, err := os.ReadFile(filepath.Join("testdata", fixture))
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(body)
+			}
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/api/v3/community", serve(community))
+		mux.Handle("/api/v3/search", serve(search))
+		server := httptest.NewServer(mux)
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	tests := []struct {
+		community string
+		name      string
+		want      int
+	}{
+		{"lemmy_community_view.json", "grundstueckverkehr", 42},
+		{"lemmy_community_flat.json", "grundstueckverkehr", 43},
+		// Ohne Community in der Antwort wird gesucht, lokale Treffer gehen vor
+		{"lemmy_community_missing.json", "grundstueckverkehr", 44},
+		{"lemmy_community_missing.json", "grundstueckverkehr@feddit.example.net", 7},
+	}
+	for _, tt := range tests {
+		server := lemmyFixtureServer(tt.community, "lemmy_search_communities.json")
+		id, err := lemmyGetCommunityID(server.URL, "jwt", tt.name)
+		if err != nil || id != tt.want {
+			t.Errorf("%s, %s: ID %d (%v), erwartet %d", tt.community, tt.name, id, err, tt.want)
+		}
+	}
+
+	server := lemmyFixtureServer("lemmy_community_missing.json", "lemmy_community_missing.json")
+	if _, err := lemmyGetCommunityID(server.URL, "jwt", "grundstueckverkehr"); err == nil || !strings.Contains(err.Error(), "nicht gefunden") {
+		t.Errorf("weder Abruf noch Suche: %v", err)
+	}
+}
+
+func TestExtractLists(t *testing.T) {
+	page, err := os.ReadFile("testdata/listing_list.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	title, text, err := extractTextBetweenHR(string(page), 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Verkauf landwirtschaftlicher Flächen\n\n" +
+		"Betroffen sind folgende Grundstücke:\n\n" +
+		"- Gemarkung Elsen, **Flurstück 12**, 1,4 ha\n" +
+		"- Gemarkung Elsen, Flurstück 13\n" +
+		"  - davon *Grünland* 0,6 ha\n\n" +
+		"- Kaufpreis liegt vor\n" +
+		"- Frist: **30.04.2026**\n\n" +
+		"Vorkaufsberechtigte Landwirte melden sich bitte."
+	if title != "Verkauf landwirtschaftlicher Flächen" || text != want {
+		t.Errorf("Titel %q, Text:\n%s\nerwartet:\n%s", title, text, want)
+	}
+}
+
+func TestExtractNestedInlineTags(t *testing.T) {
+	tests := []struct {
+		html string
+		want string
+	}{
+		{`<hr><p>Käufer: <strong><span>Max</span> Mustermann</strong></p><hr>`, "Käufer: **Max Mustermann**"},
+		{`<hr><p>Fläche <em><span><b>2,3</b></span> ha</em></p><hr>`, "Fläche ***2,3** ha*"},
+		{`<hr><p><strong>Frist <a href="#">bis <span>30.04.</span></a></strong> beachten</p><hr>`, "**Frist bis 30.04.** beachten"},
+		{`<hr><p><b><i>Achtung</i></b></p><hr>`, "***Achtung***"},
+	}
+	for _, tt := range tests {
+		if _, text, err := extractTextBetweenHR(tt.html, 1, 2); err != nil || text != tt.want {
+			t.Errorf("%s: %q (%v), erwartet %q", tt.html, text, err, tt.want)
+		}
+	}
+}
+
+func TestLinkStoreMethods(t *testing.T) {
+	stores := map[string]func(t *testing.T) LinkStore{
+		"json": func(t *testing.T) LinkStore {
+			return jsonLinkStore{filename: filepath.Join(t.TempDir(), "links.json")}
+		},
+		"mem": func(t *testing.T) LinkStore {
+			store, err := newMemLinkStore(newLinkData())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return store
+		},
+		"sqlite": func(t *testing.T) LinkStore {
+			db, err := openSQLiteDB(":memory:")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return &sqliteLinkStore{db: db, source: "links.json"}
+		},
+	}
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			defer store.Close()
+			first := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+			data := newLinkData()
+			data.addLink(LinkRecord{Href: "bielefeld/index.htm", City: "Bielefeld", FirstSeen: first})
+			data.PendingLinks = []string{"soest/index.htm"}
+			data.RemovedLinks["soest/index.htm"] = first
+			if err := store.Save(data); err != nil {
+				t.Fatal(err)
+			}
+
+			if seen, err := store.IsSeen("bielefeld/index.htm"); err != nil || !seen {
+				t.Errorf("IsSeen(bielefeld) = %v, %v", seen, err)
+			}
+			if seen, err := store.IsSeen("soest/index.htm"); err != nil || seen {
+				t.Errorf("IsSeen(soest) = %v, %v", seen, err)
+			}
+
+			// Ein fehlgeschlagener Link wird nur einmal vorgemerkt und gezählt
+			for range 2 {
+				if err := store.MarkFailed("soest/index.htm"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			loaded, err := store.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(loaded.FailedLinks) != 1 || loaded.FailedAttempts["soest/index.htm"].Count != 1 {
+				t.Errorf("nach MarkFailed: %v, %+v", loaded.FailedLinks, loaded.FailedAttempts)
+			}
+
+			// MarkSeen entfernt den Link aus den fehlgeschlagenen, zurückgestellten und entfernten
+			// Links und behält einen vorhandenen FirstSeen-Zeitpunkt
+			if err := store.MarkSeen(LinkRecord{Href: "soest/index.htm", City: "Soest", FirstSeen: first, PostedTo: []string{"mastodon"}}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.MarkSeen(LinkRecord{Href: "bielefeld/index.htm", City: "Bielefeld", FirstSeen: first.Add(time.Hour)}); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err = store.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := loaded.hrefs(); len(got) != 2 || got[0] != "bielefeld/index.htm" || got[1] != "soest/index.htm" {
+				t.Errorf("Links nach MarkSeen: %v", got)
+			}
+			if len(loaded.FailedLinks) != 0 || len(loaded.PendingLinks) != 0 || len(loaded.RemovedLinks) != 0 {
+				t.Errorf("nach MarkSeen: fehlgeschlagen %v, zurückgestellt %v, entfernt %v", loaded.FailedLinks, loaded.PendingLinks, loaded.RemovedLinks)
+			}
+			if record := loaded.record("bielefeld/index.htm"); !record.FirstSeen.Equal(first) {
+				t.Errorf("FirstSeen überschrieben: %v", record.FirstSeen)
+			}
+			if record := loaded.record("soest/index.htm"); record.City != "Soest" || len(record.PostedTo) != 1 {
+				t.Errorf("gespeicherter Link: %+v", record)
+			}
+
+			removed, err := store.RemoveMissing([]string{"soest/index.htm"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(removed) != 1 || removed[0].Href != "bielefeld/index.htm" {
+				t.Errorf("entfernt: %+v", removed)
+			}
+			loaded, err = store.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(loaded.Links) != 1 || loaded.record("soest/index.htm") == nil {
+				t.Errorf("Links nach RemoveMissing: %v", loaded.hrefs())
+			}
+			if _, ok := loaded.RemovedLinks["bielefeld/index.htm"]; !ok {
+				t.Errorf("Entfernung nicht vermerkt: %v", loaded.RemovedLinks)
+			}
+		})
+	}
+}
+
+// linkStoreSpy zählt, welche Einzelmethoden eines LinkStore ein Durchlauf aufruft
+type linkStoreSpy struct {
+	LinkStore
+	seen, marked, failed, removed int
+}
+
+func (s *linkStoreSpy) IsSeen(href string) (bool, error) {
+	s.seen++
+	return s.LinkStore.IsSeen(href)
+}
+
+func (s *linkStoreSpy) MarkSeen(record LinkRecord) error {
+	s.marked++
+	return s.LinkStore.MarkSeen(record)
+}
+
+func (s *linkStoreSpy) MarkFailed(href string) error {
+	s.failed++
+	return s.LinkStore.MarkFailed(href)
+}
+
+func (s *linkStoreSpy) RemoveMissing(currentLinks []string) ([]LinkRecord, error) {
+	s.removed++
+	return s.LinkStore.RemoveMissing(currentLinks)
+}
+
+func TestCheckSourceUsesLinkStoreMethods(t *testing.T) {
+	source := newFakeSource(t)
+	config := testConfig(t)
+	config.URL = source.URL
+	withPlatformFakes(t, &config)
+	known := source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	data := newLinkData()
+	data.addLink(LinkRecord{Href: known, City: "Bielefeld"})
+	data.addLink(LinkRecord{Href: "hamm/index.htm", City: "Hamm"})
+	mem, err := newMemLinkStore(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &linkStoreSpy{LinkStore: mem}
+	source.setListing("Soest", "Grünland", "Verkauf von Grünland an einen Nicht-Landwirt.")
+	source.setPage("minden/index.htm", "<html><body></body></html>")
+
+	result, _, err := checkSource(context.Background(), &config, store, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Posted != 1 || result.Failed != 1 || result.Removed != 1 {
+		t.Fatalf("Ergebnis %+v", result)
+	}
+	if store.seen != 2 || store.marked != 1 || store.failed != 1 || store.removed != 1 {
+		t.Errorf("IsSeen %d, MarkSeen %d, MarkFailed %d, RemoveMissing %d; erwartet 2, 1, 1, 1", store.seen, store.marked, store.failed, store.removed)
+	}
+}
+
+func TestCheckSourceWithMemLinkStore(t *testing.T) {
+	source := newFakeSource(t)
+	config := testConfig(t)
+	config.URL = source.URL
+	fakes := withPlatformFakes(t, &config)
+	known := source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	data := newLinkData()
+	data.addLink(LinkRecord{Href: known, City: "Bielefeld"})
+	data.addLink(LinkRecord{Href: "hamm/index.htm", City: "Hamm"})
+	store, err := newMemLinkStore(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	added := source.setListing("Soest", "Grünland", "Verkauf von Grünland an einen Nicht-Landwirt.")
+
+	result, _, err := checkSource(context.Background(), &config, store, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Checked != 2 || result.New != 1 || result.Posted != 1 || result.Removed != 1 {
+		t.Errorf("Ergebnis %+v, erwartet 2 geprüft, 1 neu, 1 gepostet, 1 entfernt", result)
+	}
+	if n := len(fakes.mastodon.Statuses()); n != 1 {
+		t.Errorf("%d Mastodon-Posts, erwartet 1", n)
+	}
+	saved, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.record(added) == nil {
+		t.Errorf("neuer Link %s nicht im Speicher", added)
+	}
+	if _, err := os.Stat(config.DataFile); !os.IsNotExist(err) {
+		t.Errorf("Datendatei geschrieben: %v", err)
+	}
+
+	// Jeder Load liefert eine unabhängige Kopie
+	saved.addLink(LinkRecord{Href: "unna/index.htm"})
+	again, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.record("unna/index.htm") != nil {
+		t.Error("Änderung an geladenen Daten landet ohne Save im Speicher")
+	}
+
+	// Ein zweiter Durchlauf findet nichts Neues mehr
+	result, _, err = checkSource(context.Background(), &config, store, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.New != 0 || len(fakes.mastodon.Statuses()) != 1 {
+		t.Errorf("zweiter Durchlauf: %+v, %d Posts", result, len(fakes.mastodon.Statuses()))
+	}
+}
+
+// fakePoster ist ein Poster, der je Link vorgegebene Fehler der Reihe nach zurückgibt
+type fakePoster struct {
+	name string
+	errs map[string][]error
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (f *fakePoster) Name() string { return f.name }
+
+func (f *fakePoster) Post(ctx context.Context, p preparedPost) (RemotePost, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, p.link)
+	if queue := f.errs[p.link]; len(queue) > 0 {
+		f.errs[p.link] = queue[1:]
+		if queue[0] != nil {
+			return RemotePost{}, queue[0]
+		}
+	}
+	return RemotePost{ID: p.link, URL: "https://example.org/" + p.link}, nil
+}
+
+func (f *fakePoster) ReceiptText(p preparedPost) string { return p.title }
+
+func TestPublishPreparedWithFakePoster(t *testing.T) {
+	config := testConfig(t)
+	posts := []preparedPost{{link: "a", title: "A"}, {link: "b", title: "B"}, {link: "c", title: "C"}, {link: "d", title: "D"}}
+	permanent := errors.New("HTTP 500")
+	flaky := &fakePoster{name: "flaky", errs: map[string][]error{
+		// Kurzes Retry-After wird abgewartet und der Post wiederholt
+		"a": {&RateLimitError{Platform: "flaky", RetryAfter: 10 * time.Millisecond}},
+		"b": {permanent, permanent},
+	}}
+	blocked := &fakePoster{name: "blocked", errs: map[string][]error{
+		"a": {&RateLimitError{Platform: "blocked", RetryAfter: time.Hour}},
+	}}
+	down := &fakePoster{name: "down"}
+	posters := []platformPoster{
+		{Poster: flaky, label: "Flaky"},
+		{Poster: blocked, label: "Blocked"},
+		{Poster: down, label: "Down", unavailable: errors.New("Kein gültiges Token")},
+	}
+	receipts := receiptIndex{"flaky": {"d": time.Now()}}
+
+	outcomes, created := publishPrepared(context.Background(), config, posters, posts, receipts, false)
+
+	if got := strings.Join(flaky.calls, ","); got != "a,a,b,c" {
+		t.Errorf("Aufrufe flaky: %s, erwartet a,a,b,c", got)
+	}
+	errs := outcomes["flaky"]
+	if errs[0] != nil || !errors.Is(errs[1], permanent) || errs[2] != nil || errs[3] != nil {
+		t.Errorf("Ergebnisse flaky: %v", errs)
+	}
+	if fmt.Sprint(created["flaky"]) != "[true false true false]" {
+		t.Errorf("erstellt flaky: %v, erwartet nur a und c (d hat bereits einen Nachweis)", created["flaky"])
+	}
+
+	// Lange Sperren stellen die übrigen Posts zurück
+	var rl *RateLimitError
+	errs = outcomes["blocked"]
+	if len(blocked.calls) != 1 || !errors.As(errs[0], &rl) || !errors.Is(errs[1], errPlatformDeferred) || !errors.Is(errs[3], errPlatformDeferred) {
+		t.Errorf("blocked: Aufrufe %v, Ergebnisse %v", blocked.calls, errs)
+	}
+
+	// Nicht verfügbare Plattformen werden nicht aufgerufen
+	if len(down.calls) != 0 || outcomes["down"][0] == nil || outcomes["down"][3] == nil {
+		t.Errorf("down: Aufrufe %v, Ergebnisse %v", down.calls, outcomes["down"])
+	}
+
+	// Wird der Kontext während der Pause zwischen zwei Posts abgebrochen, werden die
+	// restlichen Posts zurückgestellt
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	slow := &fakePoster{name: "slow"}
+	outcomes, _ = publishPrepared(ctx, config, []platformPoster{{Poster: slow, label: "Slow", delay: time.Hour}}, posts, receiptIndex{}, false)
+	if len(slow.calls) != 1 || outcomes["slow"][0] != nil || !errors.Is(outcomes["slow"][1], errPlatformDeferred) {
+		t.Errorf("abgebrochen: Aufrufe %v, Ergebnisse %v", slow.calls, outcomes["slow"])
+	}
+}
+
+func TestHTTPTimeout(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+	config := testConfig(t)
+	config.HTTPTimeout = 200 * time.Millisecond
+	if err := saveConfig(config, config.configFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { applyConfig(DefaultConfig()) })
+	ctx := context.Background()
+
+	start := time.Now()
+	if _, err := fetchURL(ctx, slow.URL+"/", 0, nil); err == nil {
+		t.Error("fetchURL ohne Fehler")
+	}
+	if _, _, err := mastodonLogin(slow.URL, "client", "secret", "bot", "geheim"); err == nil {
+		t.Error("mastodonLogin ohne Fehler")
+	}
+	if _, err := lemmyGetCommunityID(slow.URL, "jwt", "gvg"); err == nil {
+		t.Error("lemmyGetCommunityID ohne Fehler")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Anfragen dauerten %v, das Timeout von 200ms greift nicht", elapsed)
+	}
+
+	// Neu geladene Tokens ändern die globalen Einstellungen nicht
+	reloadTokens(&config)
+	if httpClient.Timeout != 200*time.Millisecond {
+		t.Errorf("Timeout nach reloadTokens: %v", httpClient.Timeout)
+	}
+
+	// Ohne Angabe gilt der Standard
+	loaded, err := readConfig(filepath.Join(t.TempDir(), "fehlt.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.HTTPTimeout != defaultHTTPTimeout {
+		t.Errorf("Standard-Timeout %v, erwartet %v", loaded.HTTPTimeout, defaultHTTPTimeout)
+	}
+}
+
+func TestProxyURL(t *testing.T) {
+	var mu sync.Mutex
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proxied = append(proxied, r.URL.String())
+		mu.Unlock()
+		fmt.Fprint(w, "<html><body>über den Proxy</body></html>")
+	}))
+	defer proxy.Close()
+	config := testConfig(t)
+	config.ProxyURL = proxy.URL
+	if err := applyConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { applyConfig(DefaultConfig()) })
+
+	// Der Host existiert nicht; die Antwort kann nur vom Proxy kommen
+	body, err := fetchURL(context.Background(), "http://gvg.example.invalid/bielefeld/index.htm", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body, "über den Proxy") {
+		t.Errorf("Antwort %q", body)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(proxied) != 1 || proxied[0] != "http://gvg.example.invalid/bielefeld/index.htm" {
+		t.Errorf("Anfragen am Proxy: %v", proxied)
+	}
+
+	// Ohne proxy_url gelten die Proxy-Umgebungsvariablen des Standard-Transports
+	if transport, err := proxyTransport(""); err != nil || transport != http.DefaultTransport {
+		t.Errorf("ohne proxy_url: %v, %v", transport, err)
+	}
+	if _, err := proxyTransport("socks5://127.0.0.1:1080"); err != nil {
+		t.Errorf("socks5: %v", err)
+	}
+	for _, invalid := range []string{"ftp://proxy.example.org", "proxy.example.org:3128"} {
+		if _, err := proxyTransport(invalid); err == nil {
+			t.Errorf("%s ohne Fehler", invalid)
+		}
+	}
+}
+
+// countingDialer zählt die aufgebauten TCP-Verbindungen
+type countingDialer struct {
+	dials atomic.Int32
+}
+
+func (d *countingDialer) transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		d.dials.Add(1)
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return transport
+}
+
+func TestConnectionReuse(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body>"+strings.Repeat("Ackerfläche ", 1000)+"</body></html>")
+	})
+	mux.HandleFunc("/fehler", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, strings.Repeat("Interner Fehler ", 100), http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusUnauthorized)
+	})
+	mux.HandleFunc("/api/v3/community", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"couldnt_find_community"}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("/api/v3/search", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"communities":[]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var dialer countingDialer
+	previous := httpClient.Transport
+	httpClient.Transport = rateLimitedTransport{dialer.transport()}
+	t.Cleanup(func() { httpClient.Transport = previous })
+	ctx := context.Background()
+
+	// Erfolgreiche und fehlgeschlagene Anfragen nacheinander über dieselbe Verbindung
+	for i := 0; i < 3; i++ {
+		if _, err := fetchURL(ctx, server.URL+"/", 0, nil); err != nil {
+			t.Fatal(err)
+		}
+		// Zu große Seiten werden nur bis zum Limit gelesen, der Rest wird verworfen
+		if _, err := fetchURL(ctx, server.URL+"/", 1024, nil); err == nil {
+			t.Fatal("fetchURL über dem Limit ohne Fehler")
+		}
+		if _, err := fetchURL(ctx, server.URL+"/fehler", 0, nil); err == nil {
+			t.Fatal("fetchURL /fehler ohne Fehler")
+		}
+		if _, _, err := mastodonLogin(server.URL, "client", "secret", "bot", "falsch"); err == nil {
+			t.Fatal("mastodonLogin ohne Fehler")
+		}
+		if _, err := lemmyGetCommunityID(server.URL, "jwt", "gvg"); err == nil {
+			t.Fatal("lemmyGetCommunityID ohne Fehler")
+		}
+	}
+	if n := dialer.dials.Load(); n != 1 {
+		t.Errorf("%d Verbindungen aufgebaut, erwartet 1", n)
+	}
+}
+
+func TestExtractCityNameBreadcrumbs(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    string
+	}{
+		{"breadcrumb_list.html", "Castrop-Rauxel"},
+		{"breadcrumb_anchors.html", "Lippstadt"},
+		{"breadcrumb_sie_sind_hier.html", "Bad Sassendorf"},
+	}
+	for _, tt := range tests {
+		page, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := extractCityName(string(page)); got != tt.want {
+			t.Errorf("%s: %q, erwartet %q", tt.fixture, got, tt.want)
+		}
+	}
+
+	// Ohne Überschrift und Brotkrumen bleibt der Name leer
+	if got := extractCityName("<html><body><p>Sie sind hier</p></body></html>"); got != "" {
+		t.Errorf("ohne Brotkrumen: %q", got)
+	}
+	// "Sie sind hier:" als einzelne Textzeile mit ">" als Trenner
+	if got := extractCityName("<html><body><p>Sie sind hier: Start > GVG > Soest</p></body></html>"); got != "Soest" {
+		t.Errorf("Textzeile: %q", got)
+	}
+	// Die Überschrift hat Vorrang vor den Brotkrumen
+	if got := extractCityName(`<html><body><h1>Werl</h1><nav class="breadcrumb"><a>Soest</a></nav></body></html>`); got != "Werl" {
+		t.Errorf("mit Überschrift: %q", got)
+	}
+}
+
+func TestWatchDirs(t *testing.T) {
+	page := `<html><body>
+<a href="soest/index.htm">Soest</a>
+<a href="Unna/index.htm">Unna</a>
+<a href="guetersloh/index.htm">Gütersloh</a>
+<a href="hamm/index.htm">Hamm</a>
+</body></html>`
+	tests := []struct {
+		name   string
+		watch  []string
+		ignore []string
+		want   string
+	}{
+		{"ohne Listen", nil, nil, "soest/index.htm Unna/index.htm guetersloh/index.htm hamm/index.htm"},
+		{"nur Denylist", nil, []string{"guetersloh"}, "soest/index.htm Unna/index.htm hamm/index.htm"},
+		{"leere Allowlist", []string{}, []string{"guetersloh"}, "soest/index.htm Unna/index.htm hamm/index.htm"},
+		{"Allowlist", []string{"soest", "unna", "guetersloh"}, nil, "soest/index.htm Unna/index.htm guetersloh/index.htm"},
+		// Zuerst die Allowlist, danach schließt die Denylist weiter aus
+		{"beide", []string{"soest", "unna", "guetersloh"}, []string{"guetersloh"}, "soest/index.htm Unna/index.htm"},
+	}
+	for _, tt := range tests {
+		config := testConfig(t)
+		config.URL = "https://www.example.org/gvg/"
+		config.WatchDirs = tt.watch
+		config.IgnoreDirs = tt.ignore
+		links, err := extractLinks(page, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(links, " "); got != tt.want {
+			t.Errorf("%s: %s, erwartet %s", tt.name, got, tt.want)
+		}
+	}
+
+	// Links ohne Verzeichnis zählen nur ohne Allowlist
+	config := testConfig(t)
+	if !watchedDir("index.htm", config) {
+		t.Error("Link ohne Verzeichnis ohne Allowlist ausgeschlossen")
+	}
+	config.WatchDirs = []string{"soest"}
+	if watchedDir("index.htm", config) {
+		t.Error("Link ohne Verzeichnis trotz Allowlist überwacht")
+	}
+}
+
+func TestListExportPruneAllSources(t *testing.T) {
+	config := testConfig(t)
+	config.URL = "https://www.example.org/gvg"
+	config.Sources = []SourceConfig{{URL: "https://www.example.org/gvg-owl", DataFile: filepath.Join(t.TempDir(), "owl.json")}}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	sources := sourceConfigs(config)
+	for i, href := range []string{"soest/index.htm", "minden/index.htm"} {
+		data := newLinkData()
+		data.addLink(LinkRecord{Href: href, FirstSeen: now.Add(-time.Hour)})
+		data.RemovedLinks["alt/index.htm"] = now.Add(-100 * 24 * time.Hour)
+		if err := saveSourceData(sources[i], data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var list strings.Builder
+	if err := listSources(&list, config, 0, now); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Quelle: https://www.example.org/gvg\n", "soest/index.htm", "Quelle: https://www.example.org/gvg-owl\n", "minden/index.htm"} {
+		if !strings.Contains(list.String(), want) {
+			t.Errorf("list enthält %q nicht:\n%s", want, list.String())
+		}
+	}
+
+	csvFile := filepath.Join(t.TempDir(), "links.csv")
+	if err := exportLinksCSV(config, csvFile, 0, now); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(csvFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"soest/index.htm,https://www.example.org/gvg/soest/index.htm,", "minden/index.htm,https://www.example.org/gvg-owl/minden/index.htm,"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("CSV enthält %q nicht:\n%s", want, content)
+		}
+	}
+
+	if pruned, err := pruneSources(config, 90*24*time.Hour, true, now); err != nil || pruned != 2 {
+		t.Errorf("Testmodus: %d Einträge (%v), erwartet 2", pruned, err)
+	}
+	if pruned, err := pruneSources(config, 90*24*time.Hour, false, now); err != nil || pruned != 2 {
+		t.Errorf("%d Einträge entfernt (%v), erwartet 2", pruned, err)
+	}
+	for _, source := range sources {
+		data, err := loadSourceData(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data.RemovedLinks) != 0 {
+			t.Errorf("%s: entfernte Links nicht bereinigt: %v", source.URL, data.RemovedLinks)
+		}
+	}
+}
+
+func TestQuietFlag(t *testing.T) {
+	var out strings.Builder
+	logOutput = &out
+	t.Cleanup(func() {
+		logOutput = os.Stderr
+		logLevel = "info"
+		configureLogging()
+	})
+	fs := newFlagSet("check")
+	if err := fs.Parse([]string{"-quiet"}); err != nil {
+		t.Fatal(err)
+	}
+
+	slog.Info("Überprüfe Website")
+	log.Printf("Neuer Link gefunden")
+	slog.Warn("Rate-Limit erreicht")
+	logSummary(CheckResult{Checked: 3, New: 1, Posted: 1}.Summary())
+
+	for _, hidden := range []string{"Überprüfe Website", "Neuer Link gefunden"} {
+		if strings.Contains(out.String(), hidden) {
+			t.Errorf("Ausgabe mit -quiet enthält %q:\n%s", hidden, out.String())
+		}
+	}
+	for _, shown := range []string{"WARN Rate-Limit erreicht", "run complete: checked=3 new=1 posted=1 failed=0 removed=0"} {
+		if !strings.Contains(out.String(), shown) {
+			t.Errorf("Ausgabe mit -quiet enthält %q nicht:\n%s", shown, out.String())
+		}
+	}
+}
+
+func TestPlatformOutageDoesNotAbandonLink(t *testing.T) {
+	source := newFakeSource(t)
+	discord := fakeserver.NewDiscord()
+	config := sourceConfig(t, source, discord)
+	config.MaxFailedAttempts = 2
+	href := source.setListing("Bielefeld", "Ackerfläche", "Verkauf einer Ackerfläche an einen Nicht-Landwirt.")
+	broken := "minden/index.htm"
+	source.setPage(broken, "<html><body></body></html>")
+	ctx := context.Background()
+
+	// Discord fällt länger aus, als MaxFailedAttempts Durchläufe erlauben
+	discord.FailNext("/webhook", http.StatusServiceUnavailable, -1)
+	for run := 0; run < config.MaxFailedAttempts+2; run++ {
+		if _, err := checkWebsite(ctx, config, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := loadSourceData(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record := data.record(href); record != nil {
+		t.Fatalf("%s trotz Ausfall gespeichert: %+v", href, record)
+	}
+	if !containsString(data.FailedLinks, href) || data.FailedAttempts[href].Count != 0 {
+		t.Errorf("%s: fehlgeschlagen %v, Versuche %+v", href, data.FailedLinks, data.FailedAttempts[href])
+	}
+	// Ein Link ohne extrahierbaren Text wird dagegen aufgegeben
+	if record := data.record(broken); record == nil || record.Skipped != skipAbandoned {
+		t.Errorf("%s nicht aufgegeben: %+v", broken, record)
+	}
+
+	// Nach dem Ausfall wird der Link gepostet
+	discord.FailNext("/webhook", http.StatusServiceUnavailable, 0)
+	if _, err := checkWebsite(ctx, config, false); err != nil {
+		t.Fatal(err)
+	}
+	if embeds := discord.Embeds(); len(embeds) != 1 {
+		t.Errorf("%d Discord-Posts nach dem Ausfall, erwartet 1", len(embeds))
+	}
+}